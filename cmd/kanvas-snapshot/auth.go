@@ -0,0 +1,83 @@
+package kanvas_snapshot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+)
+
+// isHTMLResponse reports whether the body is an HTML page rather than an API response
+func isHTMLResponse(body []byte) bool {
+	return strings.Contains(string(body), "<!DOCTYPE html>") || strings.Contains(string(body), "<html")
+}
+
+// isAuthFailure reports whether the response indicates Meshery rejected the credentials
+func isAuthFailure(resp *http.Response, body []byte) bool {
+	return resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || isHTMLResponse(body)
+}
+
+// authError classifies a rejected request into a dedicated authentication error
+func authError(resp *http.Response, body []byte) error {
+	if ProviderToken == "" {
+		return errors.ErrAuthenticationFailed(fmt.Errorf("no token provided"))
+	}
+
+	if expiry, ok := tokenExpiry(ProviderToken); ok && expiry.Before(time.Now()) {
+		return errors.ErrTokenExpired(fmt.Errorf("token expired at %s", expiry.Format(time.RFC3339)))
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return errors.ErrInsufficientPermissions(fmt.Errorf("server responded with %s", resp.Status))
+	}
+
+	// Meshery redirects to its provider selection page when it does not recognize the provider
+	if isHTMLResponse(body) && resp.Request != nil && strings.Contains(resp.Request.URL.Path, "/provider") {
+		return errors.ErrWrongProvider(fmt.Errorf("redirected to %s", resp.Request.URL.Path))
+	}
+
+	if strings.Contains(strings.ToLower(string(body)), "expired") {
+		return errors.ErrTokenExpired(fmt.Errorf("server reported the token as expired"))
+	}
+
+	if isHTMLResponse(body) {
+		return errors.ErrInvalidToken(fmt.Errorf("received login page instead of API response"))
+	}
+	return errors.ErrInvalidToken(fmt.Errorf("server responded with %s", resp.Status))
+}
+
+// tokenExpiry extracts the expiry time from a JWT, or from a base64 encoded
+// Meshery token wrapping a JWT. The signature is not verified.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		// Meshery UI tokens are base64 encoded JSON holding the access token
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return time.Time{}, false
+		}
+		var wrapped struct {
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.Unmarshal(decoded, &wrapped); err != nil || wrapped.AccessToken == "" {
+			return time.Time{}, false
+		}
+		return tokenExpiry(wrapped.AccessToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
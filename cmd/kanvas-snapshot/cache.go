@@ -0,0 +1,99 @@
+package kanvas_snapshot
+
+import (
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/cache"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// forceUpload bypasses the design cache
+var forceUpload bool
+
+// cachedUpload creates designs, reusing the design cached for an unchanged manifest
+type cachedUpload struct {
+	cache *cache.Cache
+	// disabled skips the cache lookup; designs created are still cached
+	disabled bool
+}
+
+// newCachedUpload returns an uploader using the design cache in the user cache directory.
+// Cache errors are logged and never fail the run.
+func newCachedUpload() *cachedUpload {
+	u := &cachedUpload{disabled: forceUpload}
+	path, err := cache.DefaultPath()
+	if err != nil {
+		Log.Warnf("Design cache disabled: %v", err)
+		return u
+	}
+	u.cache = cache.New(path)
+	return u
+}
+
+// design returns the cached design for the manifest, or creates a new one.
+// The returned entry holds the cached snapshot location if there is one.
+func (u *cachedUpload) design(content, name string) (entry cache.Entry, hash string, cached bool, err error) {
+	hash = manifest.Hash([]byte(content))
+	if entry, ok := u.lookup(hash, name); ok {
+		Log.Infof("Manifests of %s unchanged since %s, reusing design %s (use --force to upload again)", name, entry.CreatedAt.Local().Format(time.RFC3339), entry.DesignID)
+		return entry, hash, true, nil
+	}
+
+	Log.Infof("Creating Meshery design %s...", name)
+	designID, err := CreateMesheryDesign(content, name, email)
+	if err != nil {
+		return cache.Entry{}, hash, false, err
+	}
+	return cache.Entry{DesignID: designID, DesignName: name}, hash, false, nil
+}
+
+// lookup returns the cached design with the name for the hash. The design is checked in
+// Meshery first; a design that no longer exists is evicted and reported as a miss.
+func (u *cachedUpload) lookup(hash, name string) (cache.Entry, bool) {
+	if u.cache == nil || u.disabled {
+		return cache.Entry{}, false
+	}
+	entry, ok, err := u.cache.Get(MesheryAPIBaseURL, cacheKey(name, hash))
+	if err != nil {
+		Log.Warnf("Could not read design cache: %v", err)
+		return cache.Entry{}, false
+	}
+	if !ok {
+		Log.Debugf("No cached design %s for manifest hash %s", name, hash)
+		return cache.Entry{}, false
+	}
+
+	if _, err := FetchMesheryDesign(entry.DesignID); err != nil {
+		if !errors.IsDesignNotFound(err) {
+			// Checking is best effort, like every other cache failure
+			Log.Warnf("Could not check cached design %s, reusing it: %v", entry.DesignID, err)
+			return entry, true
+		}
+		Log.Infof("Cached design %s no longer exists in Meshery, creating it again", entry.DesignID)
+		if err := u.cache.Delete(MesheryAPIBaseURL, cacheKey(name, hash)); err != nil {
+			Log.Warnf("Could not update design cache: %v", err)
+		}
+		return cache.Entry{}, false
+	}
+	return entry, true
+}
+
+// store records the design and snapshot location created for the hash
+func (u *cachedUpload) store(hash string, entry cache.Entry) {
+	if u.cache == nil {
+		return
+	}
+	entry.CreatedAt = time.Now().UTC()
+	if err := u.cache.Put(MesheryAPIBaseURL, cacheKey(entry.DesignName, hash), entry); err != nil {
+		Log.Warnf("Could not update design cache: %v", err)
+		return
+	}
+	Log.Debugf("Cached design %s for manifest hash %s in %s", entry.DesignID, hash, u.cache.Path())
+}
+
+// cacheKey identifies a design by name and manifest hash, so the same manifests
+// uploaded under different names are cached separately
+func cacheKey(name, hash string) string {
+	return name + ":" + hash
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"io/fs"
 	"net/http"
 	"net/http/cookiejar"
 	"os"
@@ -18,26 +17,37 @@ import (
 	"github.com/layer5io/meshkit/logger"
 	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/config"
 	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/github"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/httpclient"
 	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/log"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/report"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/schema"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-const (
-	// Constants for default values
-	defaultMesheryURL = "https://playground.meshery.io"
-	apiEndpoint       = "/api/pattern/import" // Changed to match mesheryctl's endpoint
-)
-
 var (
 	// Global variables for configuration
 	ProviderToken          string
 	MesheryAPIBaseURL      string
 	MesheryCloudAPIBaseURL string
 	WorkflowAccessToken    string
-	Log                    log.Logger
+	// GitHubAPIBaseURL is the GitHub REST API workflows are dispatched and comments posted with
+	GitHubAPIBaseURL string
+	Log              log.Logger
 	// Configuration
 	Config *config.Config
+	// ConfigPath is the config file in use, empty if none was found
+	ConfigPath string
+	// ActiveProfile is the configuration profile applied, empty if none
+	ActiveProfile string
+	// ProviderName is the remote provider the token was issued by
+	ProviderName = "Meshery"
+	// SnapshotEndpoint is the Meshery API endpoint designs are imported with
+	SnapshotEndpoint string
+	// Settings holds the resolved settings and where each value came from
+	Settings *config.Resolved
 )
 
 var (
@@ -47,6 +57,16 @@ var (
 	designName   string
 	recursive    bool
 	skipWorkflow bool
+	outputFormat string
+	// mesheryctl context to read the Meshery URL and token from
+	mesheryContext string
+	// Configuration profile to apply
+	profileName string
+	// Config file selected with --config
+	configFile string
+	// Limits on the manifests read from a directory
+	maxFiles int
+	maxBytes int64
 	// GitHub workflow configuration
 	repoOwner  string
 	repoName   string
@@ -75,69 +95,56 @@ var generateKanvasSnapshotCmd = &cobra.Command{
 		-r, --recursive		Recursively process all manifest files in the directory
 		-e, --email     string	Email address to notify when snapshot is ready (optional)
 		    --name      string	(optional) Name for the Meshery design
+		-o, --output    string	(optional) Print the result as json or yaml on stdout
 		-h			Help for kubectl Kanvas Snapshot plugin`,
 
 	RunE: kanvasSnapshotRunE,
 }
 
-// getManifestContents reads the manifest file(s) and returns their contents
-func getManifestContents(path string, recursive bool) ([]string, error) {
-	var manifests []string
-
-	fileInfo, err := os.Stat(path)
+// loadManifests reads and parses the manifest file(s) at path
+func loadManifests(path string, recursive bool) ([]manifest.File, error) {
+	files, err := manifest.Load(path, manifestLoadOptions(recursive))
 	if err != nil {
 		return nil, errors.ErrReadingManifestFile(err)
 	}
-
-	if fileInfo.IsDir() {
-		manifests, err = processDirectory(path, recursive)
-		if err != nil {
-			return nil, errors.ErrReadingManifestFile(err)
-		}
-		if len(manifests) == 0 {
-			return nil, errors.ErrReadingManifestFile(fmt.Errorf("no YAML files found in the specified directory"))
-		}
-	} else {
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return nil, errors.ErrReadingManifestFile(err)
-		}
-		manifests = append(manifests, string(content))
+	if len(files) == 0 {
+		return nil, errors.ErrReadingManifestFile(fmt.Errorf("no YAML files found in the specified directory"))
 	}
 
-	return manifests, nil
+	for _, f := range files {
+		Log.Infof("Added manifest file: %s", f.Path)
+	}
+	return files, nil
 }
 
-// processDirectory finds all YAML and YML files in a directory
-func processDirectory(dirPath string, recursive bool) ([]string, error) {
-	var manifests []string
-	walkFn := func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories if not recursive
-		if info.IsDir() {
-			if path != dirPath && !recursive {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+// manifestLoadOptions returns the options manifests are loaded with, including the --max-files and --max-bytes limits
+func manifestLoadOptions(recursive bool) manifest.LoadOptions {
+	return manifest.LoadOptions{
+		Recursive: recursive,
+		MaxFiles:  maxFiles,
+		MaxBytes:  maxBytes,
+	}
+}
 
-		// Check if file is a YAML file
-		if strings.HasSuffix(strings.ToLower(path), ".yaml") || strings.HasSuffix(strings.ToLower(path), ".yml") {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			manifests = append(manifests, string(content))
-			Log.Infof("Added manifest file: %s", path)
+// combineManifests joins the contents of manifest files into one multi-document manifest
+func combineManifests(files []manifest.File) string {
+	var b strings.Builder
+	for i, f := range files {
+		if i > 0 {
+			b.WriteString("\n---\n")
 		}
-		return nil
+		b.Write(f.Content)
 	}
+	return b.String()
+}
 
-	err := filepath.Walk(dirPath, walkFn)
-	return manifests, err
+// manifestResources returns the resources parsed from all files
+func manifestResources(files []manifest.File) []manifest.Resource {
+	var resources []manifest.Resource
+	for _, f := range files {
+		resources = append(resources, f.Resources...)
+	}
+	return resources
 }
 
 // MesheryDesignPayload represents the payload for creating a design in Meshery
@@ -157,6 +164,15 @@ func ExtractNameFromPath(path string) string {
 
 // CreateMesheryDesign creates a new design in Meshery
 func CreateMesheryDesign(manifest, name, email string) (string, error) {
+	payloadBytes, err := newDesignPayload(manifest, name, email)
+	if err != nil {
+		return "", err
+	}
+	return postMesheryDesign(MesheryAPIBaseURL, SnapshotEndpoint, payloadBytes)
+}
+
+// newDesignPayload returns the request body importing the manifest as a design
+func newDesignPayload(manifest, name, email string) ([]byte, error) {
 	// Extract filename from manifestPath for the file_name field
 	fileName := filepath.Base(manifestPath)
 
@@ -177,16 +193,15 @@ func CreateMesheryDesign(manifest, name, email string) (string, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		Log.Errorf("Failed to marshal payload: %v", err)
-		return "", errors.ErrDecodingAPI(err)
-	}
-
-	endpoint := apiEndpoint
-	if Config != nil && Config.Meshery.SnapshotEndpoint != "" {
-		endpoint = Config.Meshery.SnapshotEndpoint
+		return nil, errors.ErrDecodingAPI(err)
 	}
+	return payloadBytes, nil
+}
 
+// postMesheryDesign sends a prepared design import request to the Meshery server and returns the design ID
+func postMesheryDesign(serverURL, endpoint string, payloadBytes []byte) (string, error) {
 	// Simple URL construction
-	fullURL := fmt.Sprintf("%s%s", MesheryAPIBaseURL, endpoint)
+	fullURL := fmt.Sprintf("%s%s", serverURL, endpoint)
 	Log.Infof("Sending request to: %s", fullURL)
 
 	// Create the request
@@ -200,25 +215,20 @@ func CreateMesheryDesign(manifest, name, email string) (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Add authentication if token is available
-	if ProviderToken != "" {
-		Log.Info("Using Meshery token for authentication")
-		cookieValue := fmt.Sprintf("token=%s;meshery-provider=Meshery", ProviderToken)
-		req.Header.Set("Cookie", cookieValue)
-	} else {
-		Log.Warn("No Meshery token provided, authentication will likely fail")
-	}
+	setAuthCookie(req)
 
 	// Create a client with the cookie jar
 	jar, _ := cookiejar.New(nil)
-	client := &http.Client{
-		Timeout: time.Second * 30,
-		Jar:     jar,
-	}
+	client := newHTTPClient()
+	client.Jar = jar
 
 	// Send the request
 	resp, err := client.Do(req)
 	if err != nil {
 		Log.Errorf("HTTP request failed: %v", err)
+		if httpclient.IsUnreachable(err) {
+			return "", errors.ErrMesheryUnreachable(serverURL, err)
+		}
 		return "", errors.ErrHTTPPostRequest(err)
 	}
 	defer resp.Body.Close()
@@ -234,16 +244,16 @@ func CreateMesheryDesign(manifest, name, email string) (string, error) {
 	Log.Infof("Response status: %s", resp.Status)
 	Log.Debugf("Response body: %s", string(body))
 
-	// Check if we got HTML instead of JSON (auth redirect)
-	if strings.Contains(string(body), "<!DOCTYPE html>") || strings.Contains(string(body), "<html") {
-		Log.Warn("Received HTML response instead of JSON - authentication failed")
-		return "", errors.ErrHTTPPostRequest(fmt.Errorf("authentication failed"))
+	// Check if authentication failed (401/403 or HTML login redirect instead of JSON)
+	if isAuthFailure(resp, body) {
+		Log.Warn("Meshery rejected the request - authentication failed")
+		return "", authError(resp, body)
 	}
 
 	// Check response status
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		Log.Warnf("Unexpected response code: %d", resp.StatusCode)
-		return "", errors.ErrHTTPPostRequest(fmt.Errorf("unexpected response code: %d", resp.StatusCode))
+		return "", errors.ErrUnexpectedResponseCode(resp.StatusCode, trimString(string(body), 200))
 	}
 
 	// First, try to parse the response as a JSON object
@@ -292,6 +302,17 @@ func CreateMesheryDesign(manifest, name, email string) (string, error) {
 	return designID, nil
 }
 
+// setAuthCookie adds the Meshery token to the request if one is available
+func setAuthCookie(req *http.Request) {
+	if ProviderToken != "" {
+		Log.Info("Using Meshery token for authentication")
+		cookieValue := fmt.Sprintf("token=%s;meshery-provider=%s", ProviderToken, ProviderName)
+		req.Header.Set("Cookie", cookieValue)
+	} else {
+		Log.Warn("No Meshery token provided, authentication will likely fail")
+	}
+}
+
 // Helper function to trim strings for logging
 func trimString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -300,53 +321,60 @@ func trimString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-// GenerateSnapshot publishes the design to Meshery's pattern catalog
-func GenerateSnapshot(designID, assetLocation, token string) error {
+// workflowTarget returns the GitHub repository, workflow and ref used for snapshot generation
+func workflowTarget() (owner, repo, workflow, ref string) {
+	return repoOwner, repoName, workflowID, branchName
+}
+
+// defaultAssetLocation returns the location the workflow publishes the snapshot image to
+func defaultAssetLocation(designID string) string {
+	return fmt.Sprintf("https://raw.githubusercontent.com/layer5labs/meshery-extensions-packages/master/action-assets/kubectl-plugin-assets/%s.png", designID)
+}
+
+// GenerateSnapshot publishes the design to Meshery's pattern catalog.
+// It returns the dispatched workflow, or nil if no token was provided.
+func GenerateSnapshot(designID, assetLocation, token string) (*WorkflowResult, error) {
 	if token == "" {
 		Log.Warn("GITHUB_TOKEN environment variable not set. Snapshot generation will be skipped.")
 		Log.Info("Please set GITHUB_TOKEN environment variable to trigger GitHub workflow.")
-		return nil
+		return nil, nil
 	}
 
 	// Generate direct URL to view in Meshery
 	mesheryViewURL := getDesignViewURL(designID)
 	Log.Infof("View your design in Meshery: %s", mesheryViewURL)
 
-	// Set default values for GitHub repository and workflow
-	repoOwnerValue := repoOwner
-	if repoOwnerValue == "" {
-		repoOwnerValue = "layer5labs"
-		Log.Infof("No repository owner specified, using default: %s", repoOwnerValue)
-	}
-
-	repoNameValue := repoName
-	if repoNameValue == "" {
-		repoNameValue = "kubectl-kanvas-snapshot"
-		Log.Infof("No repository name specified, using default: %s", repoNameValue)
-	}
-
-	workflowIDValue := workflowID
-	if workflowIDValue == "" {
-		workflowIDValue = "kanvas.yaml"
-		Log.Infof("No workflow ID specified, using default: %s", workflowIDValue)
-	}
-
 	// If assetLocation is not provided, generate a default one
 	if assetLocation == "" {
-		assetLocation = fmt.Sprintf("https://raw.githubusercontent.com/layer5labs/meshery-extensions-packages/master/action-assets/kubectl-plugin-assets/%s.png", designID)
+		assetLocation = defaultAssetLocation(designID)
 		Log.Infof("Using default asset location: %s", assetLocation)
 	}
 
+	// Resolve GitHub repository and workflow
+	repoOwnerValue, repoNameValue, workflowIDValue, refValue := workflowTarget()
+	dispatched := time.Now()
+	workflow, err := dispatchSnapshotWorkflow(repoOwnerValue, repoNameValue, workflowIDValue, refValue, designID, assetLocation, token)
+	if err != nil {
+		return nil, err
+	}
+	workflow.RunURL = findWorkflowRun(repoOwnerValue, repoNameValue, workflowIDValue, designID, token, dispatched)
+	return workflow, nil
+}
+
+// dispatchSnapshotWorkflow triggers the GitHub workflow rendering the snapshot of the design
+func dispatchSnapshotWorkflow(repoOwnerValue, repoNameValue, workflowIDValue, refValue, designID, assetLocation, token string) (*WorkflowResult, error) {
+	Log.Infof("Using workflow %s in %s/%s@%s", workflowIDValue, repoOwnerValue, repoNameValue, refValue)
+
 	// Trigger GitHub workflow using REST API
 	Log.Info("Triggering GitHub workflow to generate snapshot...")
 
 	// Construct the GitHub API URL to trigger workflow
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/actions/workflows/%s/dispatches",
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/actions/workflows/%s/dispatches", GitHubAPIBaseURL,
 		repoOwnerValue, repoNameValue, workflowIDValue)
 
 	// Prepare payload for workflow dispatch
 	payload := map[string]interface{}{
-		"ref": "master", // or any branch where the workflow is defined
+		"ref": refValue,
 		"inputs": map[string]string{
 			"designID":      designID, // Changed from contentID to designID
 			"assetLocation": assetLocation,
@@ -356,14 +384,14 @@ func GenerateSnapshot(designID, assetLocation, token string) error {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		Log.Errorf("Failed to marshal payload: %v", err)
-		return errors.ErrGeneratingSnapshot(err)
+		return nil, errors.ErrGeneratingSnapshot(err)
 	}
 
 	// Create the request
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		Log.Errorf("Failed to create request: %v", err)
-		return errors.ErrGeneratingSnapshot(err)
+		return nil, errors.ErrGeneratingSnapshot(err)
 	}
 
 	// Set headers for GitHub API
@@ -372,15 +400,16 @@ func GenerateSnapshot(designID, assetLocation, token string) error {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Create HTTP client
-	client := &http.Client{
-		Timeout: time.Second * 30,
-	}
+	client := newHTTPClient()
 
 	// Send the request
 	resp, err := client.Do(req)
 	if err != nil {
 		Log.Errorf("Failed to trigger workflow: %v", err)
-		return errors.ErrGeneratingSnapshot(err)
+		if httpclient.IsUnreachable(err) {
+			return nil, errors.ErrGitHubUnreachable(err)
+		}
+		return nil, errors.ErrGeneratingSnapshot(err)
 	}
 	defer resp.Body.Close()
 
@@ -388,14 +417,51 @@ func GenerateSnapshot(designID, assetLocation, token string) error {
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		Log.Errorf("Workflow trigger failed with status %d: %s", resp.StatusCode, string(body))
-		return errors.ErrGeneratingSnapshot(fmt.Errorf("workflow trigger failed with status %d: %s", resp.StatusCode, string(body)))
+		return nil, errors.ErrGeneratingSnapshot(fmt.Errorf("workflow trigger failed with status %d: %s", resp.StatusCode, string(body)))
 	}
 
 	Log.Info("Workflow triggered successfully!")
 	Log.Infof("Your design snapshot will be available at: %s", assetLocation)
 	Log.Info("This process may take a few minutes to complete...")
 
-	return nil
+	return &WorkflowResult{
+		Repository: fmt.Sprintf("%s/%s", repoOwnerValue, repoNameValue),
+		Workflow:   workflowIDValue,
+		Ref:        refValue,
+		RunsURL:    fmt.Sprintf("https://github.com/%s/%s/actions/workflows/%s", repoOwnerValue, repoNameValue, workflowIDValue),
+	}, nil
+}
+
+// Polling for the run started by a dispatch, which GitHub lists a few seconds later
+var (
+	runLookupAttempts = 3
+	runLookupInterval = 2 * time.Second
+)
+
+// runLookupSkew allows for the local clock being ahead of GitHub's
+const runLookupSkew = 10 * time.Second
+
+// findWorkflowRun returns the URL of the run the dispatch for the design started. The run is
+// matched by the design ID in its title, set by the workflow's run-name. It returns "" if no
+// run matches, in which case the workflow's runs page is reported.
+func findWorkflowRun(owner, repo, workflow, designID, token string, dispatched time.Time) string {
+	client := &github.Client{BaseURL: GitHubAPIBaseURL, Token: token, HTTP: newHTTPClient()}
+	for attempt := 1; attempt <= runLookupAttempts; attempt++ {
+		run, err := client.FindDispatchedRun(owner, repo, workflow, designID, dispatched.Add(-runLookupSkew))
+		if err != nil {
+			Log.Debugf("Could not look up the workflow run: %v", err)
+			return ""
+		}
+		if run != nil {
+			Log.Infof("Workflow run: %s", run.HTMLURL)
+			return run.HTMLURL
+		}
+		if attempt < runLookupAttempts {
+			time.Sleep(runLookupInterval)
+		}
+	}
+	Log.Debugf("No run of %s titled with design %s is listed yet", workflow, designID)
+	return ""
 }
 
 // isValidEmail validates an email address format
@@ -404,29 +470,9 @@ func isValidEmail(email string) bool {
 }
 
 // Main is the entrypoint for the plugin
-func Main(providerToken, mesheryCloudAPIBaseURL, mesheryAPIBaseURL, workflowAccessToken string) {
-	// Set global variables
-	ProviderToken = providerToken
-	MesheryCloudAPIBaseURL = mesheryCloudAPIBaseURL
-	MesheryAPIBaseURL = mesheryAPIBaseURL
-	WorkflowAccessToken = workflowAccessToken
-
-	// Initialize logger
-	setupLogger()
-
-	// Load configuration
-	var err error
-	Config, err = config.LoadConfig()
-	if err != nil {
-		Log.Errorf("Failed to load configuration: %v", err)
-	} else {
-		Log.Infof("Loaded configuration from: %s", config.GetConfigFilePath())
-		// Override API URL from config if set
-		if Config.Meshery.URL != "" {
-			Log.Infof("Using Meshery URL from config: %s", Config.Meshery.URL)
-			MesheryAPIBaseURL = Config.Meshery.URL
-		}
-	}
+func Main() {
+	// Initialize logger. Logs go to stderr so stdout stays clean for structured output.
+	setupLogger(os.Stderr)
 
 	// Setup command flags
 	generateKanvasSnapshotCmd.Flags().StringVarP(&manifestPath, "file", "f", "", "Path to the Kubernetes manifest file (required)")
@@ -434,12 +480,31 @@ func Main(providerToken, mesheryCloudAPIBaseURL, mesheryAPIBaseURL, workflowAcce
 	generateKanvasSnapshotCmd.Flags().StringVarP(&designName, "name", "n", "", "Name for the Meshery design (default: extracted from manifest path)")
 	generateKanvasSnapshotCmd.Flags().StringVarP(&email, "email", "e", "", "Email address for notifications")
 	generateKanvasSnapshotCmd.Flags().BoolVarP(&skipWorkflow, "skip-workflow", "s", false, "Skip publishing to Meshery's pattern catalog")
-	generateKanvasSnapshotCmd.Flags().StringVarP(&MesheryAPIBaseURL, "meshery-url", "m", "", "Meshery API URL (default: http://localhost:9081)")
-	generateKanvasSnapshotCmd.Flags().StringVarP(&ProviderToken, "meshery-token", "t", "", "Meshery authentication token")
+	generateKanvasSnapshotCmd.Flags().StringVar(&splitBy, "split-by", "", "Create one design per group: dir, file, namespace or label=<key>")
+	generateKanvasSnapshotCmd.Flags().IntVar(&splitConcurrency, "concurrency", 4, "Number of designs uploaded concurrently with --split-by")
+	generateKanvasSnapshotCmd.Flags().BoolVar(&forceUpload, "force", false, "Upload the manifests even if an unchanged copy was uploaded before")
+	generateKanvasSnapshotCmd.Flags().BoolVar(&noSpool, "no-spool", false, "Do not queue the upload for flush when Meshery or GitHub cannot be reached")
+	generateKanvasSnapshotCmd.Flags().StringVar(&gitDiffBase, "git-diff", "", "Only upload manifests changed relative to this git ref, with the resources they reference")
+	generateKanvasSnapshotCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep running and update the design in place when the manifest files change")
+	generateKanvasSnapshotCmd.Flags().BoolVar(&prComment, "pr-comment", false, "Create or update a comment with the design link, snapshot and resource summary on the pull request")
+	generateKanvasSnapshotCmd.Flags().StringVar(&pullRequest, "pr", "", "Pull request to comment on as a number, owner/repo#number or URL (default: detected from the CI environment)")
+	generateKanvasSnapshotCmd.Flags().BoolVar(&waitSnapshot, "wait", false, "Wait until the snapshot image is published")
+	generateKanvasSnapshotCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 10*time.Minute, "How long --wait waits for the snapshot image")
+	generateKanvasSnapshotCmd.Flags().StringVar(&reportPath, "report", "", "Write a report of the resources, images, ports, relationships and designs to a .md or .html file")
+	generateKanvasSnapshotCmd.Flags().StringVar(&kubeVersion, "kube-version", schema.MaxVersion.String(), fmt.Sprintf("Kubernetes version to validate the manifests for (%s to %s)", schema.MinVersion, schema.MaxVersion))
+	generateKanvasSnapshotCmd.Flags().StringSliceVar(&crdPaths, "crd", nil, "File or directory with CustomResourceDefinitions to validate custom resources with (repeatable)")
+	generateKanvasSnapshotCmd.Flags().BoolVar(&noValidate, "no-validate", false, "Upload the manifests without validating them against the Kubernetes schemas")
+	generateKanvasSnapshotCmd.Flags().StringVar(&onDuplicate, "on-duplicate", duplicateWarn, "How to handle resources defined more than once: warn, error or last-wins")
+	generateKanvasSnapshotCmd.Flags().IntVar(&maxFiles, "max-files", 0, "Stop if more manifest files are found (0 for no limit)")
+	generateKanvasSnapshotCmd.Flags().Int64Var(&maxBytes, "max-bytes", 0, "Stop if the manifest files are larger in total, in bytes (0 for no limit)")
+	generateKanvasSnapshotCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Print the result as json or yaml on stdout; logs go to stderr")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVarP(&MesheryAPIBaseURL, "meshery-url", "m", "", "Meshery API URL (default: http://localhost:9081)")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVarP(&ProviderToken, "meshery-token", "t", "", "Meshery authentication token")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&GitHubAPIBaseURL, "github-api-url", "", "GitHub REST API URL (default: https://api.github.com)")
 
 	// GitHub workflow configuration flags
 	generateKanvasSnapshotCmd.Flags().StringVar(&repoOwner, "repo-owner", "", "GitHub repository owner (defaults to layer5labs)")
-	generateKanvasSnapshotCmd.Flags().StringVar(&repoName, "repo-name", "", "GitHub repository name (defaults to meshery-extensions-packages)")
+	generateKanvasSnapshotCmd.Flags().StringVar(&repoName, "repo-name", "", "GitHub repository name (defaults to kubectl-kanvas-snapshot)")
 	generateKanvasSnapshotCmd.Flags().StringVar(&branchName, "branch", "", "GitHub repository branch (defaults to master)")
 	generateKanvasSnapshotCmd.Flags().StringVar(&workflowID, "workflow", "", "GitHub workflow ID (defaults to kanvas.yaml)")
 
@@ -452,27 +517,54 @@ func Main(providerToken, mesheryCloudAPIBaseURL, mesheryAPIBaseURL, workflowAcce
 	generateKanvasSnapshotCmd.Flags().SetAnnotation("email", "help", []string{"Email address for notifications when the design is ready."})
 	generateKanvasSnapshotCmd.Flags().SetAnnotation("recursive", "help", []string{"Process manifest files recursively in directories."})
 	generateKanvasSnapshotCmd.Flags().SetAnnotation("skip-workflow", "help", []string{"Skip publishing to Meshery's pattern catalog. The design will still be created but won't be published."})
-	generateKanvasSnapshotCmd.Flags().SetAnnotation("meshery-url", "help", []string{"Meshery API URL. Defaults to http://localhost:9081 if not set."})
-	generateKanvasSnapshotCmd.Flags().SetAnnotation("meshery-token", "help", []string{"Meshery authentication token. Can also be set via MESHERY_TOKEN environment variable."})
+	generateKanvasSnapshotCmd.PersistentFlags().SetAnnotation("meshery-url", "help", []string{"Meshery API URL. Defaults to http://localhost:9081 if not set."})
+	generateKanvasSnapshotCmd.PersistentFlags().SetAnnotation("meshery-token", "help", []string{"Meshery authentication token. Can also be set via MESHERY_TOKEN environment variable."})
+
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&mesheryContext, "meshery-context", "", "mesheryctl context to read the Meshery URL and token from (defaults to the current context)")
+
+	// Proxy and TLS settings for requests to Meshery and GitHub
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&httpOptions.ProxyURL, "proxy", "", "Proxy URL for requests to Meshery and GitHub (defaults to HTTPS_PROXY)")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&httpOptions.CAFile, "ca-file", "", "PEM bundle of certificate authorities to trust in addition to the system ones")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&httpOptions.CertFile, "client-cert", "", "PEM client certificate for mutual TLS")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&httpOptions.KeyFile, "client-key", "", "PEM client key for mutual TLS")
+	generateKanvasSnapshotCmd.PersistentFlags().BoolVar(&httpOptions.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "Do not verify server certificates. Insecure, for testing only")
+
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to the configuration file (defaults to KANVAS_CONFIG, then $XDG_CONFIG_HOME/kubectl-kanvas-snapshot/config.yaml, then ~/.meshery/kubectl-kanvas-snapshot/config.yaml)")
+	generateKanvasSnapshotCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Configuration profile to use (defaults to current_profile in the config file)")
+
+	// Load the configuration, apply the profile and resolve settings from flags, environment, .env, config and credentials for every command
+	generateKanvasSnapshotCmd.PersistentPreRunE = persistentPreRunE
+
+	// Register subcommands
+	generateKanvasSnapshotCmd.AddCommand(exportCmd, diffCmd)
+	generateKanvasSnapshotCmd.AddCommand(loginCmd, logoutCmd, whoamiCmd)
+	generateKanvasSnapshotCmd.AddCommand(credentialsCmd)
+	generateKanvasSnapshotCmd.AddCommand(configCmd)
+	generateKanvasSnapshotCmd.AddCommand(historyCmd)
+	generateKanvasSnapshotCmd.AddCommand(flushCmd)
 
 	// Execute the command
 	if err := generateKanvasSnapshotCmd.Execute(); err != nil {
-		Log.Error(fmt.Errorf("%v", err))
-		os.Exit(1)
+		Log.Error(err)
+		if details := errors.Details(err); details != "" {
+			Log.Info(details)
+		}
+		os.Exit(errors.ExitCode(err))
 	}
 }
 
-// setupLogger initializes the logger
-func setupLogger() {
+// setupLogger initializes the logger to write to w
+func setupLogger(w io.Writer) {
 	// Initialize logger with meshkit
 	mesheryLogger, err := logger.New("kubectl-kanvas-snapshot", logger.Options{
 		Format:   logger.TerminalLogFormat,
 		LogLevel: int(logrus.DebugLevel),
+		Output:   w,
 	})
 
 	if err != nil {
 		// Fall back to simple logger if meshkit logger initialization fails
-		Log = log.SetupLogger("kubectl-kanvas-snapshot", true, os.Stdout)
+		Log = log.SetupLogger("kubectl-kanvas-snapshot", true, w)
 		Log.Warn(fmt.Sprintf("Failed to initialize meshkit logger: %v. Using fallback logger.", err))
 	} else {
 		Log = &log.MeshkitLogger{Log: mesheryLogger}
@@ -493,32 +585,67 @@ func getDesignViewURL(designID string) string {
 
 // RunE function for the command
 func kanvasSnapshotRunE(_ *cobra.Command, _ []string) error {
+	// Validate output format before doing any work
+	if !isValidOutputFormat(outputFormat) {
+		return errors.ErrInvalidFlags(fmt.Errorf("invalid output format '%s': must be one of json, yaml", outputFormat))
+	}
+	if !isValidDuplicateMode(onDuplicate) {
+		return errors.ErrInvalidFlags(fmt.Errorf("invalid --on-duplicate '%s': must be one of warn, error, last-wins", onDuplicate))
+	}
+
+	if splitBy != "" {
+		if _, _, err := manifest.ParseSplitBy(splitBy); err != nil {
+			return errors.ErrInvalidSplit(err)
+		}
+		if watch {
+			return errors.ErrInvalidFlags(fmt.Errorf("--watch cannot be combined with --split-by"))
+		}
+	}
+	if gitDiffBase != "" && (splitBy != "" || watch) {
+		return errors.ErrInvalidFlags(fmt.Errorf("--git-diff cannot be combined with --split-by or --watch"))
+	}
+	if watch && (prComment || waitSnapshot) {
+		return errors.ErrInvalidFlags(fmt.Errorf("--watch cannot be combined with --pr-comment or --wait"))
+	}
+	if waitSnapshot && skipWorkflow {
+		return errors.ErrInvalidFlags(fmt.Errorf("--wait cannot be combined with --skip-workflow, no snapshot is rendered"))
+	}
+	if pullRequest != "" && !prComment {
+		return errors.ErrInvalidFlags(fmt.Errorf("--pr requires --pr-comment"))
+	}
+	if reportPath != "" {
+		if _, err := report.FormatOf(reportPath); err != nil {
+			return errors.ErrInvalidFlags(err)
+		}
+	}
+
+	result := &SnapshotResult{resultWarnings: resultWarnings{Warnings: []string{}}}
+
 	// Check if Meshery token is set
 	if ProviderToken == "" {
-		Log.Warn("MESHERY_TOKEN environment variable not set. Working in offline mode.")
+		result.warn("MESHERY_TOKEN environment variable not set. Requests to Meshery are not authenticated.")
 		Log.Info("Please set the MESHERY_TOKEN environment variable to use online features.")
-		Log.Info("You can obtain a token from your Meshery or Meshery Cloud profile.")
-	}
-
-	// Check if Meshery API URL is set
-	if MesheryAPIBaseURL == "" {
-		Log.Warn("Meshery API URL not set. Using default: http://localhost:9081")
-		MesheryAPIBaseURL = defaultMesheryURL
+		Log.Info("You can obtain a token from your Meshery or Meshery Cloud profile, or run 'kubectl kanvas-snapshot login'.")
 	}
 
 	// Log the endpoints being used
-	endpoint := apiEndpoint
-	if Config != nil && Config.Meshery.SnapshotEndpoint != "" {
-		endpoint = Config.Meshery.SnapshotEndpoint
-	}
-	Log.Infof("Using Meshery API URL: %s", MesheryAPIBaseURL)
-	Log.Infof("Using API endpoint: %s", endpoint)
+	Log.Infof("Using Meshery API URL: %s (from %s)", MesheryAPIBaseURL, Settings.Source("meshery.url"))
+	Log.Infof("Using API endpoint: %s", SnapshotEndpoint)
 
-	// Use the extracted name from manifest path if not provided
+	// Use the branch and commit with --git-diff, else the extracted name from manifest path, if not provided
+	if designName == "" && gitDiffBase != "" {
+		name, err := gitDesignName(manifestPath)
+		if err != nil {
+			return err
+		}
+		designName = name
+		Log.Infof("No design name provided. Using branch and commit: %s", designName)
+	}
 	if designName == "" {
 		designName = ExtractNameFromPath(manifestPath)
-		Log.Warnf("No design name provided. Using extracted name: %s", designName)
+		result.warnf("No design name provided. Using extracted name: %s", designName)
 	}
+	result.DesignName = designName
 
 	// Validate email if provided
 	if email != "" && !isValidEmail(email) {
@@ -527,62 +654,148 @@ func kanvasSnapshotRunE(_ *cobra.Command, _ []string) error {
 
 	// Process manifest files
 	Log.Info("Processing manifest files...")
-	manifests, err := getManifestContents(manifestPath, recursive)
+	files, err := loadManifests(manifestPath, recursive)
 	if err != nil {
 		return err
 	}
-	Log.Infof("Processed %d manifest file(s)", len(manifests))
+	Log.Infof("Processed %d manifest file(s)", len(files))
+
+	// Stop before uploading manifests that Kubernetes would reject
+	if err := validateManifests(files, &result.resultWarnings); err != nil {
+		return err
+	}
+	if files, err = checkDuplicates(files, &result.resultWarnings); err != nil {
+		return err
+	}
 
 	// Combine all manifests, ensuring proper spacing
-	combinedManifest := strings.Join(manifests, "\n---\n")
+	combinedManifest := combineManifests(files)
 
 	// Log manifest size for debugging
 	Log.Debugf("Manifest size: %d bytes", len(combinedManifest))
 
-	// Create Meshery Design
-	Log.Info("Creating Meshery design...")
-	designID, err := CreateMesheryDesign(combinedManifest, designName, email)
+	// Count resources for the result summary
+	for _, f := range files {
+		if f.ParseErr != nil {
+			result.warnf("Could not parse all manifests: %v", f.ParseErr)
+		}
+	}
+	resources := manifestResources(files)
+
+	// Only upload what changed relative to the git ref, with the resources it references
+	if gitDiffBase != "" {
+		if resources, err = selectGitChanges(files, manifestPath, gitDiffBase); err != nil {
+			return err
+		}
+		if len(resources) == 0 {
+			result.warnf("No manifests changed relative to %s, nothing to upload", gitDiffBase)
+			result.Resources = ResourceCounts{ByKind: map[string]int{}}
+			return writeResult(os.Stdout, outputFormat, result)
+		}
+		if combinedManifest, err = manifest.MarshalResources(resources); err != nil {
+			return errors.ErrReadingManifestFile(err)
+		}
+	}
+	result.Resources = ResourceCounts{Total: len(resources), ByKind: manifest.CountByKind(resources)}
+
+	// Create one design per group in batch mode
+	if splitBy != "" {
+		return runSplit(files, designName, resources, result.resultWarnings)
+	}
+
+	// Create Meshery Design, or reuse the one created from the same manifests.
+	// Watch mode always creates a new design since it is changed in place.
+	uploader := newCachedUpload()
+	uploader.disabled = uploader.disabled || watch
+	cached, hash, reused, err := uploader.design(combinedManifest, designName)
+	if err != nil && !watch {
+		// Keep the upload for 'flush' if Meshery could not be reached
+		queueFn := func() (string, error) {
+			return queueUpload(combinedManifest, designName, hash, absPaths(manifestPath), !skipWorkflow && WorkflowAccessToken != "")
+		}
+		if id := spoolOnUnreachable(err, queueFn); id != "" {
+			result.Queued = id
+			result.warnf("Meshery at %s could not be reached, the upload was queued as %s", MesheryAPIBaseURL, id)
+			return queuedResult(err, finishSnapshot(result, resources))
+		}
+	}
 	if err != nil {
+		// CreateMesheryDesign already returns classified errors, keep them intact for the exit code
 		Log.Errorf("Failed to create Meshery design: %v", err)
-		return errors.ErrCreatingMesheryDesign(err)
+		return err
+	}
+	designID := cached.DesignID
+	result.DesignID = designID
+	result.Cached = reused
+	if !reused && !watch {
+		uploader.store(hash, cached)
 	}
+	defer func() { recordHistory(result.historyRecord(hash, absPaths(manifestPath))) }()
 
 	// Generate direct URL to view in Meshery
 	mesheryViewURL := getDesignViewURL(designID)
+	result.ViewURL = mesheryViewURL
 	Log.Infof("View your design in Meshery: %s", mesheryViewURL)
 
+	// In watch mode the design keeps changing, so no snapshot is rendered
+	if watch {
+		Log.Infof("\nDesign created successfully with ID: %s", designID)
+		if err := writeReport(designName, resources, result.reportDesign(), result.Warnings); err != nil {
+			return err
+		}
+		if err := writeResult(os.Stdout, outputFormat, result); err != nil {
+			return err
+		}
+		return watchManifests(manifestPath, designID, designName, files)
+	}
+
 	if skipWorkflow {
 		Log.Info("Skipping publishing as --skip-workflow flag is set.")
 		Log.Infof("\nDesign created successfully with ID: %s", designID)
-		return nil
+		return finishSnapshot(result, resources)
+	}
+
+	// The snapshot of an unchanged design is still current
+	if reused && cached.AssetLocation != "" {
+		result.AssetLocation = cached.AssetLocation
+		Log.Infof("Reusing snapshot of design %s: %s", designID, cached.AssetLocation)
+		return finishSnapshot(result, resources)
 	}
 
 	Log.Info("Triggering GitHub workflow to generate snapshot...")
-	err = GenerateSnapshot(designID, "", WorkflowAccessToken)
+	workflow, err := GenerateSnapshot(designID, "", WorkflowAccessToken)
 	if err != nil {
-		return errors.ErrGeneratingSnapshot(err)
+		// Keep the dispatch for 'flush' if GitHub could not be reached
+		if id := spoolOnUnreachable(err, func() (string, error) { return queueDispatch(designID) }); id != "" {
+			result.Queued = id
+			result.warnf("GitHub could not be reached, the workflow dispatch was queued as %s", id)
+			return queuedResult(err, finishSnapshot(result, resources))
+		}
+		return err
 	}
 
 	// Output success message with clear instructions
 	Log.Infof("\nDesign created successfully with ID: %s", designID)
+	if workflow == nil {
+		result.warn("GITHUB_TOKEN environment variable not set. Snapshot generation was skipped.")
+		return finishSnapshot(result, resources)
+	}
+	result.Workflow = workflow
+	result.AssetLocation = defaultAssetLocation(designID)
+	cached.AssetLocation = result.AssetLocation
+	uploader.store(hash, cached)
 	Log.Info("GitHub workflow has been triggered to generate a snapshot.")
 
 	// Help user understand what to do next
-	if repoOwner == "" {
-		repoOwner = "layer5labs"
-	}
-	if repoName == "" {
-		repoName = "meshery"
-	}
-	if workflowID == "" {
-		workflowID = "kanvas.yaml"
-	}
-
 	Log.Infof("To access the snapshot images:")
-	Log.Infof("1. Go to https://github.com/%s/%s/actions/workflows/%s", repoOwner, repoName, workflowID)
-	Log.Infof("2. Find the most recent workflow run for designID: %s", designID)
-	Log.Infof("3. Wait for the workflow run to complete (~1-2 minutes)")
-	Log.Infof("4. Download the 'design-screenshots' artifact from the completed workflow")
+	if workflow.RunURL != "" {
+		Log.Infof("1. Go to %s", workflow.RunURL)
+	} else {
+		Log.Infof("1. Go to %s", workflow.RunsURL)
+		Log.Infof("   and find the most recent workflow run for designID: %s", designID)
+	}
+	Log.Infof("2. Wait for the workflow run to complete (~1-2 minutes)")
+	Log.Infof("3. Download the 'design-screenshots' artifact from the completed workflow")
 
-	return nil
+	return finishSnapshot(result, resources)
 }
@@ -0,0 +1,385 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/config"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/spf13/cobra"
+)
+
+// configCmd groups the commands managing the plugin configuration
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the plugin configuration",
+}
+
+// configUseProfileCmd sets the profile applied when --profile is not set
+var configUseProfileCmd = &cobra.Command{
+	Use:   "use-profile <name>",
+	Short: "Set the profile used when --profile is not set",
+	Long: `Set the profile used when --profile is not set.
+
+		Profiles are defined under 'profiles:' in the configuration file. Each profile
+		can set the Meshery URL, snapshot endpoint, token source and GitHub workflow.
+
+		Example usage:
+
+		kubectl kanvas-snapshot config use-profile staging`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: skipSettings,
+	RunE: func(_ *cobra.Command, args []string) error {
+		// The file is read directly, so a current_profile naming a removed profile can be fixed
+		path := findConfigFile()
+		if path == "" {
+			return errors.ErrInvalidConfig(fmt.Errorf("no config file found"))
+		}
+		_, ok, err := config.GetValue(path, "profiles."+args[0])
+		if err != nil {
+			return errors.ErrInvalidConfig(err)
+		}
+		if !ok {
+			return errors.ErrInvalidConfig(fmt.Errorf("profile '%s' not found in %s", args[0], path))
+		}
+
+		if err := config.SetValue(path, "current_profile", args[0]); err != nil {
+			return errors.ErrInvalidConfig(err)
+		}
+
+		Log.Infof("Switched to profile '%s' in %s", args[0], path)
+		return nil
+	},
+}
+
+// configInitCmd writes a commented configuration file
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a commented configuration file",
+	Long: `Write a commented configuration file.
+
+		The file is written to the path selected with --config or KANVAS_CONFIG, or else
+		to ~/.meshery/kubectl-kanvas-snapshot/config.yaml.
+
+		Example usage:
+
+		kubectl kanvas-snapshot config init
+		kubectl kanvas-snapshot config init --force`,
+	Args:              cobra.NoArgs,
+	PersistentPreRunE: skipSettings,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		path, _ := config.ExplicitConfigFile(configFile)
+		if path == "" {
+			var err error
+			if path, err = config.UserConfigPath(); err != nil {
+				return errors.ErrInvalidConfig(err)
+			}
+		}
+		if err := config.Init(path, configInitForce); err != nil {
+			if !configInitForce {
+				err = fmt.Errorf("%w, use --force to replace it", err)
+			}
+			return errors.ErrInvalidConfig(err)
+		}
+		Log.Infof("Wrote configuration file %s", path)
+		return nil
+	},
+}
+
+// configSetCmd sets a value in the configuration file
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a value in the configuration file",
+	Long: `Set a value in the configuration file.
+
+		Keys use the file layout separated by dots. Comments in the file are preserved. The
+		file is created if it does not exist, and left unchanged if the new value is invalid.
+
+		Example usage:
+
+		kubectl kanvas-snapshot config set meshery.url https://meshery.example.com
+		kubectl kanvas-snapshot config set profiles.staging.github.owner example`,
+	Args:              cobra.ExactArgs(2),
+	PersistentPreRunE: skipSettings,
+	RunE: func(_ *cobra.Command, args []string) error {
+		path, err := editableConfigPath()
+		if err != nil {
+			return errors.ErrInvalidConfig(err)
+		}
+		if err := config.SetValue(path, args[0], args[1]); err != nil {
+			return errors.ErrInvalidConfig(err)
+		}
+		Log.Infof("Set %s in %s", args[0], path)
+		return nil
+	},
+}
+
+// configGetCmd prints a value of the configuration file
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a value of the configuration file",
+	Long: `Print a value of the configuration file.
+
+		Only the file is read. Use 'config view --resolved' to see the values in effect
+		after flags, environment variables and defaults are applied.
+
+		Example usage:
+
+		kubectl kanvas-snapshot config get meshery.url`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: skipSettings,
+	RunE: func(_ *cobra.Command, args []string) error {
+		path := findConfigFile()
+		if path == "" {
+			return errors.ErrInvalidConfig(fmt.Errorf("no config file found"))
+		}
+		value, ok, err := config.GetValue(path, args[0])
+		if err != nil {
+			return errors.ErrInvalidConfig(err)
+		}
+		if !ok {
+			return errors.ErrInvalidConfig(fmt.Errorf("'%s' is not set in %s", args[0], path))
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+// configValidateCmd checks a configuration file
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check the configuration file for errors",
+	Long: `Check the configuration file for errors.
+
+		Unknown keys, values of the wrong type, malformed URLs and endpoints, unknown token
+		sources and an undefined current_profile are reported with their line numbers.
+
+		Example usage:
+
+		kubectl kanvas-snapshot config validate
+		kubectl kanvas-snapshot config validate ./ci/config.yaml`,
+	Args:              cobra.MaximumNArgs(1),
+	PersistentPreRunE: skipSettings,
+	RunE: func(_ *cobra.Command, args []string) error {
+		var path string
+		if len(args) > 0 {
+			path = args[0]
+		} else if path = findConfigFile(); path == "" {
+			return errors.ErrInvalidConfig(fmt.Errorf("no config file found"))
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return errors.ErrInvalidConfig(err)
+		}
+
+		problems := config.Validate(data, filepath.Dir(path))
+		if len(problems) == 0 {
+			Log.Infof("%s is valid", path)
+			return nil
+		}
+		for _, p := range problems {
+			if p.Line == 0 {
+				fmt.Fprintf(os.Stdout, "%s: %s\n", path, p.Message)
+			} else {
+				fmt.Fprintf(os.Stdout, "%s:%d: %s\n", path, p.Line, p.Message)
+			}
+		}
+		return errors.ErrInvalidConfig(fmt.Errorf("%d problem(s) found in %s", len(problems), path))
+	},
+}
+
+// Whether config init replaces an existing file
+var configInitForce bool
+
+// editableConfigPath returns the config file to edit: the selected or discovered one,
+// or the file in the user's home directory if none exists yet
+func editableConfigPath() (string, error) {
+	if path := findConfigFile(); path != "" {
+		return path, nil
+	}
+	return config.UserConfigPath()
+}
+
+// findConfigFile selects the config file and logs the locations checked
+func findConfigFile() string {
+	path, candidates := config.FindConfigFile(configFile)
+	for _, c := range candidates {
+		state := "not found"
+		if c.Exists {
+			state = "found"
+		}
+		Log.Debugf("Checked config file %s (%s): %s", c.Path, c.Source, state)
+	}
+	return path
+}
+
+// configViewCmd prints the configuration
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the configuration",
+	Long: `Print the configuration.
+
+		Without flags, the configuration file is printed with the selected profile applied.
+		With --resolved, every setting is printed with the value in effect and where it came
+		from. Values are resolved in the order flags, environment variables, .env file,
+		configuration file (including the selected profile), credential store or mesheryctl
+		context, and finally built-in defaults. Tokens are masked.
+
+		Example usage:
+
+		kubectl kanvas-snapshot config view
+		kubectl kanvas-snapshot config view --resolved
+		kubectl kanvas-snapshot config view --resolved --profile staging`,
+	Args: cobra.NoArgs,
+	RunE: configViewRunE,
+}
+
+// Whether config view prints the resolved settings
+var configViewResolved bool
+
+// RunE function for the config view command
+func configViewRunE(_ *cobra.Command, _ []string) error {
+	if !configViewResolved {
+		return writeResult(os.Stdout, outputFormatYAML, Config)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, v := range Settings.Values {
+		value := v.Value
+		if v.Secret && value != "" {
+			value = maskSecret(value)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, value, v.Source)
+	}
+	return w.Flush()
+}
+
+// maskSecret hides all but the last four characters of a secret
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "********"
+	}
+	return "********" + secret[len(secret)-4:]
+}
+
+// skipSettings replaces persistentPreRunE for commands that work on the config file
+// directly, so they still run when the file is invalid
+func skipSettings(_ *cobra.Command, _ []string) error {
+	return nil
+}
+
+// applyProfile overlays the selected profile onto the configuration
+func applyProfile() (string, error) {
+	if Config == nil {
+		return "", nil
+	}
+
+	name, err := Config.ApplyProfile(profileName)
+	if err != nil {
+		return name, errors.ErrInvalidConfig(err)
+	}
+	if name != "" {
+		Log.Infof("Using profile '%s'", name)
+	}
+	return name, nil
+}
+
+// resolveSettings resolves every setting from flags, environment variables, the .env file,
+// the configuration file, the credential store and mesheryctl contexts, and defaults
+func resolveSettings(cmd *cobra.Command, profile string) (*config.Resolved, error) {
+	dotEnv, err := config.LoadDotEnv(".env")
+	if err != nil {
+		Log.Warnf("Could not read .env file: %v", err)
+	}
+
+	fallback, err := newCredentialFallback()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Resolve(config.Sources{
+		Flag: func(name string) (string, bool) {
+			flag := cmd.Flags().Lookup(name)
+			if flag == nil || !flag.Changed {
+				return "", false
+			}
+			return flag.Value.String(), true
+		},
+		Env:      os.LookupEnv,
+		DotEnv:   dotEnv,
+		Config:   Config,
+		Profile:  profile,
+		Selected: fallback.selected,
+		Fallback: fallback.lookup,
+	})
+}
+
+// applySettings assigns the resolved settings to the command's configuration
+func applySettings(resolved *config.Resolved) error {
+	MesheryAPIBaseURL = resolved.Get("meshery.url")
+	SnapshotEndpoint = resolved.Get("meshery.snapshot_endpoint")
+	ProviderToken = resolved.Get("meshery.token")
+	MesheryCloudAPIBaseURL = resolved.Get("meshery.cloud_url")
+	WorkflowAccessToken = resolved.Get("github.token")
+	GitHubAPIBaseURL = strings.TrimSuffix(resolved.Get("github.api_url"), "/")
+	repoOwner = resolved.Get("github.owner")
+	repoName = resolved.Get("github.repo")
+	branchName = resolved.Get("github.branch")
+	workflowID = resolved.Get("github.workflow")
+
+	for i, v := range resolved.Values {
+		if v.Source != config.SourceUnset {
+			Log.Debugf("Using %s from %s", v.Key, v.Source)
+		}
+		if v.Secret && v.Source == config.SourceDotEnv {
+			warnPlaintextToken(config.Settings[i].Env, strings.ReplaceAll(v.Key, ".", "-"))
+		}
+	}
+
+	return setupHTTPTransport(resolved)
+}
+
+// warnPlaintextToken points users with tokens in a plaintext .env file to the credential store
+func warnPlaintextToken(envName, credentialName string) {
+	Log.Warnf("Storing %s in a plaintext .env file is deprecated. Store it in the OS keyring instead:", envName)
+	Log.Warnf("  echo \"$%s\" | kubectl kanvas-snapshot credentials set %s", envName, credentialName)
+}
+
+// persistentPreRunE prepares configuration and credentials for every command
+func persistentPreRunE(cmd *cobra.Command, _ []string) error {
+	var err error
+	ConfigPath = findConfigFile()
+	Config, err = config.LoadConfig(ConfigPath)
+	if err != nil {
+		return errors.ErrInvalidConfig(err)
+	}
+	if ConfigPath != "" {
+		Log.Debugf("Loaded configuration from: %s", ConfigPath)
+	} else {
+		Log.Debug("No configuration file found, using defaults")
+	}
+
+	ActiveProfile, err = applyProfile()
+	if err != nil {
+		return err
+	}
+
+	Settings, err = resolveSettings(cmd, ActiveProfile)
+	if err != nil {
+		return err
+	}
+	return applySettings(Settings)
+}
+
+func init() {
+	configViewCmd.Flags().BoolVar(&configViewResolved, "resolved", false, "Print every setting with its value and where it came from")
+
+	configInitCmd.Flags().BoolVar(&configInitForce, "force", false, "Replace an existing configuration file")
+
+	configCmd.AddCommand(configInitCmd, configViewCmd, configSetCmd, configGetCmd, configValidateCmd, configUseProfileCmd)
+}
@@ -0,0 +1,166 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/credentials"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/spf13/cobra"
+)
+
+// credentialStore holds tokens between runs. It is set on first use, tests can replace it.
+var credentialStore credentials.Store
+
+// getCredentialStore returns the credential store, selecting the default one on first use
+func getCredentialStore() (credentials.Store, error) {
+	if credentialStore != nil {
+		return credentialStore, nil
+	}
+	store, err := credentials.Default()
+	if err != nil {
+		return nil, errors.ErrCredentials(err)
+	}
+	Log.Debugf("Using credential store: %s", store.Name())
+	credentialStore = store
+	migrateLegacyCredentials(store)
+	return credentialStore, nil
+}
+
+// migrateLegacyCredentials moves the token that earlier versions of login saved in the
+// plaintext credentials.json into the store, and deletes that file
+func migrateLegacyCredentials(store credentials.Store) {
+	path, err := credentials.LegacyPath()
+	if err != nil {
+		return
+	}
+	imported, err := credentials.MigrateLegacyFile(path, store)
+	if err != nil {
+		Log.Warnf("Could not import the token saved by an earlier login: %v", err)
+		return
+	}
+	if imported {
+		Log.Infof("Moved the Meshery token saved by an earlier login from %s to %s", path, store.Name())
+	}
+}
+
+// storedCredential returns the value stored for key, or an empty string if there is none
+func storedCredential(key string) (string, error) {
+	store, err := getCredentialStore()
+	if err != nil {
+		return "", err
+	}
+	value, err := store.Get(key)
+	if err == credentials.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.ErrCredentials(err)
+	}
+	return value, nil
+}
+
+// credentialsCmd groups the commands managing stored credentials
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "Manage tokens stored in the OS keyring",
+	Long: `Manage tokens stored in the OS keyring.
+
+		Tokens are stored in the OS keyring (Secret Service on Linux, Keychain on macOS,
+		Credential Manager on Windows). If no keyring is available, they are stored in an
+		encrypted file in ~/.meshery/kubectl-kanvas-snapshot/. Its key is kept next to it,
+		so the file is only as safe as a file readable only by you.
+
+		Credentials: ` + strings.Join(credentials.Keys(), ", ") + `
+
+		Example usage:
+
+		echo "$GITHUB_TOKEN" | kubectl kanvas-snapshot credentials set github-token
+		kubectl kanvas-snapshot credentials get meshery-token
+		kubectl kanvas-snapshot credentials clear github-token`,
+}
+
+// credentialsSetCmd stores a credential read from stdin
+var credentialsSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a credential read from stdin",
+	Args:  credentialNameArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		value, err := readTokenFromStdin(os.Stdin)
+		if err != nil {
+			return errors.ErrCredentials(err)
+		}
+		store, err := getCredentialStore()
+		if err != nil {
+			return err
+		}
+		if err := store.Set(args[0], value); err != nil {
+			return errors.ErrCredentials(err)
+		}
+		Log.Infof("Stored %s in %s", args[0], store.Name())
+		return nil
+	},
+}
+
+// credentialsGetCmd prints a stored credential
+var credentialsGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print a stored credential",
+	Args:  credentialNameArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		value, err := storedCredential(args[0])
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return errors.ErrCredentials(fmt.Errorf("no %s stored", args[0]))
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+// credentialsClearCmd removes stored credentials
+var credentialsClearCmd = &cobra.Command{
+	Use:   "clear [name]",
+	Short: "Remove a stored credential, or all of them if no name is given",
+	Args:  credentialNameArgs(0),
+	RunE: func(_ *cobra.Command, args []string) error {
+		keys := args
+		if len(keys) == 0 {
+			keys = credentials.Keys()
+		}
+		store, err := getCredentialStore()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := store.Delete(key); err != nil {
+				return errors.ErrCredentials(err)
+			}
+			Log.Infof("Cleared %s from %s", key, store.Name())
+		}
+		return nil
+	},
+}
+
+// credentialNameArgs validates that the arguments name managed credentials.
+// With required set to 0, a single name is optional.
+func credentialNameArgs(required int) cobra.PositionalArgs {
+	return func(_ *cobra.Command, args []string) error {
+		if len(args) < required || len(args) > 1 {
+			return fmt.Errorf("expected a credential name: one of %s", strings.Join(credentials.Keys(), ", "))
+		}
+		for _, arg := range args {
+			if !credentials.IsValidKey(arg) {
+				return fmt.Errorf("unknown credential '%s': must be one of %s", arg, strings.Join(credentials.Keys(), ", "))
+			}
+		}
+		return nil
+	}
+}
+
+func init() {
+	credentialsCmd.AddCommand(credentialsSetCmd, credentialsGetCmd, credentialsClearCmd)
+}
@@ -0,0 +1,110 @@
+package kanvas_snapshot
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/credentials"
+)
+
+// useFileCredentialStore replaces the credential store with a file in a temporary directory
+func useFileCredentialStore(t *testing.T) credentials.Store {
+	t.Helper()
+	setupLogger(io.Discard)
+	store := credentials.NewFileStore(filepath.Join(t.TempDir(), "credentials.json"))
+	credentialStore = store
+	t.Cleanup(func() { credentialStore = nil })
+	return store
+}
+
+// withStdin runs fn with os.Stdin reading input
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+	fn()
+}
+
+func TestCredentialsSetGetClear(t *testing.T) {
+	store := useFileCredentialStore(t)
+
+	withStdin(t, "ghp_secret\n", func() {
+		if err := credentialsSetCmd.RunE(credentialsSetCmd, []string{credentials.KeyGitHubToken}); err != nil {
+			t.Fatalf("credentials set: %v", err)
+		}
+	})
+	if got, err := store.Get(credentials.KeyGitHubToken); err != nil || got != "ghp_secret" {
+		t.Errorf("stored %q, %v, want %q", got, err, "ghp_secret")
+	}
+	if got, err := storedCredential(credentials.KeyGitHubToken); err != nil || got != "ghp_secret" {
+		t.Errorf("storedCredential = %q, %v, want %q", got, err, "ghp_secret")
+	}
+
+	if err := credentialsClearCmd.RunE(credentialsClearCmd, []string{credentials.KeyGitHubToken}); err != nil {
+		t.Fatalf("credentials clear: %v", err)
+	}
+	if got, err := storedCredential(credentials.KeyGitHubToken); err != nil || got != "" {
+		t.Errorf("storedCredential after clear = %q, %v, want none", got, err)
+	}
+	if err := credentialsGetCmd.RunE(credentialsGetCmd, []string{credentials.KeyGitHubToken}); err == nil {
+		t.Error("credentials get of a cleared credential succeeded, want an error")
+	}
+}
+
+func TestCredentialsClearAll(t *testing.T) {
+	store := useFileCredentialStore(t)
+	for _, key := range credentials.Keys() {
+		if err := store.Set(key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := credentialsClearCmd.RunE(credentialsClearCmd, nil); err != nil {
+		t.Fatalf("credentials clear: %v", err)
+	}
+	for _, key := range credentials.Keys() {
+		if _, err := store.Get(key); err != credentials.ErrNotFound {
+			t.Errorf("%s still stored after clear: %v", key, err)
+		}
+	}
+}
+
+func TestCredentialFallbackUsesStore(t *testing.T) {
+	store := useFileCredentialStore(t)
+	if err := store.Set(credentials.KeyMesheryToken, "stored-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(credentials.KeyMesheryProvider, "Layer5"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", t.TempDir())
+	provider := ProviderName
+	t.Cleanup(func() { ProviderName = provider })
+
+	fallback := &credentialFallback{useStore: true, useGitHubStore: true}
+	token, source, err := fallback.lookup("meshery.token")
+	if err != nil || token != "stored-token" || source != "credential store" {
+		t.Errorf("lookup(meshery.token) = %q, %q, %v, want the stored token", token, source, err)
+	}
+	if ProviderName != "Layer5" {
+		t.Errorf("ProviderName = %q, want the stored provider", ProviderName)
+	}
+
+	// token_source: env does not read the store
+	fallback = &credentialFallback{}
+	if token, _, _ := fallback.lookup("meshery.token"); token != "" {
+		t.Errorf("lookup with the store disabled = %q, want none", token)
+	}
+}
@@ -0,0 +1,375 @@
+package kanvas_snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/design"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/diagram"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/diff"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/gitdiff"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of arguments the diff command compares
+const (
+	diffTypeAuto   = "auto"
+	diffTypePath   = "path"
+	diffTypeGit    = "git"
+	diffTypeDesign = "design"
+)
+
+// maxDiffFields limits the changed fields listed per resource in the text summary
+const maxDiffFields = 10
+
+// diffColors are the colors of each change status in diagrams and designs
+var diffColors = map[string]string{
+	diff.StatusAdded:     "#2da44e",
+	diff.StatusRemoved:   "#cf222e",
+	diff.StatusChanged:   "#bf8700",
+	diff.StatusUnchanged: "#8c959f",
+}
+
+// diffFills are the background colors of each change status in diagrams
+var diffFills = map[string]string{
+	diff.StatusAdded:     "#dafbe1",
+	diff.StatusRemoved:   "#ffebe9",
+	diff.StatusChanged:   "#fff8c5",
+	diff.StatusUnchanged: "#f6f8fa",
+}
+
+var (
+	// diff command flags
+	diffType      string
+	diffPath      string
+	diffRecursive bool
+	diffDiagram   string
+	diffUpload    bool
+	diffName      string
+	diffOutput    string
+)
+
+// DiffResult is the machine-readable result of the diff command
+type DiffResult struct {
+	Old           string                    `json:"old" yaml:"old"`
+	New           string                    `json:"new" yaml:"new"`
+	Summary       DiffSummary               `json:"summary" yaml:"summary"`
+	Resources     []diff.ResourceChange     `json:"resources" yaml:"resources"`
+	Relationships []diff.RelationshipChange `json:"relationships" yaml:"relationships"`
+	// Diagram is the file the Mermaid diagram was written to
+	Diagram        string `json:"diagram,omitempty" yaml:"diagram,omitempty"`
+	DesignID       string `json:"designID,omitempty" yaml:"designID,omitempty"`
+	DesignName     string `json:"designName,omitempty" yaml:"designName,omitempty"`
+	ViewURL        string `json:"viewURL,omitempty" yaml:"viewURL,omitempty"`
+	resultWarnings `yaml:",inline"`
+}
+
+// DiffSummary counts the resources and relationships by change status
+type DiffSummary struct {
+	Resources     diff.Counts `json:"resources" yaml:"resources"`
+	Relationships diff.Counts `json:"relationships" yaml:"relationships"`
+}
+
+// diffCmd compares two manifest sets, git refs or designs
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare two manifest paths, git refs or Meshery designs",
+	Long: `Compare two manifest paths, git refs or Meshery designs.
+
+		Lists the resources and relationships added, removed and changed between <old>
+		and <new>. Each argument is a manifest file or directory, a git ref of the
+		repository containing --file, or a Meshery design ID. By default a path that
+		exists is compared as a path, else a ref that resolves as a git ref, else a design.
+
+		The comparison can be drawn as a Mermaid diagram with --diagram, or uploaded to
+		Meshery as a combined design with --upload, with components colored by change
+		status: green for added, red for removed, amber for changed and gray for unchanged.
+
+		Example usage:
+
+		kubectl kanvas-snapshot diff ./old/ ./new/ -r
+		kubectl kanvas-snapshot diff main HEAD -f ./deploy -r --diagram diff.md
+		kubectl kanvas-snapshot diff 3f1c9e2a-... 8b7d6c5e-... --upload`,
+	Args: cobra.ExactArgs(2),
+	RunE: diffRunE,
+}
+
+func diffRunE(_ *cobra.Command, args []string) error {
+	if !isValidOutputFormat(diffOutput) {
+		return errors.ErrInvalidFlags(fmt.Errorf("invalid output format '%s': must be one of json, yaml", diffOutput))
+	}
+	switch diffType {
+	case diffTypeAuto, diffTypePath, diffTypeGit, diffTypeDesign:
+	default:
+		return errors.ErrInvalidFlags(fmt.Errorf("invalid --type '%s': must be one of auto, path, git, design", diffType))
+	}
+
+	result := &DiffResult{Old: args[0], New: args[1]}
+	oldResources, err := loadDiffSide(args[0], result)
+	if err != nil {
+		return err
+	}
+	newResources, err := loadDiffSide(args[1], result)
+	if err != nil {
+		return err
+	}
+
+	d := diff.Compare(oldResources, newResources)
+	result.Resources = d.Resources
+	result.Relationships = d.Relationships
+	result.Summary = DiffSummary{Resources: d.ResourceCounts(), Relationships: d.RelationshipCounts()}
+
+	if diffDiagram != "" {
+		if err := writeDiffDiagram(diffDiagram, d); err != nil {
+			return errors.ErrDiff(diffDiagram, err)
+		}
+		result.Diagram = diffDiagram
+		Log.Infof("Wrote diagram to %s", diffDiagram)
+	}
+
+	if diffUpload {
+		name := diffName
+		if name == "" {
+			name = fmt.Sprintf("%s..%s", diffLabel(args[0]), diffLabel(args[1]))
+		}
+		designID, err := uploadDiffDesign(name, d)
+		if err != nil {
+			return err
+		}
+		result.DesignID = designID
+		result.DesignName = name
+		result.ViewURL = getDesignViewURL(designID)
+		Log.Infof("View the comparison in Meshery: %s", result.ViewURL)
+	}
+
+	if diffOutput != "" {
+		return writeResult(os.Stdout, diffOutput, result)
+	}
+	return writeDiffSummary(os.Stdout, result)
+}
+
+// loadDiffSide returns the resources of a diff argument, detecting its kind unless --type is set
+func loadDiffSide(arg string, result *DiffResult) ([]manifest.Resource, error) {
+	kind := diffType
+	if kind == diffTypeAuto {
+		kind = detectDiffType(arg)
+	}
+
+	switch kind {
+	case diffTypePath:
+		Log.Infof("Comparing manifests at %s", arg)
+		files, err := manifest.Load(arg, manifestLoadOptions(diffRecursive))
+		if err != nil {
+			return nil, errors.ErrDiff(arg, err)
+		}
+		for _, f := range files {
+			if f.ParseErr != nil {
+				result.warnf("Could not parse all manifests: %v", f.ParseErr)
+			}
+		}
+		return manifestResources(files), nil
+
+	case diffTypeGit:
+		resources, err := gitResources(arg, result)
+		if err != nil {
+			return nil, errors.ErrDiff(arg, err)
+		}
+		return resources, nil
+
+	default:
+		Log.Infof("Comparing Meshery design %s", arg)
+		pattern, err := FetchMesheryDesign(arg)
+		if err != nil {
+			return nil, err
+		}
+		d, err := design.Parse([]byte(pattern.PatternFile))
+		if err != nil {
+			return nil, errors.ErrDiff(arg, err)
+		}
+		var resources []manifest.Resource
+		for _, m := range d.ToManifests() {
+			resources = append(resources, manifest.FromObject("design "+arg, m.Object))
+		}
+		return resources, nil
+	}
+}
+
+// detectDiffType returns path for existing paths, git for refs of the repository
+// containing --file, and design otherwise
+func detectDiffType(arg string) string {
+	if _, err := os.Stat(arg); err == nil {
+		return diffTypePath
+	}
+	if repo, err := gitdiff.Open(diffPath); err == nil {
+		if _, err := repo.Commit(arg); err == nil {
+			return diffTypeGit
+		}
+	}
+	return diffTypeDesign
+}
+
+// gitResources returns the resources of the manifests at --file as of the git ref
+func gitResources(ref string, result *DiffResult) ([]manifest.Resource, error) {
+	repo, err := gitdiff.Open(diffPath)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.Commit(ref)
+	if err != nil {
+		return nil, err
+	}
+	Log.Infof("Comparing manifests at %s (%s)", ref, shortSHA(commit))
+
+	paths, err := repo.Files(commit, realPath(diffPath), diffRecursive)
+	if err != nil {
+		return nil, err
+	}
+	var resources []manifest.Resource
+	for _, path := range paths {
+		if !manifest.IsManifestFile(path) {
+			continue
+		}
+		content, _, err := repo.Show(commit, path)
+		if err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(repo.Root, path)
+		parsed, err := manifest.Parse(ref+":"+filepath.ToSlash(rel), content)
+		if err != nil {
+			result.warnf("Could not parse all manifests: %v", err)
+		}
+		resources = append(resources, parsed...)
+	}
+	return resources, nil
+}
+
+// diffLabel shortens a diff argument for the default design name
+func diffLabel(arg string) string {
+	if _, err := os.Stat(arg); err == nil {
+		return filepath.Base(realPath(arg))
+	}
+	return arg
+}
+
+// diffGraph draws the compared resources grouped by namespace, colored by change status,
+// with removed relationships dashed
+func diffGraph(d *diff.Result) *diagram.Graph {
+	g := &diagram.Graph{Classes: make(map[string]string, len(diffColors))}
+	for status, color := range diffColors {
+		g.Classes[status] = fmt.Sprintf("fill:%s,stroke:%s,stroke-width:2px", diffFills[status], color)
+	}
+
+	ids := make(map[string]string, len(d.Resources))
+	for i, r := range d.Resources {
+		ids[r.Ref] = fmt.Sprintf("r%d", i)
+		group := ""
+		if r.Namespace != "" {
+			group = "namespace " + r.Namespace
+		}
+		g.Nodes = append(g.Nodes, diagram.Node{ID: ids[r.Ref], Label: r.Kind + "\n" + r.Name, Group: group, Class: r.Status})
+	}
+	for _, rel := range d.Relationships {
+		e := diagram.Edge{From: ids[rel.From], To: ids[rel.To], Label: rel.Type}
+		switch rel.Status {
+		case diff.StatusAdded:
+			e.Style = "stroke:" + diffColors[diff.StatusAdded]
+		case diff.StatusRemoved:
+			e.Style = "stroke:" + diffColors[diff.StatusRemoved]
+			e.Dashed = true
+		}
+		g.Edges = append(g.Edges, e)
+	}
+	return g
+}
+
+// writeDiffDiagram writes the diagram as a Markdown file with a Mermaid block if path
+// ends in .md, or as plain Mermaid source otherwise
+func writeDiffDiagram(path string, d *diff.Result) error {
+	g := diffGraph(d)
+	content := g.Mermaid()
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		content = g.Markdown() + "\nGreen: added, red: removed, amber: changed, gray: unchanged. Dashed arrows are removed relationships.\n"
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// uploadDiffDesign creates a design holding the new resources and the removed ones,
+// with components colored by change status
+func uploadDiffDesign(name string, d *diff.Result) (string, error) {
+	resources := make([]manifest.Resource, 0, len(d.Resources))
+	for _, r := range d.Resources {
+		resources = append(resources, r.Resource)
+	}
+	combined := design.FromResources("", name, resources)
+	for i, r := range d.Resources {
+		styles := map[string]interface{}{
+			"primaryColor": diffColors[r.Status],
+			"border-color": diffColors[r.Status],
+			"border-width": 4,
+		}
+		if r.Status == diff.StatusRemoved {
+			styles["border-style"] = "dashed"
+		}
+		combined.Components[i].Styles = styles
+	}
+
+	patternFile, err := combined.Marshal()
+	if err != nil {
+		return "", errors.ErrCreatingMesheryDesign(err)
+	}
+	payload, err := json.Marshal(patternSaveRequest{
+		Save:        true,
+		PatternData: patternData{Name: name, PatternFile: string(patternFile)},
+	})
+	if err != nil {
+		return "", errors.ErrCreatingMesheryDesign(err)
+	}
+	return postMesheryDesign(MesheryAPIBaseURL, patternEndpoint, payload)
+}
+
+// writeDiffSummary prints the changes as text, leaving out unchanged resources and relationships
+func writeDiffSummary(w io.Writer, result *DiffResult) error {
+	symbols := map[string]string{diff.StatusAdded: "+", diff.StatusRemoved: "-", diff.StatusChanged: "~"}
+	res, rel := result.Summary.Resources, result.Summary.Relationships
+
+	fmt.Fprintf(w, "Comparing %s with %s\n\n", result.Old, result.New)
+	fmt.Fprintf(w, "Resources: %d added, %d removed, %d changed, %d unchanged\n", res.Added, res.Removed, res.Changed, res.Unchanged)
+	for _, r := range result.Resources {
+		symbol, ok := symbols[r.Status]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "  %s %s\n", symbol, r.Ref)
+		for i, field := range r.Fields {
+			if i == maxDiffFields {
+				fmt.Fprintf(w, "      ... and %d more\n", len(r.Fields)-maxDiffFields)
+				break
+			}
+			fmt.Fprintf(w, "      %s\n", field)
+		}
+	}
+
+	fmt.Fprintf(w, "\nRelationships: %d added, %d removed, %d unchanged\n", rel.Added, rel.Removed, rel.Unchanged)
+	for _, r := range result.Relationships {
+		if symbol, ok := symbols[r.Status]; ok {
+			fmt.Fprintf(w, "  %s %s -> %s (%s)\n", symbol, r.From, r.To, r.Type)
+		}
+	}
+	return nil
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffType, "type", diffTypeAuto, "Kind of the arguments: auto, path, git or design")
+	diffCmd.Flags().StringVarP(&diffPath, "file", "f", ".", "Manifest file or directory compared at each git ref")
+	diffCmd.Flags().BoolVarP(&diffRecursive, "recursive", "r", false, "Process manifest files recursively in directories")
+	diffCmd.Flags().StringVar(&diffDiagram, "diagram", "", "Write a Mermaid diagram of the changes to this file (.md for Markdown)")
+	diffCmd.Flags().BoolVar(&diffUpload, "upload", false, "Create a combined design in Meshery with components colored by change status")
+	diffCmd.Flags().StringVarP(&diffName, "name", "n", "", "Name of the combined design (default: <old>..<new>)")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "", "Print the result as json or yaml on stdout; logs go to stderr")
+}
@@ -0,0 +1,108 @@
+package kanvas_snapshot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/design"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/diff"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+func TestUploadDiffDesignStylesComponents(t *testing.T) {
+	setupLogger(io.Discard)
+	var saved patternSaveRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != patternEndpoint {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&saved); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "d-1"}`))
+	}))
+	defer server.Close()
+	oldURL := MesheryAPIBaseURL
+	MesheryAPIBaseURL = server.URL
+	defer func() { MesheryAPIBaseURL = oldURL }()
+
+	oldResources, err := manifest.Parse("old.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+data: {mode: fast}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {ports: [{port: 80}]}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: token}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newResources, err := manifest.Parse("new.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+data: {mode: slow}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {ports: [{port: 80}]}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := diff.Compare(oldResources, newResources)
+
+	id, err := uploadDiffDesign("web diff", d)
+	if err != nil || id != "d-1" {
+		t.Fatalf("uploadDiffDesign = %q, %v, want d-1", id, err)
+	}
+	if saved.PatternData.Name != "web diff" {
+		t.Errorf("design name = %q, want web diff", saved.PatternData.Name)
+	}
+	var uploaded design.Design
+	if err := yaml.Unmarshal([]byte(saved.PatternData.PatternFile), &uploaded); err != nil {
+		t.Fatalf("parsing the uploaded design: %v", err)
+	}
+
+	if len(uploaded.Components) != len(d.Resources) {
+		t.Fatalf("design has %d components, want %d", len(uploaded.Components), len(d.Resources))
+	}
+	seen := map[string]bool{}
+	for i, r := range d.Resources {
+		seen[r.Status] = true
+		c := uploaded.Components[i]
+		if c.DisplayName != r.Name || c.Component.Kind != r.Kind {
+			t.Errorf("component %d is %s %s, want %s %s", i, c.Component.Kind, c.DisplayName, r.Kind, r.Name)
+		}
+		if c.Styles["primaryColor"] != diffColors[r.Status] || c.Styles["border-color"] != diffColors[r.Status] {
+			t.Errorf("%s is styled %v, want the %s color %s", r.Ref, c.Styles, r.Status, diffColors[r.Status])
+		}
+		if dashed := c.Styles["border-style"] == "dashed"; dashed != (r.Status == diff.StatusRemoved) {
+			t.Errorf("%s (%s) has border-style %v", r.Ref, r.Status, c.Styles["border-style"])
+		}
+	}
+	for _, status := range []string{diff.StatusAdded, diff.StatusRemoved, diff.StatusChanged, diff.StatusUnchanged} {
+		if !seen[status] {
+			t.Errorf("no %s resource compared", status)
+		}
+	}
+}
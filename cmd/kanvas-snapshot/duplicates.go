@@ -0,0 +1,59 @@
+package kanvas_snapshot
+
+import (
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// How resources defined more than once are handled, selected with --on-duplicate
+const (
+	duplicateWarn     = "warn"
+	duplicateError    = "error"
+	duplicateLastWins = "last-wins"
+)
+
+// onDuplicate selects how resources defined more than once are handled
+var onDuplicate string
+
+// isValidDuplicateMode reports whether mode is a supported --on-duplicate value
+func isValidDuplicateMode(mode string) bool {
+	switch mode {
+	case duplicateWarn, duplicateError, duplicateLastWins:
+		return true
+	}
+	return false
+}
+
+// checkDuplicates reports the resources defined more than once by apiVersion, kind,
+// namespace and name, with the files and lines of each definition. With last-wins the
+// files are returned with only the last definition of each; with error the run stops.
+func checkDuplicates(files []manifest.File, warnings *resultWarnings) ([]manifest.File, error) {
+	dups := manifest.FindDuplicates(files)
+	if len(dups) == 0 {
+		return files, nil
+	}
+
+	switch onDuplicate {
+	case duplicateError:
+		for _, d := range dups {
+			Log.Errorf("%s", d)
+		}
+		return nil, errors.ErrDuplicateResources(len(dups), dups[0].String())
+
+	case duplicateLastWins:
+		for _, d := range dups {
+			locations := d.Locations()
+			warnings.warnf("%s; keeping the last one at %s", d, locations[len(locations)-1])
+		}
+		kept, err := manifest.KeepLast(files)
+		if err != nil {
+			return nil, errors.ErrReadingManifestFile(err)
+		}
+		return kept, nil
+	}
+
+	for _, d := range dups {
+		warnings.warnf("%s; all of them are uploaded", d)
+	}
+	return files, nil
+}
@@ -0,0 +1,194 @@
+package kanvas_snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/design"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Export command flags
+	exportOutputDir string
+	exportCombined  bool
+)
+
+// exportCmd downloads a Meshery design and writes it back out as Kubernetes manifests
+var exportCmd = &cobra.Command{
+	Use:   "export <designID>",
+	Short: "Export a Meshery design as Kubernetes manifests",
+	Long: `Export a Meshery design as Kubernetes manifests.
+
+		This command fetches a design from Meshery and writes its Kubernetes components
+		back out as YAML, so designs edited visually in Kanvas can be committed to git.
+
+		Example usage:
+
+		kubectl kanvas-snapshot export 3f1c9e2a-... -o ./out/
+		kubectl kanvas-snapshot export 3f1c9e2a-... -o ./out/ --combined`,
+	Args: cobra.ExactArgs(1),
+	RunE: exportRunE,
+}
+
+// mesheryPattern represents the subset of Meshery's pattern response used for export
+type mesheryPattern struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	PatternFile string `json:"pattern_file"`
+}
+
+// FetchMesheryDesign retrieves a design from Meshery
+func FetchMesheryDesign(designID string) (*mesheryPattern, error) {
+	fullURL := fmt.Sprintf("%s/api/pattern/%s", MesheryAPIBaseURL, url.PathEscape(designID))
+	Log.Infof("Fetching design from: %s", fullURL)
+
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, errors.ErrFetchingDesign(designID, err)
+	}
+	setAuthCookie(req)
+
+	client := newHTTPClient()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.ErrFetchingDesign(designID, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.ErrFetchingDesign(designID, err)
+	}
+
+	if isAuthFailure(resp, body) {
+		return nil, authError(resp, body)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.ErrDesignNotFound(designID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ErrUnexpectedResponseCode(resp.StatusCode, trimString(string(body), 200))
+	}
+
+	pattern := &mesheryPattern{}
+	if err := json.Unmarshal(body, pattern); err != nil {
+		return nil, errors.ErrDecodingAPI(err)
+	}
+	if pattern.PatternFile == "" {
+		return nil, errors.ErrFetchingDesign(designID, fmt.Errorf("design has no pattern file"))
+	}
+
+	return pattern, nil
+}
+
+// writeManifests writes the manifests either as one file per resource or as a single combined file
+func writeManifests(manifests []design.Manifest, outputDir, combinedName string, combined bool) ([]string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	if combined {
+		docs := make([]string, 0, len(manifests))
+		for _, m := range manifests {
+			out, err := m.Marshal()
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, string(out))
+		}
+		path := filepath.Join(outputDir, design.SafeFileName(combinedName))
+		if err := os.WriteFile(path, []byte(strings.Join(docs, "---\n")), 0644); err != nil {
+			return nil, err
+		}
+		return append(written, path), nil
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range manifests {
+		name := uniqueFileName(m, seen)
+		seen[name] = true
+
+		out, err := m.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(outputDir, name)
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// uniqueFileName returns a file name for the manifest that is not in seen. Resources with the
+// same kind and name get their namespace added, and a numeric suffix if that is taken too.
+func uniqueFileName(m design.Manifest, seen map[string]bool) string {
+	name := m.FileName()
+	if !seen[name] {
+		return name
+	}
+	base := fmt.Sprintf("%s-%s", m.Kind, m.Name)
+	if m.Namespace != "" {
+		base = fmt.Sprintf("%s-%s-%s", m.Namespace, m.Kind, m.Name)
+		if name = design.SafeFileName(base); !seen[name] {
+			return name
+		}
+	}
+	for i := 2; ; i++ {
+		if name = design.SafeFileName(fmt.Sprintf("%s-%d", base, i)); !seen[name] {
+			return name
+		}
+	}
+}
+
+// RunE function for the export command
+func exportRunE(_ *cobra.Command, args []string) error {
+	designID := args[0]
+
+	pattern, err := FetchMesheryDesign(designID)
+	if err != nil {
+		return err
+	}
+
+	d, err := design.Parse([]byte(pattern.PatternFile))
+	if err != nil {
+		return errors.ErrExportingDesign(err)
+	}
+
+	manifests := d.ToManifests()
+	if len(manifests) == 0 {
+		return errors.ErrExportingDesign(fmt.Errorf("design '%s' has no Kubernetes components", designID))
+	}
+	Log.Infof("Design '%s' contains %d Kubernetes resource(s)", pattern.Name, len(manifests))
+
+	name := pattern.Name
+	if name == "" {
+		name = designID
+	}
+
+	written, err := writeManifests(manifests, exportOutputDir, name, exportCombined)
+	if err != nil {
+		return errors.ErrExportingDesign(err)
+	}
+
+	for _, path := range written {
+		Log.Infof("Wrote %s", path)
+	}
+	return nil
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutputDir, "output-dir", "o", ".", "Directory to write the exported manifests to")
+	exportCmd.Flags().BoolVar(&exportCombined, "combined", false, "Write all resources to a single file instead of one file per resource")
+}
@@ -0,0 +1,42 @@
+package kanvas_snapshot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/design"
+)
+
+func TestWriteManifestsUniqueNames(t *testing.T) {
+	manifest := func(namespace string) design.Manifest {
+		return design.Manifest{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       "settings",
+			Namespace:  namespace,
+			Object:     map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "settings"}},
+		}
+	}
+	manifests := []design.Manifest{manifest(""), manifest(""), manifest("shop"), manifest("shop"), manifest("")}
+
+	dir := t.TempDir()
+	written, err := writeManifests(manifests, dir, "", false)
+	if err != nil {
+		t.Fatalf("writeManifests: %v", err)
+	}
+	want := []string{
+		"configmap-settings.yaml",
+		"configmap-settings-2.yaml",
+		"shop-configmap-settings.yaml",
+		"shop-configmap-settings-2.yaml",
+		"configmap-settings-3.yaml",
+	}
+	if len(written) != len(want) {
+		t.Fatalf("wrote %v, want %v", written, want)
+	}
+	for i, path := range written {
+		if path != filepath.Join(dir, want[i]) {
+			t.Errorf("file %d = %s, want %s", i, filepath.Base(path), want[i])
+		}
+	}
+}
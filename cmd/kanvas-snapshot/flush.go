@@ -0,0 +1,370 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/cache"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/config"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/history"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/spool"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// noSpool fails runs that cannot reach Meshery or GitHub instead of queueing them
+	noSpool bool
+	// flush command flags
+	flushList bool
+	flushDrop string
+)
+
+// openSpool returns the queue of uploads waiting for Meshery or GitHub
+func openSpool() (*spool.Spool, error) {
+	dir, err := spool.DefaultDir()
+	if err != nil {
+		return nil, errors.ErrSpool(err)
+	}
+	return spool.New(dir), nil
+}
+
+// queueUpload saves the prepared design upload, and the workflow dispatch if dispatch is set,
+// to be sent by 'flush'. It returns the ID of the queued item.
+func queueUpload(content, name, hash string, inputs []string, dispatch bool) (string, error) {
+	payload, err := newDesignPayload(content, name, email)
+	if err != nil {
+		return "", err
+	}
+	item := &spool.Item{
+		Server:  MesheryAPIBaseURL,
+		Profile: ActiveProfile,
+		Context: mesheryContext,
+		Upload: &spool.Upload{
+			Endpoint:   SnapshotEndpoint,
+			DesignName: name,
+			Payload:    payload,
+			Hash:       hash,
+			Inputs:     inputs,
+		},
+	}
+	if dispatch {
+		item.Dispatch = newSpoolDispatch("", "")
+	}
+	return addSpoolItem(item)
+}
+
+// queueDispatch saves the workflow dispatch of an existing design to be sent by 'flush'
+func queueDispatch(designID string) (string, error) {
+	return addSpoolItem(&spool.Item{
+		Server:   MesheryAPIBaseURL,
+		Profile:  ActiveProfile,
+		Context:  mesheryContext,
+		Dispatch: newSpoolDispatch(designID, defaultAssetLocation(designID)),
+	})
+}
+
+// newSpoolDispatch returns a dispatch of the configured snapshot workflow
+func newSpoolDispatch(designID, assetLocation string) *spool.Dispatch {
+	owner, repo, workflow, ref := workflowTarget()
+	return &spool.Dispatch{
+		DesignID:      designID,
+		AssetLocation: assetLocation,
+		Owner:         owner,
+		Repo:          repo,
+		Workflow:      workflow,
+		Ref:           ref,
+	}
+}
+
+// spoolOnUnreachable queues the request with queue if err means Meshery or GitHub could not be
+// reached and queueing is enabled. It returns the ID of the queued item, or an empty string if
+// nothing was queued. The run fails either way.
+func spoolOnUnreachable(err error, queue func() (string, error)) string {
+	if noSpool || !errors.IsUnreachable(err) {
+		return ""
+	}
+	id, qerr := queue()
+	if qerr != nil {
+		Log.Warnf("Could not queue the request: %s", errorDetails(qerr))
+		return ""
+	}
+	return id
+}
+
+// queuedResult returns the error of a run whose request was queued after the result was
+// written. The run still fails with the unreachable error, so CI does not report a queued
+// upload as done.
+func queuedResult(unreachable, finishErr error) error {
+	if finishErr != nil {
+		return finishErr
+	}
+	return unreachable
+}
+
+// addSpoolItem queues the item and tells the user how to send it
+func addSpoolItem(item *spool.Item) (string, error) {
+	s, err := openSpool()
+	if err != nil {
+		return "", err
+	}
+	if err := s.Add(item); err != nil {
+		return "", errors.ErrSpool(err)
+	}
+	Log.Warnf("Queued %s of %s as %s in %s", item.Kind(), item.Name(), item.ID, s.Dir())
+	Log.Info("Run 'kubectl kanvas-snapshot flush' to send it once the network is back.")
+	return item.ID, nil
+}
+
+// flushCmd retries queued uploads and workflow dispatches
+var flushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Retry uploads and workflow dispatches queued while Meshery or GitHub was unreachable",
+	Long: `Retry uploads and workflow dispatches queued while Meshery or GitHub was unreachable.
+
+		When the Meshery server or GitHub cannot be reached, the prepared request is saved in
+		~/.meshery/kubectl-kanvas-snapshot/spool instead of being lost. This command sends the
+		queued items in the order they were queued. Uploads go to the Meshery server they
+		were prepared for, with the tokens of the profile and mesheryctl context they were
+		queued with. Items whose profile or context now points to another server are skipped
+		with a warning. Items that fail or are skipped stay queued.
+
+		Example usage:
+
+		kubectl kanvas-snapshot flush
+		kubectl kanvas-snapshot flush --list
+		kubectl kanvas-snapshot flush --drop 20261018T120000.000000000-1a2b3c4d`,
+	Args: cobra.NoArgs,
+	RunE: flushRunE,
+}
+
+func flushRunE(cmd *cobra.Command, _ []string) error {
+	s, err := openSpool()
+	if err != nil {
+		return err
+	}
+
+	// Only one flush at a time, so no item is sent twice
+	unlock, err := s.Lock()
+	if err != nil {
+		return errors.ErrSpool(err)
+	}
+	defer unlock()
+
+	items, err := s.List()
+	if err != nil {
+		return errors.ErrSpool(err)
+	}
+
+	if flushList {
+		return writeSpoolTable(os.Stdout, items)
+	}
+	if flushDrop != "" {
+		return dropSpoolItem(s, items, flushDrop)
+	}
+	if len(items) == 0 {
+		Log.Info("No queued uploads")
+		return nil
+	}
+
+	var firstErr error
+	sent, skipped := 0, 0
+	for _, item := range items {
+		restore, err := useItemCredentials(cmd, item)
+		if err != nil {
+			Log.Warnf("Skipping queued %s of %s: %s", item.Kind(), item.Name(), errorDetails(err))
+			skipped++
+			continue
+		}
+		err = flushItem(s, item)
+		restore()
+		if err != nil {
+			Log.Warnf("Could not send %s of %s: %s", item.Kind(), item.Name(), errorDetails(err))
+			item.Attempts++
+			item.LastError = errorDetails(err)
+			if err := s.Update(item); err != nil {
+				return errors.ErrSpool(err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sent++
+	}
+
+	Log.Infof("Sent %d of %d queued item(s)", sent, len(items))
+	if skipped > 0 {
+		Log.Warnf("Skipped %d queued item(s). Point their profile or mesheryctl context back to their server, or remove them with --drop.", skipped)
+	}
+	return firstErr
+}
+
+// useItemCredentials selects the Meshery server and tokens of the profile and mesheryctl
+// context the item was queued with, so a token is only sent to the server it belongs to.
+// It fails if they now resolve to another server. The returned function restores the
+// current settings.
+func useItemCredentials(cmd *cobra.Command, item *spool.Item) (func(), error) {
+	resolved, provider := Settings, ProviderName
+	if item.Profile != ActiveProfile || item.Context != mesheryContext {
+		var err error
+		if resolved, provider, err = resolveItemSettings(cmd, item.Profile, item.Context); err != nil {
+			return nil, err
+		}
+	}
+
+	server := resolved.Get("meshery.url")
+	if strings.TrimSuffix(server, "/") != strings.TrimSuffix(item.Server, "/") {
+		return nil, fmt.Errorf("it was queued for %s, but %s now uses %s", item.Server, itemSelection(item), server)
+	}
+
+	current := []string{ProviderToken, ProviderName, WorkflowAccessToken, GitHubAPIBaseURL}
+	ProviderToken = resolved.Get("meshery.token")
+	ProviderName = provider
+	WorkflowAccessToken = resolved.Get("github.token")
+	GitHubAPIBaseURL = strings.TrimSuffix(resolved.Get("github.api_url"), "/")
+	return func() {
+		ProviderToken, ProviderName, WorkflowAccessToken, GitHubAPIBaseURL = current[0], current[1], current[2], current[3]
+	}, nil
+}
+
+// resolveItemSettings resolves the settings of another profile and mesheryctl context, and
+// the provider of its Meshery token. The current configuration is left unchanged.
+func resolveItemSettings(cmd *cobra.Command, profile, context string) (*config.Resolved, string, error) {
+	cfg, err := config.LoadConfig(ConfigPath)
+	if err != nil {
+		return nil, "", errors.ErrInvalidConfig(err)
+	}
+	if profile != "" {
+		if _, err := cfg.ApplyProfile(profile); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// The credential fallback reads the configuration and context from globals and
+	// records the provider of the token it finds in ProviderName
+	currentConfig, currentContext, currentProvider := Config, mesheryContext, ProviderName
+	defer func() {
+		Config, mesheryContext, ProviderName = currentConfig, currentContext, currentProvider
+	}()
+	Config, mesheryContext, ProviderName = cfg, context, defaultProvider
+
+	resolved, err := resolveSettings(cmd, profile)
+	if err != nil {
+		return nil, "", err
+	}
+	return resolved, ProviderName, nil
+}
+
+// itemSelection describes the profile and mesheryctl context of a queued item
+func itemSelection(item *spool.Item) string {
+	switch {
+	case item.Profile != "" && item.Context != "":
+		return fmt.Sprintf("profile '%s' with mesheryctl context '%s'", item.Profile, item.Context)
+	case item.Profile != "":
+		return fmt.Sprintf("profile '%s'", item.Profile)
+	case item.Context != "":
+		return fmt.Sprintf("mesheryctl context '%s'", item.Context)
+	}
+	return "the default configuration"
+}
+
+// flushItem sends a queued item and removes it from the spool once everything was sent.
+// After a successful upload the item is updated, so a failed dispatch does not upload again.
+func flushItem(s *spool.Spool, item *spool.Item) error {
+	// Requests go to the server the item was prepared for
+	MesheryAPIBaseURL = item.Server
+
+	rec := history.Record{}
+	if item.Upload != nil {
+		Log.Infof("Uploading queued design %s to %s...", item.Upload.DesignName, item.Server)
+		designID, err := postMesheryDesign(item.Server, item.Upload.Endpoint, item.Upload.Payload)
+		if err != nil {
+			return err
+		}
+		Log.Infof("View your design in Meshery: %s", getDesignViewURL(designID))
+
+		rec = history.Record{
+			Inputs:     item.Upload.Inputs,
+			Hash:       item.Upload.Hash,
+			DesignID:   designID,
+			DesignName: item.Upload.DesignName,
+			ViewURL:    getDesignViewURL(designID),
+		}
+		newCachedUpload().store(rec.Hash, cache.Entry{DesignID: designID, DesignName: rec.DesignName})
+
+		if item.Dispatch == nil {
+			recordHistory(rec)
+			return s.Remove(item.ID)
+		}
+		item.Dispatch.DesignID = designID
+		item.Dispatch.AssetLocation = defaultAssetLocation(designID)
+		item.Upload = nil
+		if err := s.Update(item); err != nil {
+			recordHistory(rec)
+			return errors.ErrSpool(err)
+		}
+	}
+
+	d := item.Dispatch
+	if WorkflowAccessToken == "" {
+		if rec.DesignID != "" {
+			recordHistory(rec)
+		}
+		return errors.ErrGeneratingSnapshot(fmt.Errorf("GITHUB_TOKEN is not set, the workflow dispatch of design %s stays queued", d.DesignID))
+	}
+	workflow, err := dispatchSnapshotWorkflow(d.Owner, d.Repo, d.Workflow, d.Ref, d.DesignID, d.AssetLocation, WorkflowAccessToken)
+	if err != nil {
+		if rec.DesignID != "" {
+			recordHistory(rec)
+		}
+		return err
+	}
+
+	// Record a dispatch queued on its own under the design it belongs to
+	if rec.DesignID == "" {
+		rec = history.Record{DesignID: d.DesignID, ViewURL: getDesignViewURL(d.DesignID)}
+	} else {
+		newCachedUpload().store(rec.Hash, cache.Entry{DesignID: d.DesignID, DesignName: rec.DesignName, AssetLocation: d.AssetLocation})
+	}
+	rec.WorkflowRun = workflow.URL()
+	rec.AssetLocation = d.AssetLocation
+	recordHistory(rec)
+	return s.Remove(item.ID)
+}
+
+// dropSpoolItem removes a queued item without sending it
+func dropSpoolItem(s *spool.Spool, items []*spool.Item, id string) error {
+	for _, item := range items {
+		if item.ID == id {
+			if err := s.Remove(id); err != nil {
+				return errors.ErrSpool(err)
+			}
+			Log.Infof("Dropped queued %s of %s", item.Kind(), item.Name())
+			return nil
+		}
+	}
+	return errors.ErrSpool(fmt.Errorf("no queued item %s", id))
+}
+
+// writeSpoolTable prints the queued items as a table
+func writeSpoolTable(w io.Writer, items []*spool.Item) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKIND\tNAME\tSERVER\tATTEMPTS\tLAST ERROR")
+	for _, item := range items {
+		lastError := item.LastError
+		if lastError == "" {
+			lastError = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", item.ID, item.Kind(), item.Name(), item.Server, item.Attempts, trimString(lastError, 60))
+	}
+	return tw.Flush()
+}
+
+func init() {
+	flushCmd.Flags().BoolVar(&flushList, "list", false, "List the queued items without sending them")
+	flushCmd.Flags().StringVar(&flushDrop, "drop", "", "Remove the queued item with this ID without sending it")
+}
@@ -0,0 +1,207 @@
+package kanvas_snapshot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/config"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/spool"
+)
+
+// fakeSnapshotServers are a Meshery server creating designs and a GitHub API accepting
+// workflow dispatches, counting the requests they receive
+type fakeSnapshotServers struct {
+	meshery, github *httptest.Server
+
+	mu         sync.Mutex
+	uploads    int
+	dispatches []string
+	// dispatchStatus is the response to workflow dispatches
+	dispatchStatus int
+}
+
+func newFakeSnapshotServers(t *testing.T) *fakeSnapshotServers {
+	t.Helper()
+	f := &fakeSnapshotServers{dispatchStatus: http.StatusNoContent}
+	f.meshery = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/pattern/import" {
+			http.NotFound(w, r)
+			return
+		}
+		f.mu.Lock()
+		f.uploads++
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "d-1"}`))
+	}))
+	f.github = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/octo/app/actions/workflows/kanvas.yaml/dispatches" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Inputs map[string]string `json:"inputs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.dispatches = append(f.dispatches, body.Inputs["designID"])
+		w.WriteHeader(f.dispatchStatus)
+	}))
+	t.Cleanup(f.meshery.Close)
+	t.Cleanup(f.github.Close)
+	return f
+}
+
+// useFlushGlobals points the flush at the fake servers and keeps the cache and history
+// in temporary directories
+func useFlushGlobals(t *testing.T, f *fakeSnapshotServers) {
+	t.Helper()
+	setupLogger(io.Discard)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", home)
+	oldURL, oldGitHub, oldToken, oldWorkflowToken := MesheryAPIBaseURL, GitHubAPIBaseURL, ProviderToken, WorkflowAccessToken
+	oldSettings, oldProfile, oldContext := Settings, ActiveProfile, mesheryContext
+	t.Cleanup(func() {
+		MesheryAPIBaseURL, GitHubAPIBaseURL, ProviderToken, WorkflowAccessToken = oldURL, oldGitHub, oldToken, oldWorkflowToken
+		Settings, ActiveProfile, mesheryContext = oldSettings, oldProfile, oldContext
+	})
+	MesheryAPIBaseURL, GitHubAPIBaseURL = "", f.github.URL
+	ProviderToken, WorkflowAccessToken = "meshery-token", "github-token"
+	ActiveProfile, mesheryContext = "", ""
+	Settings = &config.Resolved{Values: []config.Value{
+		{Key: "meshery.url", Value: f.meshery.URL},
+		{Key: "meshery.token", Value: "meshery-token"},
+		{Key: "github.token", Value: "github-token"},
+		{Key: "github.api_url", Value: f.github.URL},
+	}}
+}
+
+// queuedUpload returns an upload of design web followed by a dispatch to octo/app
+func queuedUpload(server string) *spool.Item {
+	return &spool.Item{
+		Server: server,
+		Upload: &spool.Upload{
+			Endpoint:   "/api/pattern/import",
+			DesignName: "web",
+			Payload:    json.RawMessage(`{"name": "web"}`),
+			Hash:       "hash",
+		},
+		Dispatch: &spool.Dispatch{Owner: "octo", Repo: "app", Workflow: "kanvas.yaml", Ref: "master"},
+	}
+}
+
+func TestFlushItemUploadThenDispatch(t *testing.T) {
+	f := newFakeSnapshotServers(t)
+	useFlushGlobals(t, f)
+	s := spool.New(t.TempDir())
+	if err := s.Add(queuedUpload(f.meshery.URL)); err != nil {
+		t.Fatal(err)
+	}
+	next := func() *spool.Item {
+		t.Helper()
+		items, err := s.List()
+		if err != nil || len(items) != 1 {
+			t.Fatalf("spool holds %v, %v, want one item", items, err)
+		}
+		return items[0]
+	}
+
+	// The upload succeeds, the dispatch fails
+	f.dispatchStatus = http.StatusInternalServerError
+	if err := flushItem(s, next()); err == nil {
+		t.Fatal("flushItem with a failing dispatch succeeded, want an error")
+	}
+	item := next()
+	if item.Upload != nil || item.Dispatch.DesignID != "d-1" || item.Dispatch.AssetLocation != defaultAssetLocation("d-1") {
+		t.Errorf("item after the upload = %s %+v, want a dispatch of design d-1", item.Kind(), item.Dispatch)
+	}
+
+	// Without a GitHub token the dispatch stays queued
+	WorkflowAccessToken = ""
+	if err := flushItem(s, next()); err == nil || !strings.Contains(errorDetails(err), "stays queued") {
+		t.Errorf("flushItem without a GitHub token = %v, want the dispatch kept", err)
+	}
+	WorkflowAccessToken = "github-token"
+
+	// Retrying only dispatches
+	f.dispatchStatus = http.StatusNoContent
+	if err := flushItem(s, next()); err != nil {
+		t.Fatalf("flushItem: %v", err)
+	}
+	if f.uploads != 1 {
+		t.Errorf("design uploaded %d times, want once", f.uploads)
+	}
+	if len(f.dispatches) != 2 || f.dispatches[1] != "d-1" {
+		t.Errorf("dispatched %v, want design d-1 twice", f.dispatches)
+	}
+	if items, _ := s.List(); len(items) != 0 {
+		t.Errorf("spool holds %v after sending everything", items)
+	}
+}
+
+func TestUseItemCredentials(t *testing.T) {
+	f := newFakeSnapshotServers(t)
+	useFlushGlobals(t, f)
+	ProviderToken, WorkflowAccessToken = "", ""
+
+	// The server matches up to a trailing slash
+	restore, err := useItemCredentials(flushCmd, queuedUpload(f.meshery.URL+"/"))
+	if err != nil {
+		t.Fatalf("useItemCredentials: %v", err)
+	}
+	if ProviderToken != "meshery-token" || WorkflowAccessToken != "github-token" || GitHubAPIBaseURL != f.github.URL {
+		t.Errorf("credentials = %q, %q, %s", ProviderToken, WorkflowAccessToken, GitHubAPIBaseURL)
+	}
+	restore()
+	if ProviderToken != "" || WorkflowAccessToken != "" {
+		t.Errorf("restored credentials = %q, %q, want none", ProviderToken, WorkflowAccessToken)
+	}
+
+	// No token is sent to another server than the current configuration's
+	if _, err := useItemCredentials(flushCmd, queuedUpload("http://other.example.com")); err == nil || !strings.Contains(err.Error(), "it was queued for http://other.example.com") {
+		t.Errorf("useItemCredentials for another server = %v, want an error", err)
+	}
+	if ProviderToken != "" {
+		t.Errorf("credentials set for another server: %q", ProviderToken)
+	}
+}
+
+func TestFlushSkipsItemsOfOtherServers(t *testing.T) {
+	f := newFakeSnapshotServers(t)
+	useFlushGlobals(t, f)
+	s, err := openSpool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := queuedUpload("http://other.example.com")
+	ours := queuedUpload(f.meshery.URL)
+	for _, item := range []*spool.Item{other, ours} {
+		if err := s.Add(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := flushRunE(flushCmd, nil); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if f.uploads != 1 || len(f.dispatches) != 1 {
+		t.Errorf("sent %d upload(s) and %d dispatch(es), want the item of this server only", f.uploads, len(f.dispatches))
+	}
+	items, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ID != other.ID || items[0].Upload == nil || items[0].Attempts != 0 {
+		t.Errorf("spool holds %v, want the skipped item unchanged", items)
+	}
+}
@@ -0,0 +1,114 @@
+package kanvas_snapshot
+
+import (
+	"path/filepath"
+	"reflect"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/gitdiff"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// gitDiffBase limits the input to manifests changed relative to this git ref
+var gitDiffBase string
+
+// gitDesignName returns the default design name with --git-diff: branch name and short SHA
+func gitDesignName(path string) (string, error) {
+	repo, err := gitdiff.Open(path)
+	if err != nil {
+		return "", errors.ErrGitDiff(err)
+	}
+	name, err := repo.DefaultName()
+	if err != nil {
+		return "", errors.ErrGitDiff(err)
+	}
+	return name, nil
+}
+
+// selectGitChanges returns the resources added or modified relative to the merge base of
+// base and HEAD in the repository containing path, followed by the unchanged resources they
+// reference, in manifest order. Resources in a modified file that are identical at the base
+// are not considered changed.
+func selectGitChanges(files []manifest.File, path, base string) ([]manifest.Resource, error) {
+	repo, err := gitdiff.Open(path)
+	if err != nil {
+		return nil, errors.ErrGitDiff(err)
+	}
+	commit, err := repo.MergeBase(base)
+	if err != nil {
+		return nil, errors.ErrGitDiff(err)
+	}
+	paths, err := repo.ChangedFiles(commit)
+	if err != nil {
+		return nil, errors.ErrGitDiff(err)
+	}
+	changedFiles := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		changedFiles[realPath(p)] = true
+	}
+
+	resources := manifestResources(files)
+	var changed []int
+	baseObjects := make(map[string]map[string]map[string]interface{})
+	for i, r := range resources {
+		source := realPath(r.Source)
+		if !changedFiles[source] {
+			continue
+		}
+		objects, ok := baseObjects[source]
+		if !ok {
+			if objects, err = baseResources(repo, commit, source); err != nil {
+				return nil, errors.ErrGitDiff(err)
+			}
+			baseObjects[source] = objects
+		}
+		if old, ok := objects[r.Ref()]; !ok || !reflect.DeepEqual(old, r.Object) {
+			changed = append(changed, i)
+		}
+	}
+
+	selected := manifest.WithReferences(manifest.InferRelationships(resources), changed)
+	Log.Infof("%d resource(s) changed relative to %s (merge base %s), %d unchanged resource(s) included as references", len(changed), base, shortSHA(commit), len(selected)-len(changed))
+
+	result := make([]manifest.Resource, 0, len(selected))
+	for _, i := range selected {
+		result = append(result, resources[i])
+	}
+	return result, nil
+}
+
+// baseResources returns the objects of the file at the commit by resource reference,
+// or none if the file did not exist
+func baseResources(repo *gitdiff.Repo, commit, path string) (map[string]map[string]interface{}, error) {
+	content, ok, err := repo.Show(commit, path)
+	if err != nil || !ok {
+		return nil, err
+	}
+	objects := make(map[string]map[string]interface{})
+	// A base version that no longer parses counts as entirely changed
+	resources, _ := manifest.Parse(path, content)
+	for _, r := range resources {
+		objects[r.Ref()] = r.Object
+	}
+	return objects, nil
+}
+
+// realPath returns the absolute path with symbolic links resolved, so paths reported
+// by git match the manifest paths given on the command line
+func realPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
+// shortSHA abbreviates a commit SHA for log messages
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
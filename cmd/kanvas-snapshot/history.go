@@ -0,0 +1,294 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/history"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// history command flags
+	historyLimit     int
+	historyOutput    string
+	historyOlderThan string
+	historyKeep      int
+	historyPruneAll  bool
+	historyOpenAsset bool
+	historyNoBrowser bool
+)
+
+// historyEntry is a history record with its number, counted from the newest record
+type historyEntry struct {
+	N              int `json:"n" yaml:"n"`
+	history.Record `yaml:",inline"`
+}
+
+// openHistory returns the history store
+func openHistory() (*history.Store, error) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return nil, errors.ErrHistory(err)
+	}
+	return history.New(path), nil
+}
+
+// recordHistory adds a design created or reused by this run to the history.
+// Failures are logged and never fail the run.
+func recordHistory(r history.Record) {
+	if r.DesignID == "" {
+		return
+	}
+	store, err := openHistory()
+	if err != nil {
+		Log.Warnf("Could not record run in history: %v", errorDetails(err))
+		return
+	}
+	r.Time = time.Now().UTC()
+	r.Server = MesheryAPIBaseURL
+	if err := store.Append(r); err != nil {
+		Log.Warnf("Could not record run in history: %v", err)
+	}
+}
+
+// absPaths returns the paths made absolute, so history entries stay meaningful from other directories
+func absPaths(paths ...string) []string {
+	abs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if a, err := filepath.Abs(p); err == nil {
+			p = a
+		}
+		abs = append(abs, p)
+	}
+	return abs
+}
+
+// historyEntries returns the records numbered from the newest, newest first
+func historyEntries(records []history.Record) []historyEntry {
+	entries := make([]historyEntry, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		entries = append(entries, historyEntry{N: len(records) - i, Record: records[i]})
+	}
+	return entries
+}
+
+// historyCmd groups the commands managing the history of created designs
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List, search and prune the designs created by previous runs",
+	Long: `List, search and prune the designs created by previous runs.
+
+		Every run that creates or reuses a design records the time, input paths, manifest
+		hash, Meshery server, design ID, view URL, workflow run and snapshot location in
+		~/.meshery/kubectl-kanvas-snapshot/history.jsonl. Entries are numbered from the
+		newest, which is 1.
+
+		Example usage:
+
+		kubectl kanvas-snapshot history list
+		kubectl kanvas-snapshot history search payments
+		kubectl kanvas-snapshot history open 1
+		kubectl kanvas-snapshot history prune --older-than 30d`,
+	PersistentPreRunE: skipSettings,
+}
+
+// historyListCmd prints the most recent history entries
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the most recent designs",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return printHistory(func(history.Record) bool { return true })
+	},
+}
+
+// historySearchCmd prints the history entries matching a query
+var historySearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "List the designs whose name, ID, inputs, server or hash contain the query",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return printHistory(func(r history.Record) bool { return r.Matches(args[0]) })
+	},
+}
+
+// historyPruneCmd removes old history entries
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove old entries from the history",
+	Long: `Remove old entries from the history.
+
+		Example usage:
+
+		kubectl kanvas-snapshot history prune --older-than 30d
+		kubectl kanvas-snapshot history prune --keep 100
+		kubectl kanvas-snapshot history prune --all`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		if !historyPruneAll && historyOlderThan == "" && historyKeep <= 0 {
+			return errors.ErrInvalidFlags(fmt.Errorf("expected --older-than, --keep or --all"))
+		}
+		var cutoff time.Time
+		if historyOlderThan != "" {
+			age, err := parseAge(historyOlderThan)
+			if err != nil {
+				return errors.ErrInvalidFlags(err)
+			}
+			cutoff = time.Now().Add(-age)
+		}
+
+		store, err := openHistory()
+		if err != nil {
+			return err
+		}
+		removed, err := store.Prune(func(r history.Record, n int) bool {
+			return historyPruneAll ||
+				(!cutoff.IsZero() && r.Time.Before(cutoff)) ||
+				(historyKeep > 0 && n > historyKeep)
+		})
+		if err != nil {
+			return errors.ErrHistory(err)
+		}
+		Log.Infof("Removed %d history entr%s", removed, pluralY(removed))
+		return nil
+	},
+}
+
+// historyOpenCmd opens the design of a history entry in the browser
+var historyOpenCmd = &cobra.Command{
+	Use:   "open <n>",
+	Short: "Open the design of history entry n in the browser (1 is the newest)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return errors.ErrHistory(fmt.Errorf("invalid entry number '%s'", args[0]))
+		}
+
+		store, err := openHistory()
+		if err != nil {
+			return err
+		}
+		records, err := store.List()
+		if err != nil {
+			return errors.ErrHistory(err)
+		}
+		if n > len(records) {
+			return errors.ErrHistory(fmt.Errorf("no history entry %d, the history has %d entries", n, len(records)))
+		}
+		r := records[len(records)-n]
+
+		url := r.ViewURL
+		if historyOpenAsset {
+			if r.AssetLocation == "" {
+				return errors.ErrHistory(fmt.Errorf("no snapshot was triggered for history entry %d", n))
+			}
+			url = r.AssetLocation
+		}
+
+		// Print the URL so it can be used without a browser
+		fmt.Println(url)
+		if historyNoBrowser {
+			return nil
+		}
+		if err := openBrowser(url); err != nil {
+			Log.Warnf("Could not open browser: %v", err)
+		}
+		return nil
+	},
+}
+
+// printHistory prints the entries matching keep, newest first, up to --limit
+func printHistory(keep func(history.Record) bool) error {
+	if !isValidOutputFormat(historyOutput) {
+		return errors.ErrInvalidFlags(fmt.Errorf("invalid output format '%s': must be one of json, yaml", historyOutput))
+	}
+
+	store, err := openHistory()
+	if err != nil {
+		return err
+	}
+	records, err := store.List()
+	if err != nil {
+		return errors.ErrHistory(err)
+	}
+
+	entries := []historyEntry{}
+	for _, e := range historyEntries(records) {
+		if historyLimit > 0 && len(entries) == historyLimit {
+			break
+		}
+		if keep(e.Record) {
+			entries = append(entries, e)
+		}
+	}
+
+	if historyOutput != "" {
+		return writeResult(os.Stdout, historyOutput, entries)
+	}
+	if len(entries) == 0 {
+		Log.Info("No history entries found")
+		return nil
+	}
+	return writeHistoryTable(os.Stdout, entries)
+}
+
+// writeHistoryTable prints history entries as a table
+func writeHistoryTable(w io.Writer, entries []historyEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTIME\tNAME\tDESIGN ID\tSERVER\tINPUTS")
+	for _, e := range entries {
+		name := e.DesignName
+		if e.Cached {
+			name += " (cached)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", e.N, e.Time.Local().Format("2006-01-02 15:04"), name, e.DesignID, e.Server, trimString(strings.Join(e.Inputs, ","), 60))
+	}
+	return tw.Flush()
+}
+
+// parseAge parses a duration such as 36h, also accepting days such as 30d
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s': expected e.g. 30d or 12h", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age '%s': expected e.g. 30d or 12h", s)
+	}
+	return age, nil
+}
+
+// pluralY returns the suffix of "entry" for n entries
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+func init() {
+	for _, c := range []*cobra.Command{historyListCmd, historySearchCmd} {
+		c.Flags().IntVar(&historyLimit, "limit", 20, "Maximum number of entries to print (0 for all)")
+		c.Flags().StringVarP(&historyOutput, "output", "o", "", "Print the entries as json or yaml")
+	}
+	historyPruneCmd.Flags().StringVar(&historyOlderThan, "older-than", "", "Remove entries older than this age, e.g. 30d or 12h")
+	historyPruneCmd.Flags().IntVar(&historyKeep, "keep", 0, "Keep only this many of the newest entries")
+	historyPruneCmd.Flags().BoolVar(&historyPruneAll, "all", false, "Remove all entries")
+	historyOpenCmd.Flags().BoolVar(&historyOpenAsset, "asset", false, "Open the snapshot location instead of the design")
+	historyOpenCmd.Flags().BoolVar(&historyNoBrowser, "no-browser", false, "Only print the URL")
+
+	historyCmd.AddCommand(historyListCmd, historySearchCmd, historyPruneCmd, historyOpenCmd)
+}
@@ -0,0 +1,69 @@
+package kanvas_snapshot
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/config"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/httpclient"
+)
+
+var (
+	// httpOptions holds the proxy, TLS and timeout settings for outbound requests
+	httpOptions = httpclient.Options{Timeout: 30 * time.Second}
+	// httpTransport is shared by all requests to Meshery and GitHub
+	httpTransport *http.Transport
+)
+
+// setupHTTPTransport builds the shared transport from the resolved settings
+func setupHTTPTransport(resolved *config.Resolved) error {
+	httpOptions.ProxyURL = resolved.Get("http.proxy")
+	httpOptions.CAFile = resolved.Get("http.ca_file")
+	httpOptions.CertFile = resolved.Get("http.client_cert")
+	httpOptions.KeyFile = resolved.Get("http.client_key")
+
+	if value := resolved.Get("http.insecure_skip_tls_verify"); value != "" {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.ErrHTTPClientConfig(err)
+		}
+		httpOptions.InsecureSkipTLSVerify = insecure
+	}
+
+	if value := resolved.Get("defaults.timeout_seconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.ErrHTTPClientConfig(err)
+		}
+		httpOptions.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	transport, err := httpclient.NewTransport(httpOptions)
+	if err != nil {
+		return errors.ErrHTTPClientConfig(err)
+	}
+	httpTransport = transport
+
+	if httpOptions.InsecureSkipTLSVerify {
+		Log.Warn("TLS certificate verification is disabled. Connections to Meshery and GitHub are not protected against interception.")
+	}
+	if httpOptions.ProxyURL != "" {
+		// NewTransport accepted the URL, so it parses; leave out its password
+		proxyURL, _ := url.Parse(httpOptions.ProxyURL)
+		Log.Debugf("Using proxy %s", proxyURL.Redacted())
+	}
+	return nil
+}
+
+// newHTTPClient returns a client using the shared transport, so the proxy and TLS
+// settings apply to every outbound request
+func newHTTPClient() *http.Client {
+	client := &http.Client{Timeout: httpOptions.Timeout}
+	if httpTransport != nil {
+		client.Transport = httpTransport
+	}
+	return client
+}
@@ -0,0 +1,469 @@
+package kanvas_snapshot
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/config"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/credentials"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Default remote provider used for the login flow
+	defaultProviderURL = "https://cloud.layer5.io"
+	defaultProvider    = "Meshery"
+	// Path on the local callback server the provider redirects to with the token
+	loginCallbackPath = "/api/user/token"
+)
+
+var (
+	// Login command flags
+	loginProviderURL string
+	loginNoBrowser   bool
+	loginWithToken   bool
+	loginTimeout     time.Duration
+)
+
+// loginCmd obtains a Meshery token and stores it for later runs
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to Meshery and store the token",
+	Long: `Log in to Meshery and store the token.
+
+		This command opens the provider's login page in a browser and waits for the provider
+		to redirect back to a local callback with the token. The callback URL holds a random state,
+		so only the redirect of this login is accepted.
+		The token is checked against the Meshery server, like whoami does, and stored in the
+		OS keyring, or in an encrypted file if no keyring is available.
+
+		Example usage:
+
+		kubectl kanvas-snapshot login
+		kubectl kanvas-snapshot login --no-browser
+		echo "$MESHERY_TOKEN" | kubectl kanvas-snapshot login --with-token`,
+	Args: cobra.NoArgs,
+	RunE: loginRunE,
+}
+
+// logoutCmd removes the stored token
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the stored Meshery token",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		store, err := getCredentialStore()
+		if err != nil {
+			return err
+		}
+		for _, key := range []string{credentials.KeyMesheryToken, credentials.KeyMesheryProvider} {
+			if err := store.Delete(key); err != nil {
+				return errors.ErrCredentials(err)
+			}
+		}
+		Log.Infof("Logged out. Stored Meshery token removed from %s.", store.Name())
+		return nil
+	},
+}
+
+// whoamiCmd validates the token against the Meshery server and prints the user
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the user the Meshery token belongs to",
+	Args:  cobra.NoArgs,
+	RunE:  whoamiRunE,
+}
+
+// MesheryUser represents the subset of Meshery's user response shown by whoami
+type MesheryUser struct {
+	UserID    string `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+}
+
+// credentialFallback looks up the Meshery token, GitHub token and Meshery server in the
+// credential store or in a mesheryctl context. It is consulted for values not set with
+// flags, environment variables or the config file. An explicitly selected --meshery-context
+// instead provides the Meshery server and token together, ranking with the flags, so its
+// token is never sent to a server set elsewhere. The token_source settings of the config
+// file restrict which of these fallbacks are used.
+type credentialFallback struct {
+	useStore       bool
+	useMesheryctl  bool
+	useGitHubStore bool
+	// explicitContext is set if the mesheryctl context was selected with --meshery-context
+	explicitContext bool
+
+	// mesheryctl context, loaded on first use
+	loaded      bool
+	contextName string
+	mesheryctl  *config.MesheryctlConfig
+	context     *config.MesheryctlContext
+}
+
+// newCredentialFallback applies the token_source settings of the config file
+func newCredentialFallback() (*credentialFallback, error) {
+	mesheryTokenSource, mesheryTokenArg, githubTokenSource := config.TokenSourceAuto, "", config.TokenSourceAuto
+	if Config != nil {
+		var err error
+		if mesheryTokenSource, mesheryTokenArg, err = config.ParseTokenSource(Config.Meshery.TokenSource); err != nil {
+			return nil, errors.ErrInvalidConfig(err)
+		}
+		if githubTokenSource, _, err = config.ParseTokenSource(Config.GitHub.TokenSource); err != nil {
+			return nil, errors.ErrInvalidConfig(err)
+		}
+	}
+
+	explicitContext := mesheryContext != ""
+
+	// A mesheryctl token source names the context unless one was selected explicitly
+	if mesheryTokenSource == config.TokenSourceMesheryctl && mesheryContext == "" {
+		mesheryContext = mesheryTokenArg
+	}
+
+	useStore := mesheryTokenSource == config.TokenSourceAuto || mesheryTokenSource == config.TokenSourceKeyring
+	return &credentialFallback{
+		useStore:        useStore && (mesheryContext == "" || mesheryTokenSource == config.TokenSourceKeyring),
+		useMesheryctl:   mesheryTokenSource == config.TokenSourceAuto || mesheryTokenSource == config.TokenSourceMesheryctl,
+		useGitHubStore:  githubTokenSource != config.TokenSourceEnv,
+		explicitContext: explicitContext,
+	}, nil
+}
+
+// selected returns the Meshery server and token of an explicitly selected mesheryctl context
+func (f *credentialFallback) selected(key string) (string, string, error) {
+	if !f.explicitContext {
+		return "", "", nil
+	}
+	switch key {
+	case "meshery.url":
+		if err := f.loadContext(); err != nil {
+			return "", "", err
+		}
+		return f.context.Endpoint, fmt.Sprintf("mesheryctl context '%s' (--meshery-context)", f.contextName), nil
+	case "meshery.token":
+		token, source, err := f.mesheryctlToken()
+		if source != "" {
+			source += " (--meshery-context)"
+		}
+		return token, source, err
+	}
+	return "", "", nil
+}
+
+// lookup returns the value of a setting and its source, or an empty value if not found
+func (f *credentialFallback) lookup(key string) (string, string, error) {
+	switch key {
+	case "meshery.token":
+		if f.useStore {
+			token, err := storedCredential(credentials.KeyMesheryToken)
+			if err != nil {
+				Log.Warnf("Could not load stored Meshery token: %v", err)
+			} else if token != "" {
+				if provider, _ := storedCredential(credentials.KeyMesheryProvider); provider != "" {
+					ProviderName = provider
+				}
+				return token, "credential store", nil
+			}
+		}
+		if f.useMesheryctl {
+			return f.mesheryctlToken()
+		}
+	case "meshery.url":
+		if f.useMesheryctl {
+			if err := f.loadContext(); err != nil || f.context == nil {
+				return "", "", err
+			}
+			return f.context.Endpoint, fmt.Sprintf("mesheryctl context '%s'", f.contextName), nil
+		}
+	case "github.token":
+		if f.useGitHubStore {
+			token, err := storedCredential(credentials.KeyGitHubToken)
+			if err != nil {
+				Log.Warnf("Could not load stored GitHub token: %v", err)
+				return "", "", nil
+			}
+			return token, "credential store", nil
+		}
+	}
+	return "", "", nil
+}
+
+// mesheryctlToken reads the token of the mesheryctl context
+func (f *credentialFallback) mesheryctlToken() (string, string, error) {
+	if err := f.loadContext(); err != nil || f.context == nil || f.context.Token == "" {
+		return "", "", err
+	}
+
+	auth, err := f.mesheryctl.LoadAuth(f.context)
+	if err != nil {
+		if mesheryContext != "" {
+			return "", "", errors.ErrCredentials(err)
+		}
+		Log.Debugf("Not using mesheryctl token: %v", err)
+		return "", "", nil
+	}
+	if auth.Provider != "" {
+		ProviderName = auth.Provider
+	}
+	return auth.Token, fmt.Sprintf("mesheryctl context '%s'", f.contextName), nil
+}
+
+// loadContext loads the selected mesheryctl context, or the current one if none was selected.
+// Only an explicitly selected context is required to exist.
+func (f *credentialFallback) loadContext() error {
+	if f.loaded {
+		return nil
+	}
+	f.loaded = true
+
+	cfg, err := config.LoadMesheryctlConfig()
+	if err != nil {
+		return errors.ErrCredentials(err)
+	}
+	if cfg == nil {
+		if mesheryContext != "" {
+			return errors.ErrCredentials(fmt.Errorf("--meshery-context set but no mesheryctl config found"))
+		}
+		return nil
+	}
+
+	name, ctx, err := cfg.Context(mesheryContext)
+	if err != nil {
+		if mesheryContext != "" {
+			return errors.ErrCredentials(err)
+		}
+		Log.Debugf("Not using mesheryctl context: %v", err)
+		return nil
+	}
+
+	f.contextName, f.mesheryctl, f.context = name, cfg, ctx
+	return nil
+}
+
+// loginURL returns the provider login URL that redirects back to callbackURL.
+// The provider appends ?token=<token> to the decoded callback URL.
+func loginURL(providerURL, callbackURL string) string {
+	source := base64.RawURLEncoding.EncodeToString([]byte(callbackURL))
+	return fmt.Sprintf("%s/login?source=%s", strings.TrimSuffix(providerURL, "/"), source)
+}
+
+// newLoginState returns a random nonce tying the login callback to this login
+func newLoginState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// loginCallback handles the provider's redirect to loginCallbackPath/<state>, sending the
+// token to tokenCh. The state is part of the path since the provider only appends the token,
+// and callbacks without the state of this login are rejected, so a page visited while
+// logging in cannot inject a token.
+func loginCallback(state string, tokenCh chan<- string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(loginCallbackPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.URL.Path, loginCallbackPath+"/")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(state)) != 1 {
+			http.Error(w, "Invalid state in callback", http.StatusBadRequest)
+			return
+		}
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "Missing token in callback", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "Login successful. You can close this window and return to the terminal.")
+		select {
+		case tokenCh <- token:
+		default:
+		}
+	})
+	return mux
+}
+
+// waitForToken runs a local callback server and returns the token the provider redirects with
+func waitForToken(providerURL string, timeout time.Duration) (string, error) {
+	state, err := newLoginState()
+	if err != nil {
+		return "", err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	tokenCh := make(chan string, 1)
+	server := &http.Server{Handler: loginCallback(state, tokenCh)}
+	go func() { _ = server.Serve(listener) }()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	callbackURL := fmt.Sprintf("http://%s%s/%s", listener.Addr().String(), loginCallbackPath, state)
+	login := loginURL(providerURL, callbackURL)
+
+	if loginNoBrowser {
+		Log.Infof("Open the following URL in a browser to log in:\n%s", login)
+	} else {
+		Log.Infof("Opening browser to log in: %s", login)
+		if err := openBrowser(login); err != nil {
+			Log.Warnf("Could not open browser: %v", err)
+			Log.Infof("Open the following URL in a browser to log in:\n%s", login)
+		}
+	}
+
+	select {
+	case token := <-tokenCh:
+		return token, nil
+	case <-time.After(timeout):
+		return "", fmt.Errorf("timed out after %s waiting for the login callback", timeout)
+	}
+}
+
+// openBrowser opens url in the user's default browser
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+// readTokenFromStdin reads a token piped to the login command
+func readTokenFromStdin(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return "", fmt.Errorf("no token provided on stdin")
+	}
+	return token, nil
+}
+
+// RunE function for the login command
+func loginRunE(_ *cobra.Command, _ []string) error {
+	providerURL := loginProviderURL
+	if providerURL == "" {
+		providerURL = MesheryCloudAPIBaseURL
+	}
+	if providerURL == "" {
+		providerURL = defaultProviderURL
+	}
+
+	var token string
+	var err error
+	if loginWithToken {
+		token, err = readTokenFromStdin(os.Stdin)
+	} else {
+		token, err = waitForToken(providerURL, loginTimeout)
+	}
+	if err != nil {
+		return errors.ErrLogin(err)
+	}
+
+	// Check the token the way whoami does before storing it
+	ProviderToken, ProviderName = token, defaultProvider
+	user, err := FetchMesheryUser()
+	if err != nil {
+		return err
+	}
+
+	store, err := getCredentialStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(credentials.KeyMesheryToken, token); err != nil {
+		return errors.ErrCredentials(err)
+	}
+	if err := store.Set(credentials.KeyMesheryProvider, defaultProvider); err != nil {
+		return errors.ErrCredentials(err)
+	}
+
+	Log.Infof("Logged in to %s as %s <%s>. Token stored in %s", MesheryAPIBaseURL, userDisplayName(user), user.Email, store.Name())
+	return nil
+}
+
+// FetchMesheryUser validates the token against the Meshery server and returns its user
+func FetchMesheryUser() (*MesheryUser, error) {
+	fullURL := fmt.Sprintf("%s/api/user", MesheryAPIBaseURL)
+
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, errors.ErrHTTPPostRequest(err)
+	}
+	setAuthCookie(req)
+
+	client := newHTTPClient()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.ErrHTTPPostRequest(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.ErrHTTPPostRequest(err)
+	}
+
+	if isAuthFailure(resp, body) {
+		return nil, authError(resp, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ErrUnexpectedResponseCode(resp.StatusCode, trimString(string(body), 200))
+	}
+
+	user := &MesheryUser{}
+	if err := json.Unmarshal(body, user); err != nil {
+		return nil, errors.ErrDecodingAPI(err)
+	}
+	return user, nil
+}
+
+// RunE function for the whoami command
+func whoamiRunE(_ *cobra.Command, _ []string) error {
+	user, err := FetchMesheryUser()
+	if err != nil {
+		return err
+	}
+
+	Log.Infof("Logged in to %s as %s <%s> (user ID: %s)", MesheryAPIBaseURL, userDisplayName(user), user.Email, user.UserID)
+	return nil
+}
+
+// userDisplayName returns the full name of a Meshery user
+func userDisplayName(user *MesheryUser) string {
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+func init() {
+	loginCmd.Flags().StringVar(&loginProviderURL, "provider-url", "", "Remote provider URL to log in with (defaults to MESHERY_CLOUD_URL or https://cloud.layer5.io)")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Print the login URL instead of opening a browser")
+	loginCmd.Flags().BoolVar(&loginWithToken, "with-token", false, "Read an existing token from stdin instead of running the browser flow")
+	loginCmd.Flags().DurationVar(&loginTimeout, "timeout", 5*time.Minute, "How long to wait for the login callback")
+}
@@ -0,0 +1,71 @@
+package kanvas_snapshot
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// providerRedirect returns the URL the provider redirects to after login: the callback
+// decoded from the source parameter of the login URL, with the token appended
+func providerRedirect(t *testing.T, login, token string) string {
+	t.Helper()
+	u, err := url.Parse(login)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := base64.RawURLEncoding.DecodeString(u.Query().Get("source"))
+	if err != nil {
+		t.Fatalf("decoding source: %v", err)
+	}
+	return string(source) + "?token=" + url.QueryEscape(token)
+}
+
+func TestLoginCallback(t *testing.T) {
+	const state = "0123456789abcdef"
+	tokenCh := make(chan string, 1)
+	server := httptest.NewServer(loginCallback(state, tokenCh))
+	defer server.Close()
+
+	login := loginURL("https://cloud.example.com/", server.URL+loginCallbackPath+"/"+state)
+	if u, _ := url.Parse(login); u.Path != "/login" || u.Query().Get("state") != "" {
+		t.Errorf("login URL = %s, want /login with only the source parameter", login)
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"other state", server.URL + loginCallbackPath + "/fedcba9876543210?token=evil", http.StatusBadRequest},
+		{"no state", server.URL + loginCallbackPath + "/?token=evil", http.StatusBadRequest},
+		{"missing token", server.URL + loginCallbackPath + "/" + state, http.StatusBadRequest},
+		{"provider redirect", providerRedirect(t, login, "good-token"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(tt.url)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+
+	select {
+	case token := <-tokenCh:
+		if token != "good-token" {
+			t.Errorf("token = %q, want %q", token, "good-token")
+		}
+	default:
+		t.Fatal("no token received from the provider redirect")
+	}
+	select {
+	case token := <-tokenCh:
+		t.Errorf("unexpected second token %q", token)
+	default:
+	}
+}
@@ -0,0 +1,116 @@
+package kanvas_snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/history"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Supported structured output formats
+	outputFormatJSON = "json"
+	outputFormatYAML = "yaml"
+)
+
+// SnapshotResult is the machine-readable result of a snapshot run
+type SnapshotResult struct {
+	DesignID   string `json:"designID" yaml:"designID"`
+	DesignName string `json:"designName" yaml:"designName"`
+	ViewURL    string `json:"viewURL" yaml:"viewURL"`
+	// Cached is set when an unchanged design was reused instead of uploaded
+	Cached bool `json:"cached" yaml:"cached"`
+	// Queued is the spool item holding the upload or dispatch that could not be sent
+	Queued        string `json:"queued,omitempty" yaml:"queued,omitempty"`
+	AssetLocation string `json:"assetLocation,omitempty" yaml:"assetLocation,omitempty"`
+	// SnapshotReady is set when --wait saw the snapshot image published
+	SnapshotReady bool            `json:"snapshotReady,omitempty" yaml:"snapshotReady,omitempty"`
+	Workflow      *WorkflowResult `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+	Resources     ResourceCounts  `json:"resources" yaml:"resources"`
+	// PRComment is the URL of the pull request comment
+	PRComment      string `json:"prComment,omitempty" yaml:"prComment,omitempty"`
+	resultWarnings `yaml:",inline"`
+}
+
+// historyRecord returns the history record of the result
+func (r *SnapshotResult) historyRecord(hash string, inputs []string) history.Record {
+	rec := history.Record{
+		Inputs:        inputs,
+		Hash:          hash,
+		DesignID:      r.DesignID,
+		DesignName:    r.DesignName,
+		ViewURL:       r.ViewURL,
+		AssetLocation: r.AssetLocation,
+		Cached:        r.Cached,
+	}
+	if r.Workflow != nil {
+		rec.WorkflowRun = r.Workflow.URL()
+	}
+	return rec
+}
+
+// resultWarnings collects the warnings reported in a result
+type resultWarnings struct {
+	Warnings []string `json:"warnings" yaml:"warnings"`
+}
+
+// WorkflowResult describes the GitHub workflow dispatched to render the snapshot
+type WorkflowResult struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Workflow   string `json:"workflow" yaml:"workflow"`
+	Ref        string `json:"ref" yaml:"ref"`
+	RunsURL    string `json:"runsURL" yaml:"runsURL"`
+	// RunURL is the run the dispatch started, empty if GitHub did not list it in time
+	RunURL string `json:"runURL,omitempty" yaml:"runURL,omitempty"`
+}
+
+// URL returns the run the dispatch started, or the workflow's runs page if it is not known
+func (w *WorkflowResult) URL() string {
+	if w.RunURL != "" {
+		return w.RunURL
+	}
+	return w.RunsURL
+}
+
+// ResourceCounts summarizes the resources found in the manifests
+type ResourceCounts struct {
+	Total  int            `json:"total" yaml:"total"`
+	ByKind map[string]int `json:"byKind" yaml:"byKind"`
+}
+
+// warn logs a warning and records it in the result
+func (r *resultWarnings) warn(msg string) {
+	Log.Warn(msg)
+	r.Warnings = append(r.Warnings, msg)
+}
+
+// warnf logs a formatted warning and records it in the result
+func (r *resultWarnings) warnf(format string, args ...interface{}) {
+	r.warn(fmt.Sprintf(format, args...))
+}
+
+// isValidOutputFormat reports whether the structured output format is supported
+func isValidOutputFormat(format string) bool {
+	return format == "" || format == outputFormatJSON || format == outputFormatYAML
+}
+
+// writeResult prints the result object in the requested format
+func writeResult(w io.Writer, format string, result interface{}) error {
+	switch format {
+	case outputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(result)
+	case outputFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return nil
+}
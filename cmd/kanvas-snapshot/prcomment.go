@@ -0,0 +1,221 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/github"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// commentMarker identifies the plugin's comment among the comments of a pull request
+const commentMarker = "<!-- kubectl-kanvas-snapshot -->"
+
+// snapshotPollInterval is how often --wait checks whether the snapshot image was published
+const snapshotPollInterval = 10 * time.Second
+
+// rawAssetURL matches snapshot locations in a GitHub repository,
+// https://raw.githubusercontent.com/<owner>/<repo>/<ref>/<path>
+var rawAssetURL = regexp.MustCompile(`^https://raw\.githubusercontent\.com/([^/]+)/([^/]+)/([^/]+)/(.+)$`)
+
+var (
+	// prComment posts the design link, snapshot and resource summary on the pull request
+	prComment bool
+	// pullRequest is the pull request to comment on, detected from the CI environment if empty
+	pullRequest string
+	// waitSnapshot waits until the snapshot image is published
+	waitSnapshot bool
+	waitTimeout  time.Duration
+)
+
+// commentDesign is a design shown in the pull request comment
+type commentDesign struct {
+	Title         string
+	ViewURL       string
+	AssetLocation string
+	SnapshotReady bool
+	// Status describes a design without a snapshot, e.g. why it was not created
+	Status    string
+	Resources ResourceCounts
+}
+
+// githubClient returns a client for the configured GitHub API
+func githubClient() *github.Client {
+	return &github.Client{BaseURL: GitHubAPIBaseURL, Token: WorkflowAccessToken, HTTP: newHTTPClient()}
+}
+
+// waitForSnapshot polls until the snapshot image is published at assetLocation or the
+// deadline passes. Errors other than a rejected token are retried.
+func waitForSnapshot(assetLocation string, deadline time.Time) error {
+	Log.Infof("Waiting for the snapshot at %s...", assetLocation)
+	for {
+		ready, err := snapshotAvailable(assetLocation)
+		if ready {
+			Log.Infof("Snapshot is ready: %s", assetLocation)
+			return nil
+		}
+		if apiErr, ok := err.(*github.APIError); ok && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			return errors.ErrGeneratingSnapshot(err)
+		}
+		if err != nil {
+			Log.Debugf("Could not check the snapshot: %v", err)
+		}
+		if time.Now().Add(snapshotPollInterval).After(deadline) {
+			return errors.ErrSnapshotTimeout(assetLocation, waitTimeout)
+		}
+		time.Sleep(snapshotPollInterval)
+	}
+}
+
+// snapshotAvailable reports whether the snapshot image exists. Images in a GitHub repository
+// are checked through the API, since raw.githubusercontent.com caches missing files.
+func snapshotAvailable(assetLocation string) (bool, error) {
+	if m := rawAssetURL.FindStringSubmatch(assetLocation); m != nil {
+		return githubClient().FileExists(m[1], m[2], m[3], m[4])
+	}
+	resp, err := newHTTPClient().Head(assetLocation)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+}
+
+// resolvePullRequest returns the pull request given with --pr or detected from the CI
+// environment, or nil if the build is not for a pull request
+func resolvePullRequest() (*github.PullRequest, error) {
+	if pullRequest != "" {
+		return github.ParsePullRequest(pullRequest, os.Getenv("GITHUB_REPOSITORY"))
+	}
+	pr, ci, err := github.DetectPullRequest(os.Getenv)
+	if pr != nil {
+		Log.Infof("Detected pull request %s from %s", pr, ci)
+	}
+	return pr, err
+}
+
+// postPRComment creates or updates the plugin's comment on the pull request and returns
+// its URL. Builds that are not for a pull request are skipped with a warning.
+func postPRComment(designs []commentDesign, warnings *resultWarnings) (string, error) {
+	pr, err := resolvePullRequest()
+	if err != nil {
+		return "", errors.ErrPRComment(err)
+	}
+	if pr == nil {
+		warnings.warn("No pull request detected, the pull request comment was skipped. Pass --pr to select one.")
+		return "", nil
+	}
+	if WorkflowAccessToken == "" {
+		return "", errors.ErrPRComment(fmt.Errorf("GITHUB_TOKEN is not set"))
+	}
+
+	comment, created, err := githubClient().UpsertComment(*pr, commentMarker, commentBody(designs, time.Now()))
+	if err != nil {
+		return "", errors.ErrPRComment(err)
+	}
+	if created {
+		Log.Infof("Commented on pull request %s: %s", pr, comment.HTMLURL)
+	} else {
+		Log.Infof("Updated comment on pull request %s: %s", pr, comment.HTMLURL)
+	}
+	return comment.HTMLURL, nil
+}
+
+// commentBody renders the pull request comment in Markdown
+func commentBody(designs []commentDesign, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(commentMarker + "\n")
+	b.WriteString("## Kanvas snapshot\n")
+
+	for _, d := range designs {
+		b.WriteString("\n")
+		if d.ViewURL != "" {
+			fmt.Fprintf(&b, "### [%s](%s)\n\n", d.Title, d.ViewURL)
+		} else {
+			fmt.Fprintf(&b, "### %s\n\n", d.Title)
+		}
+
+		switch {
+		case d.SnapshotReady:
+			fmt.Fprintf(&b, "[![Snapshot of %s](%s)](%s)\n\n", d.Title, d.AssetLocation, d.ViewURL)
+		case d.AssetLocation != "":
+			fmt.Fprintf(&b, "The snapshot is being rendered and will be published [here](%s) in a few minutes.\n\n", d.AssetLocation)
+		case d.Status != "":
+			fmt.Fprintf(&b, "%s\n\n", d.Status)
+		}
+
+		if d.Resources.Total == 0 {
+			continue
+		}
+		kinds := make([]string, 0, len(d.Resources.ByKind))
+		for kind := range d.Resources.ByKind {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		fmt.Fprintf(&b, "<details><summary>%d resource(s)</summary>\n\n", d.Resources.Total)
+		b.WriteString("| Kind | Count |\n|------|------:|\n")
+		for _, kind := range kinds {
+			fmt.Fprintf(&b, "| %s | %d |\n", kind, d.Resources.ByKind[kind])
+		}
+		b.WriteString("\n</details>\n")
+	}
+
+	fmt.Fprintf(&b, "\n<sub>Updated by kubectl kanvas-snapshot at %s</sub>\n", now.UTC().Format("2006-01-02 15:04 UTC"))
+	return b.String()
+}
+
+// finishSnapshot waits for the snapshot, comments on the pull request and writes the report
+// as requested, then writes the result. A snapshot that is not ready in time fails the run
+// after the comment is posted without it.
+func finishSnapshot(result *SnapshotResult, resources []manifest.Resource) error {
+	var waitErr error
+	if waitSnapshot && result.AssetLocation != "" {
+		if waitErr = waitForSnapshot(result.AssetLocation, time.Now().Add(waitTimeout)); waitErr == nil {
+			result.SnapshotReady = true
+		}
+	}
+
+	if prComment {
+		if result.DesignID == "" {
+			result.warn("No design was created, the pull request comment was skipped.")
+		} else {
+			d := commentDesign{
+				Title:         result.DesignName,
+				ViewURL:       result.ViewURL,
+				AssetLocation: result.AssetLocation,
+				SnapshotReady: result.SnapshotReady,
+				Resources:     result.Resources,
+			}
+			if d.AssetLocation == "" {
+				d.Status = "No snapshot was rendered for this design."
+			}
+			url, err := postPRComment([]commentDesign{d}, &result.resultWarnings)
+			if err != nil {
+				return err
+			}
+			result.PRComment = url
+		}
+	}
+
+	reportErr := writeReport(result.DesignName, resources, result.reportDesign(), result.Warnings)
+	if err := writeResult(os.Stdout, outputFormat, result); err != nil {
+		return err
+	}
+	if reportErr != nil {
+		return reportErr
+	}
+	return waitErr
+}
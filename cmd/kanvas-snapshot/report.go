@@ -0,0 +1,88 @@
+package kanvas_snapshot
+
+import (
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/report"
+)
+
+// reportPath is the Markdown or HTML report written with --report
+var reportPath string
+
+// writeReport writes the report of the resources and the designs created from them
+// to --report, if set
+func writeReport(title string, resources []manifest.Resource, designs []report.Design, warnings []string) error {
+	if reportPath == "" {
+		return nil
+	}
+	r := &report.Report{
+		Title:     title,
+		Generated: time.Now(),
+		Sources:   resourceSources(resources),
+		Resources: resources,
+		Designs:   designs,
+		Warnings:  warnings,
+	}
+	if err := report.Write(reportPath, r); err != nil {
+		return errors.ErrWritingReport(reportPath, err)
+	}
+	Log.Infof("Report written to %s", reportPath)
+	return nil
+}
+
+// resourceSources returns the files the resources were read from, in order of appearance
+func resourceSources(resources []manifest.Resource) []string {
+	seen := make(map[string]bool)
+	var sources []string
+	for _, r := range resources {
+		if r.Source != "" && !seen[r.Source] {
+			seen[r.Source] = true
+			sources = append(sources, r.Source)
+		}
+	}
+	return sources
+}
+
+// reportDesign returns the design of a single run as shown in the report
+func (r *SnapshotResult) reportDesign() []report.Design {
+	if r.DesignID == "" && r.Queued == "" {
+		return nil
+	}
+	d := report.Design{Name: r.DesignName, ViewURL: r.ViewURL, SnapshotURL: r.AssetLocation}
+	switch {
+	case r.Queued != "":
+		d.Status = groupStatusQueued
+	case r.AssetLocation != "":
+		d.Status = groupStatusSnapshot
+	default:
+		d.Status = groupStatusCreated
+	}
+	if r.SnapshotReady {
+		d.Status = "snapshot-ready"
+	}
+	if r.Cached {
+		d.Status += " (cached)"
+	}
+	return []report.Design{d}
+}
+
+// groupReportDesigns returns the groups of a batch run as shown in the report
+func groupReportDesigns(groups []GroupResult) []report.Design {
+	designs := make([]report.Design, 0, len(groups))
+	for _, g := range groups {
+		d := report.Design{Name: g.DesignName, ViewURL: g.ViewURL, SnapshotURL: g.AssetLocation, Status: g.Status}
+		if g.SnapshotReady {
+			d.Status = "snapshot-ready"
+		}
+		if g.Cached {
+			d.Status += " (cached)"
+		}
+		if g.Error != "" {
+			d.Status += ": " + g.Error
+		}
+		designs = append(designs, d)
+	}
+	return designs
+}
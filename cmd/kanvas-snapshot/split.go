@@ -0,0 +1,297 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/history"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+const (
+	// Status of a group uploaded in batch mode
+	groupStatusCreated        = "created"
+	groupStatusSnapshot       = "snapshot-triggered"
+	groupStatusFailed         = "failed"
+	groupStatusSnapshotFailed = "snapshot-failed"
+	groupStatusQueued         = "queued"
+)
+
+var (
+	// Batch mode flags
+	splitBy          string
+	splitConcurrency int
+)
+
+// BatchResult is the machine-readable result of a batch run with --split-by
+type BatchResult struct {
+	SplitBy string        `json:"splitBy" yaml:"splitBy"`
+	Groups  []GroupResult `json:"groups" yaml:"groups"`
+	Failed  int           `json:"failed" yaml:"failed"`
+	// PRComment is the URL of the pull request comment
+	PRComment      string `json:"prComment,omitempty" yaml:"prComment,omitempty"`
+	resultWarnings `yaml:",inline"`
+}
+
+// GroupResult is the result of uploading one group as a design
+type GroupResult struct {
+	Group         string          `json:"group" yaml:"group"`
+	Status        string          `json:"status" yaml:"status"`
+	Error         string          `json:"error,omitempty" yaml:"error,omitempty"`
+	DesignID      string          `json:"designID,omitempty" yaml:"designID,omitempty"`
+	DesignName    string          `json:"designName" yaml:"designName"`
+	ViewURL       string          `json:"viewURL,omitempty" yaml:"viewURL,omitempty"`
+	AssetLocation string          `json:"assetLocation,omitempty" yaml:"assetLocation,omitempty"`
+	SnapshotReady bool            `json:"snapshotReady,omitempty" yaml:"snapshotReady,omitempty"`
+	Workflow      *WorkflowResult `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+	Cached        bool            `json:"cached" yaml:"cached"`
+	Queued        string          `json:"queued,omitempty" yaml:"queued,omitempty"`
+	Sources       []string        `json:"sources" yaml:"sources"`
+	Resources     ResourceCounts  `json:"resources" yaml:"resources"`
+
+	// hash is the manifest hash of the group
+	hash string
+}
+
+// runSplit uploads one design per group with bounded concurrency. All groups are
+// attempted; the error of the first failed group is returned after the summary.
+func runSplit(files []manifest.File, baseName string, resources []manifest.Resource, warnings resultWarnings) error {
+	kind, labelKey, err := manifest.ParseSplitBy(splitBy)
+	if err != nil {
+		return errors.ErrInvalidSplit(err)
+	}
+
+	groups, err := manifest.GroupFiles(files, manifestPath, kind, labelKey)
+	if err != nil {
+		return errors.ErrReadingManifestFile(err)
+	}
+	Log.Infof("Split manifests by %s into %d group(s)", splitBy, len(groups))
+
+	result := &BatchResult{SplitBy: splitBy, Groups: make([]GroupResult, len(groups)), resultWarnings: warnings}
+
+	dispatch := !skipWorkflow
+	if dispatch && WorkflowAccessToken == "" {
+		result.warn("GITHUB_TOKEN environment variable not set. Snapshot generation was skipped.")
+		dispatch = false
+	}
+
+	concurrency := splitConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	uploader := newCachedUpload()
+	groupErrs := make([]error, len(groups))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, g manifest.Group) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result.Groups[i], groupErrs[i] = uploadGroup(uploader, g, groupDesignName(baseName, g.Name), dispatch)
+		}(i, g)
+	}
+	wg.Wait()
+
+	for _, g := range result.Groups {
+		recordHistory(g.historyRecord())
+	}
+
+	// Queued groups are not failures, but still fail the run since nothing was sent
+	var firstErr error
+	for i, err := range groupErrs {
+		if err == nil {
+			continue
+		}
+		if result.Groups[i].Status != groupStatusQueued {
+			result.Failed++
+			Log.Warnf("Group %s failed: %s", groups[i].Name, result.Groups[i].Error)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	// Wait for all snapshots, then comment once with every group
+	if waitSnapshot {
+		deadline := time.Now().Add(waitTimeout)
+		for i := range result.Groups {
+			g := &result.Groups[i]
+			if g.AssetLocation == "" {
+				continue
+			}
+			if err := waitForSnapshot(g.AssetLocation, deadline); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			g.SnapshotReady = true
+		}
+	}
+	if prComment {
+		url, err := postPRComment(groupCommentDesigns(result.Groups), &result.resultWarnings)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		result.PRComment = url
+	}
+	if err := writeReport(baseName, resources, groupReportDesigns(result.Groups), result.Warnings); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	if outputFormat == "" {
+		if err := writeGroupTable(os.Stdout, result.Groups); err != nil {
+			return err
+		}
+	} else if err := writeResult(os.Stdout, outputFormat, result); err != nil {
+		return err
+	}
+
+	if firstErr != nil {
+		Log.Warnf("%d of %d design(s) failed", result.Failed, len(groups))
+	}
+	return firstErr
+}
+
+// uploadGroup creates the design of a group and dispatches its snapshot workflow.
+// The design and snapshot of an unchanged group are reused from the cache.
+func uploadGroup(uploader *cachedUpload, g manifest.Group, name string, dispatch bool) (GroupResult, error) {
+	r := GroupResult{
+		Group:      g.Name,
+		DesignName: name,
+		Sources:    g.Sources,
+		Resources:  ResourceCounts{Total: len(g.Resources), ByKind: manifest.CountByKind(g.Resources)},
+	}
+
+	cached, hash, reused, err := uploader.design(g.Content, name)
+	r.hash = hash
+	if err != nil {
+		queueFn := func() (string, error) {
+			return queueUpload(g.Content, name, hash, absPaths(g.Sources...), dispatch)
+		}
+		if id := spoolOnUnreachable(err, queueFn); id != "" {
+			r.Status, r.Queued = groupStatusQueued, id
+			return r, err
+		}
+		r.Status, r.Error = groupStatusFailed, errorDetails(err)
+		return r, err
+	}
+	r.Status = groupStatusCreated
+	r.DesignID = cached.DesignID
+	r.ViewURL = getDesignViewURL(cached.DesignID)
+	r.Cached = reused
+	if !reused {
+		uploader.store(hash, cached)
+	}
+
+	if !dispatch {
+		return r, nil
+	}
+	if reused && cached.AssetLocation != "" {
+		r.Status = groupStatusSnapshot
+		r.AssetLocation = cached.AssetLocation
+		return r, nil
+	}
+	workflow, err := GenerateSnapshot(cached.DesignID, "", WorkflowAccessToken)
+	if err != nil {
+		if id := spoolOnUnreachable(err, func() (string, error) { return queueDispatch(cached.DesignID) }); id != "" {
+			r.Status, r.Queued = groupStatusQueued, id
+			return r, err
+		}
+		r.Status, r.Error = groupStatusSnapshotFailed, errorDetails(err)
+		return r, err
+	}
+	if workflow != nil {
+		r.Status = groupStatusSnapshot
+		r.Workflow = workflow
+		r.AssetLocation = defaultAssetLocation(cached.DesignID)
+		cached.AssetLocation = r.AssetLocation
+		uploader.store(hash, cached)
+	}
+	return r, nil
+}
+
+// groupCommentDesigns returns the groups as shown in the pull request comment
+func groupCommentDesigns(groups []GroupResult) []commentDesign {
+	designs := make([]commentDesign, 0, len(groups))
+	for _, g := range groups {
+		d := commentDesign{
+			Title:         g.DesignName,
+			ViewURL:       g.ViewURL,
+			AssetLocation: g.AssetLocation,
+			SnapshotReady: g.SnapshotReady,
+			Resources:     g.Resources,
+		}
+		switch g.Status {
+		case groupStatusFailed:
+			d.Status = "The design could not be created: " + g.Error
+		case groupStatusSnapshotFailed:
+			d.Status = "The snapshot workflow could not be triggered: " + g.Error
+		case groupStatusQueued:
+			d.Status = "The upload is queued until Meshery or GitHub can be reached."
+		default:
+			d.Status = "No snapshot was rendered for this design."
+		}
+		designs = append(designs, d)
+	}
+	return designs
+}
+
+// historyRecord returns the history record of the group
+func (g GroupResult) historyRecord() history.Record {
+	rec := history.Record{
+		Inputs:        absPaths(g.Sources...),
+		Hash:          g.hash,
+		DesignID:      g.DesignID,
+		DesignName:    g.DesignName,
+		ViewURL:       g.ViewURL,
+		AssetLocation: g.AssetLocation,
+		Cached:        g.Cached,
+	}
+	if g.Workflow != nil {
+		rec.WorkflowRun = g.Workflow.URL()
+	}
+	return rec
+}
+
+// groupDesignName names the design of a group after the base name and the group
+func groupDesignName(baseName, group string) string {
+	if group == "." {
+		return baseName
+	}
+	return fmt.Sprintf("%s-%s", baseName, group)
+}
+
+// errorDetails describes an error including its underlying cause
+func errorDetails(err error) string {
+	if details := errors.Details(err); details != "" {
+		return details
+	}
+	return err.Error()
+}
+
+// writeGroupTable prints the summary of a batch run as a table
+func writeGroupTable(w io.Writer, groups []GroupResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tDESIGN ID\tSTATUS")
+	for _, g := range groups {
+		designID := g.DesignID
+		if designID == "" {
+			designID = "-"
+		}
+		status := g.Status
+		if g.Cached {
+			status += " (cached)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", g.Group, designID, status)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,132 @@
+package kanvas_snapshot
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/schema"
+)
+
+var (
+	// kubeVersion selects the Kubernetes version the manifests are validated for
+	kubeVersion string
+	// crdPaths are files or directories with CustomResourceDefinitions of custom resources
+	crdPaths []string
+	// noValidate uploads the manifests without validating them
+	noValidate bool
+)
+
+// newSchemaRegistry returns the schemas of --kube-version with the CRDs from --crd
+func newSchemaRegistry() (*schema.Registry, error) {
+	registry, err := schema.Builtin(kubeVersion)
+	if err != nil {
+		return nil, errors.ErrLoadingSchemas(err)
+	}
+	for _, path := range crdPaths {
+		files, err := manifest.Load(path, manifest.LoadOptions{Recursive: true})
+		if err != nil {
+			return nil, errors.ErrLoadingSchemas(err)
+		}
+		found := 0
+		for _, f := range files {
+			if f.ParseErr != nil {
+				return nil, errors.ErrLoadingSchemas(f.ParseErr)
+			}
+			for _, r := range f.Resources {
+				if r.Kind != "CustomResourceDefinition" {
+					continue
+				}
+				if err := registry.AddCRD(r.Object); err != nil {
+					return nil, errors.ErrLoadingSchemas(fmt.Errorf("%s:%d: %w", r.Source, r.Line, err))
+				}
+				found++
+			}
+		}
+		if found == 0 {
+			return nil, errors.ErrLoadingSchemas(fmt.Errorf("no CustomResourceDefinitions found in %s", path))
+		}
+		Log.Debugf("Loaded %d CustomResourceDefinition(s) from %s", found, path)
+	}
+	return registry, nil
+}
+
+// validateManifests checks that the files parse and that each resource matches the schema
+// of its kind for --kube-version. Every problem is logged with its file and line, and the
+// run stops before anything is uploaded. Unknown fields and kinds without a schema are
+// reported as warnings.
+func validateManifests(files []manifest.File, warnings *resultWarnings) error {
+	if noValidate {
+		return nil
+	}
+	registry, err := newSchemaRegistry()
+	if err != nil {
+		return err
+	}
+
+	// CustomResourceDefinitions applied with the manifests describe their custom resources
+	var problems []string
+	for _, f := range files {
+		for _, r := range f.Resources {
+			if r.Kind == "CustomResourceDefinition" {
+				if err := registry.AddCRD(r.Object); err != nil {
+					problems = append(problems, fmt.Sprintf("%s:%d: %v", r.Source, r.Line, err))
+				}
+			}
+		}
+	}
+
+	unknown := make(map[string]bool)
+	for _, f := range files {
+		if f.ParseErr != nil {
+			problems = append(problems, f.ParseErr.Error())
+		}
+		for _, r := range f.Resources {
+			for _, v := range registry.Validate(r) {
+				msg := fmt.Sprintf("%s:%d: %s: %s", r.Source, r.LineOf(v.Path), resourceLabel(r), v)
+				if v.Warning {
+					warnings.warn(msg)
+					continue
+				}
+				problems = append(problems, msg)
+			}
+			if r.APIVersion != "" && r.Kind != "" && !registry.HasSchema(r) {
+				unknown[r.APIVersion+" "+r.Kind] = true
+			}
+		}
+	}
+
+	kinds := make([]string, 0, len(unknown))
+	for k := range unknown {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		warnings.warnf("No schema for %s, its resources were not validated. Pass its CustomResourceDefinition with --crd.", k)
+	}
+
+	if len(problems) > 0 {
+		for _, p := range problems {
+			Log.Errorf("%s", p)
+		}
+		return errors.ErrInvalidManifests(len(problems), problems[0])
+	}
+	Log.Infof("Validated the manifests against the schemas of Kubernetes %s", registry.Version())
+	return nil
+}
+
+// resourceLabel names a resource in messages, e.g. Deployment shop/web
+func resourceLabel(r manifest.Resource) string {
+	kind := r.Kind
+	if kind == "" {
+		kind = "resource"
+	}
+	switch {
+	case r.Name == "":
+		return kind
+	case r.Namespace == "":
+		return kind + " " + r.Name
+	}
+	return kind + " " + r.Namespace + "/" + r.Name
+}
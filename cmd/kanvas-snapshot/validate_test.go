@@ -0,0 +1,62 @@
+package kanvas_snapshot
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// useValidationFlags sets --kube-version and --crd for the test
+func useValidationFlags(t *testing.T, version string, crds ...string) {
+	t.Helper()
+	setupLogger(io.Discard)
+	oldVersion, oldCRDs := kubeVersion, crdPaths
+	kubeVersion, crdPaths = version, crds
+	t.Cleanup(func() { kubeVersion, crdPaths = oldVersion, oldCRDs })
+}
+
+func loadTestManifests(t *testing.T, name string) []manifest.File {
+	t.Helper()
+	files, err := manifest.Load(filepath.Join("..", "..", "pkg", "snapshot", "schema", "testdata", name), manifest.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestValidateManifestsWithCRDFlag(t *testing.T) {
+	files := loadTestManifests(t, "crontab.yaml")
+
+	useValidationFlags(t, "1.33")
+	warnings := &resultWarnings{}
+	if err := validateManifests(files, warnings); err != nil {
+		t.Fatalf("validateManifests without the CRD: %v", err)
+	}
+	if len(warnings.Warnings) != 1 || !strings.Contains(warnings.Warnings[0], "No schema for stable.example.com/v1 CronTab") {
+		t.Errorf("warnings = %v, want the CronTab without a schema", warnings.Warnings)
+	}
+
+	useValidationFlags(t, "1.33", filepath.Join("..", "..", "pkg", "snapshot", "schema", "testdata", "crontab-crd.yaml"))
+	err := validateManifests(files, &resultWarnings{})
+	if errors.ExitCode(err) != errors.ExitInvalidInput || !strings.Contains(errors.Details(err), "crontab.yaml:7: CronTab nightly: spec.replicas") {
+		t.Errorf("validateManifests with --crd = %v, want spec.replicas rejected", err)
+	}
+}
+
+func TestValidateManifestsUnknownFieldsWarn(t *testing.T) {
+	useValidationFlags(t, "1.33")
+	files := loadTestManifests(t, "invalid.yaml")
+	warnings := &resultWarnings{}
+
+	err := validateManifests(files, warnings)
+	if details := errors.Details(err); !strings.HasPrefix(details, "1 error(s)") || !strings.Contains(details, "invalid.yaml:6: Deployment api: spec.replicas") {
+		t.Errorf("validateManifests = %v, want spec.replicas rejected", err)
+	}
+	if len(warnings.Warnings) != 1 || !strings.Contains(warnings.Warnings[0], "invalid.yaml:18: Deployment api: spec.template.spec.containers[0].volumeMount: unknown field") {
+		t.Errorf("warnings = %v, want the unknown field", warnings.Warnings)
+	}
+}
@@ -0,0 +1,243 @@
+package kanvas_snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/design"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/errors"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// patternEndpoint is the Meshery API endpoint existing designs are saved with
+const patternEndpoint = "/api/pattern"
+
+// watchDebounce is how long the manifests must be unchanged before they are uploaded,
+// so an editor saving several files, or writing one file in steps, causes a single update
+const watchDebounce = 500 * time.Millisecond
+
+// watch keeps the design in sync with the manifests after it is created
+var watch bool
+
+// patternSaveRequest is the request body saving a design, under an existing ID or as a new design
+type patternSaveRequest struct {
+	Save        bool        `json:"save"`
+	PatternData patternData `json:"pattern_data"`
+}
+
+type patternData struct {
+	// ID is empty to create a new design
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	PatternFile string `json:"pattern_file"`
+}
+
+// UpdateMesheryDesign applies the given resources to an existing design. The design is
+// fetched first and only the configuration of its components is changed, so the layout
+// and the components Meshery added on import are kept.
+func UpdateMesheryDesign(designID, name string, resources []manifest.Resource) error {
+	pattern, err := FetchMesheryDesign(designID)
+	if err != nil {
+		return err
+	}
+	patternFile, err := design.Update([]byte(pattern.PatternFile), resources)
+	if err != nil {
+		return errors.ErrUpdatingMesheryDesign(designID, err)
+	}
+
+	payloadBytes, err := json.Marshal(patternSaveRequest{
+		Save:        true,
+		PatternData: patternData{ID: designID, Name: name, PatternFile: string(patternFile)},
+	})
+	if err != nil {
+		return errors.ErrUpdatingMesheryDesign(designID, err)
+	}
+
+	req, err := http.NewRequest("POST", MesheryAPIBaseURL+patternEndpoint, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return errors.ErrHTTPPostRequest(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setAuthCookie(req)
+
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return errors.ErrHTTPPostRequest(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.ErrHTTPPostRequest(err)
+	}
+	Log.Debugf("Response body: %s", string(body))
+
+	if isAuthFailure(resp, body) {
+		return authError(resp, body)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return errors.ErrUnexpectedResponseCode(resp.StatusCode, trimString(string(body), 200))
+	}
+	return nil
+}
+
+// watchManifests updates the design whenever the manifests at path change, until interrupted
+func watchManifests(path, designID, name string, files []manifest.File) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.ErrWatchManifests(err)
+	}
+	defer watcher.Close()
+
+	// A single file is watched through its directory, since editors often save
+	// by writing a new file and renaming it over the old one
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.ErrWatchManifests(err)
+	}
+	if info.IsDir() {
+		err = addWatchDirs(watcher, path)
+	} else {
+		err = watcher.Add(filepath.Dir(path))
+	}
+	if err != nil {
+		return errors.ErrWatchManifests(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	Log.Infof("Watching %s for changes. Press Ctrl+C to stop.", path)
+
+	lastHash := manifestHash(files)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			Log.Info("Stopped watching for changes")
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !isRelevantEvent(watcher, path, info.IsDir(), event) {
+				continue
+			}
+			Log.Debugf("Detected change: %s", event)
+			timer.Reset(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			Log.Warnf("Error watching manifest files: %v", err)
+
+		case <-timer.C:
+			lastHash = syncDesign(path, designID, name, lastHash)
+		}
+	}
+}
+
+// syncDesign re-reads the manifests and updates the design if they changed.
+// Failures are logged rather than returned so watching continues after a bad edit.
+// It returns the hash of the manifests the design now holds.
+func syncDesign(path, designID, name, lastHash string) string {
+	files, err := manifest.Load(path, manifestLoadOptions(recursive))
+	if err != nil {
+		Log.Warnf("Could not read manifests: %v", err)
+		return lastHash
+	}
+	for _, f := range files {
+		if f.ParseErr != nil {
+			Log.Warnf("Skipping update, could not parse %s: %v", f.Path, f.ParseErr)
+			return lastHash
+		}
+	}
+
+	hash := manifestHash(files)
+	if hash == lastHash {
+		Log.Debug("Manifests unchanged, skipping update")
+		return lastHash
+	}
+	if err := validateManifests(files, &resultWarnings{}); err != nil {
+		Log.Warnf("Skipping update, the manifests are invalid: %s", errorDetails(err))
+		return lastHash
+	}
+	if files, err = checkDuplicates(files, &resultWarnings{}); err != nil {
+		Log.Warnf("Skipping update: %s", errorDetails(err))
+		return lastHash
+	}
+
+	resources := manifestResources(files)
+	if err := UpdateMesheryDesign(designID, name, resources); err != nil {
+		Log.Warnf("Failed to update design %s: %v", designID, err)
+		if details := errors.Details(err); details != "" {
+			Log.Info(details)
+		}
+		return lastHash
+	}
+	Log.Infof("Updated design %s with %d resource(s) from %d file(s)", designID, len(resources), len(files))
+	return hash
+}
+
+// addWatchDirs watches dir and, with --recursive, its subdirectories
+func addWatchDirs(watcher *fsnotify.Watcher, dir string) error {
+	if !recursive {
+		return watcher.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(p)
+		}
+		return nil
+	})
+}
+
+// isRelevantEvent reports whether the event changes the manifests being watched.
+// New subdirectories are added to the watcher when watching recursively.
+func isRelevantEvent(watcher *fsnotify.Watcher, path string, isDir bool, event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	if !isDir {
+		return filepath.Clean(event.Name) == filepath.Clean(path)
+	}
+	if event.Has(fsnotify.Create) && recursive {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := addWatchDirs(watcher, event.Name); err != nil {
+				Log.Warnf("Could not watch %s: %v", event.Name, err)
+			}
+			return true
+		}
+	}
+	// Removing a subdirectory removes the manifests in it
+	return manifest.IsManifestFile(event.Name) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
+}
+
+// manifestHash returns a hash of the manifest paths and contents
+func manifestHash(files []manifest.File) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00%d\x00", f.Path, len(f.Content))
+		h.Write(f.Content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
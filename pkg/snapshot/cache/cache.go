@@ -0,0 +1,141 @@
+// Package cache maps manifest hashes to the designs and snapshots created from them,
+// so unchanged manifests are not uploaded again.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/filelock"
+)
+
+// Entry is a design created from a manifest
+type Entry struct {
+	DesignID   string `json:"designID"`
+	DesignName string `json:"designName"`
+	// AssetLocation is where the snapshot is published, empty if no snapshot was triggered
+	AssetLocation string    `json:"assetLocation,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// data is the content of the cache file: entries by server and key
+type data struct {
+	Servers map[string]map[string]Entry `json:"servers"`
+}
+
+// Cache is a design cache stored in a JSON file. Updates hold a lock on a
+// separate lock file and replace the cache file atomically, so concurrent
+// runs neither lose entries nor read a partly written file.
+type Cache struct {
+	path string
+}
+
+// New returns a cache stored in the file at path
+func New(path string) *Cache {
+	return &Cache{path: path}
+}
+
+// DefaultPath returns the cache file in the user cache directory,
+// e.g. ~/.cache/kubectl-kanvas-snapshot/designs.json on Linux
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kubectl-kanvas-snapshot", "designs.json"), nil
+}
+
+// Path returns the cache file
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Get returns the entry stored under key for the server
+func (c *Cache) Get(server, key string) (Entry, bool, error) {
+	d, err := c.read()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	entry, ok := d.Servers[serverKey(server)][key]
+	return entry, ok, nil
+}
+
+// Put stores the entry under key for the server, replacing any previous one
+func (c *Cache) Put(server, key string, entry Entry) error {
+	unlock, err := filelock.LockFor(c.path)
+	if err != nil {
+		return fmt.Errorf("error locking cache file: %w", err)
+	}
+	defer unlock()
+
+	// Read under the lock so entries written by concurrent runs are kept
+	d, err := c.read()
+	if err != nil {
+		return err
+	}
+	server = serverKey(server)
+	if d.Servers[server] == nil {
+		d.Servers[server] = make(map[string]Entry)
+	}
+	d.Servers[server][key] = entry
+	return c.write(d)
+}
+
+// Delete removes the entry stored under key for the server. Removing an absent entry is not an error.
+func (c *Cache) Delete(server, key string) error {
+	unlock, err := filelock.LockFor(c.path)
+	if err != nil {
+		return fmt.Errorf("error locking cache file: %w", err)
+	}
+	defer unlock()
+
+	d, err := c.read()
+	if err != nil {
+		return err
+	}
+	server = serverKey(server)
+	if _, ok := d.Servers[server][key]; !ok {
+		return nil
+	}
+	delete(d.Servers[server], key)
+	return c.write(d)
+}
+
+// read reads the cache file. A missing file is an empty cache.
+func (c *Cache) read() (*data, error) {
+	d := &data{}
+	content, err := os.ReadFile(c.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading cache file: %w", err)
+	}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, d); err != nil {
+			return nil, fmt.Errorf("error parsing cache file %s: %w", c.path, err)
+		}
+	}
+	if d.Servers == nil {
+		d.Servers = make(map[string]map[string]Entry)
+	}
+	return d, nil
+}
+
+// write replaces the cache file with d through a temporary file and a rename
+func (c *Cache) write(d *data) error {
+	content, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := filelock.WriteFileAtomic(c.path, content); err != nil {
+		return fmt.Errorf("error writing cache file: %w", err)
+	}
+	return nil
+}
+
+// serverKey normalizes a server URL so trailing slashes and case do not split the cache
+func serverKey(server string) string {
+	return strings.ToLower(strings.TrimRight(server, "/"))
+}
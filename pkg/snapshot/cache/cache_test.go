@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPutGetDelete(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "designs.json"))
+	const server, key = "http://localhost:9081", "shop:abc123"
+
+	if err := c.Put(server+"/", key, Entry{DesignID: "d1", DesignName: "shop"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c.Put(server, "other:def456", Entry{DesignID: "d2"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if entry, ok, err := c.Get("HTTP://LOCALHOST:9081", key); err != nil || !ok || entry.DesignID != "d1" {
+		t.Fatalf("Get = %+v, %v, %v, want d1", entry, ok, err)
+	}
+
+	if err := c.Delete(server, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, err := c.Get(server, key); err != nil || ok {
+		t.Errorf("Get after Delete = %v, %v, want a miss", ok, err)
+	}
+	if _, ok, _ := c.Get(server, "other:def456"); !ok {
+		t.Error("Delete removed another entry")
+	}
+	if err := c.Delete("http://other:9081", key); err != nil {
+		t.Errorf("Delete of an absent entry = %v, want nil", err)
+	}
+}
@@ -4,56 +4,170 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v2"
+	"strings"
 )
 
 // Config represents the plugin configuration
 type Config struct {
-	Meshery  MesheryConfig  `yaml:"meshery"`
-	Defaults DefaultsConfig `yaml:"defaults"`
+	Meshery  MesheryConfig  `yaml:"meshery,omitempty"`
+	GitHub   GitHubConfig   `yaml:"github,omitempty"`
+	HTTP     HTTPConfig     `yaml:"http,omitempty"`
+	Defaults DefaultsConfig `yaml:"defaults,omitempty"`
+	// CurrentProfile selects the profile applied when --profile is not set
+	CurrentProfile string             `yaml:"current_profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+
+	// dir is the directory of the config file, used to resolve relative token files
+	dir string
 }
 
 // MesheryConfig represents Meshery server configuration
 type MesheryConfig struct {
-	URL              string `yaml:"url"`
-	SnapshotEndpoint string `yaml:"snapshot_endpoint"`
+	URL              string `yaml:"url,omitempty"`
+	SnapshotEndpoint string `yaml:"snapshot_endpoint,omitempty"`
+	// TokenSource selects where the Meshery token is read from, see TokenSource constants
+	TokenSource string `yaml:"token_source,omitempty"`
+	// TokenFromEnv names an environment variable holding the Meshery token
+	TokenFromEnv string `yaml:"token_from_env,omitempty"`
+	// TokenFile is a file holding the Meshery token, relative to the config file
+	TokenFile string `yaml:"token_file,omitempty"`
+}
+
+// GitHubConfig represents the GitHub workflow used to render snapshots
+type GitHubConfig struct {
+	// APIURL is the GitHub REST API, https://<host>/api/v3 for GitHub Enterprise Server
+	APIURL   string `yaml:"api_url,omitempty"`
+	Owner    string `yaml:"owner,omitempty"`
+	Repo     string `yaml:"repo,omitempty"`
+	Branch   string `yaml:"branch,omitempty"`
+	Workflow string `yaml:"workflow,omitempty"`
+	// TokenSource selects where the GitHub token is read from, see TokenSource constants
+	TokenSource string `yaml:"token_source,omitempty"`
+	// TokenFromEnv names an environment variable holding the GitHub token
+	TokenFromEnv string `yaml:"token_from_env,omitempty"`
+	// TokenFile is a file holding the GitHub token, relative to the config file
+	TokenFile string `yaml:"token_file,omitempty"`
 }
 
+// HTTPConfig represents proxy and TLS settings for requests to Meshery and GitHub.
+// Relative paths are resolved against the directory of the config file.
+type HTTPConfig struct {
+	// Proxy is the proxy URL for all requests; HTTPS_PROXY and NO_PROXY are honored if unset
+	Proxy string `yaml:"proxy,omitempty"`
+	// CAFile is a PEM bundle of certificate authorities trusted in addition to the system ones
+	CAFile string `yaml:"ca_file,omitempty"`
+	// ClientCert and ClientKey are the PEM client certificate and key for mutual TLS
+	ClientCert string `yaml:"client_cert,omitempty"`
+	ClientKey  string `yaml:"client_key,omitempty"`
+	// InsecureSkipTLSVerify disables verification of server certificates
+	InsecureSkipTLSVerify bool `yaml:"insecure_skip_tls_verify,omitempty"`
+}
+
+// Profile represents a named set of Meshery server and GitHub settings
+type Profile struct {
+	MesheryConfig `yaml:",inline"`
+	GitHub        GitHubConfig `yaml:"github,omitempty"`
+}
+
+// Token sources supported by token_source
+const (
+	// TokenSourceAuto tries flags, environment, credential store and mesheryctl in turn
+	TokenSourceAuto = ""
+	// TokenSourceEnv only uses the token from flags and environment variables
+	TokenSourceEnv = "env"
+	// TokenSourceKeyring only uses the token from the credential store
+	TokenSourceKeyring = "keyring"
+	// TokenSourceMesheryctl uses the token of a mesheryctl context, written as mesheryctl[:<context>]
+	TokenSourceMesheryctl = "mesheryctl"
+)
+
 // DefaultsConfig represents default settings
 type DefaultsConfig struct {
-	SnapshotName       string `yaml:"snapshot_name"`
-	TimeoutSeconds     int    `yaml:"timeout_seconds"`
-	NotifyOnCompletion bool   `yaml:"notify_on_completion"`
+	SnapshotName       string `yaml:"snapshot_name,omitempty"`
+	TimeoutSeconds     int    `yaml:"timeout_seconds,omitempty"`
+	NotifyOnCompletion bool   `yaml:"notify_on_completion,omitempty"`
+}
+
+// EnvConfigFile is the environment variable selecting the config file
+const EnvConfigFile = "KANVAS_CONFIG"
+
+// Candidate is a location checked for the config file
+type Candidate struct {
+	Path string
+	// Source describes why the location was checked, e.g. --config or XDG_CONFIG_HOME
+	Source string
+	Exists bool
 }
 
-// GetConfigFilePath returns the path to the config file
-func GetConfigFilePath() string {
-	// Check in current directory
-	if _, err := os.Stat("config/config.yaml"); err == nil {
-		return "config/config.yaml"
+// ExplicitConfigFile returns the config file selected with the --config flag value,
+// or else with KANVAS_CONFIG, and the source of the selection. It is empty if neither is set.
+func ExplicitConfigFile(flag string) (path, source string) {
+	if flag != "" {
+		return flag, "--config"
 	}
+	if env := os.Getenv(EnvConfigFile); env != "" {
+		return env, EnvConfigFile
+	}
+	return "", ""
+}
 
-	// Check in user's home directory
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
-		configPath := filepath.Join(homeDir, ".meshery", "kubectl-kanvas-snapshot", "config.yaml")
-		if _, err := os.Stat(configPath); err == nil {
-			return configPath
+// XDGConfigPath returns the config file location under $XDG_CONFIG_HOME, which defaults to ~/.config
+func XDGConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
 		}
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "kubectl-kanvas-snapshot", "config.yaml"), nil
+}
+
+// FindConfigFile returns the config file to use and the candidates checked, in order:
+// the --config flag value, KANVAS_CONFIG, $XDG_CONFIG_HOME/kubectl-kanvas-snapshot/config.yaml
+// and ~/.meshery/kubectl-kanvas-snapshot/config.yaml. An explicitly selected file is returned
+// even if it does not exist. The path is empty if no config file was found.
+func FindConfigFile(flag string) (string, []Candidate) {
+	if path, source := ExplicitConfigFile(flag); path != "" {
+		return path, []Candidate{{Path: path, Source: source, Exists: fileExists(path)}}
+	}
+
+	var candidates []Candidate
+	if path, err := XDGConfigPath(); err == nil {
+		candidates = append(candidates, Candidate{Path: path, Source: "XDG_CONFIG_HOME", Exists: fileExists(path)})
+	}
+	if path, err := UserConfigPath(); err == nil {
+		candidates = append(candidates, Candidate{Path: path, Source: "home directory", Exists: fileExists(path)})
 	}
 
-	// Return default location
-	return "config/config.yaml"
+	for _, c := range candidates {
+		if c.Exists {
+			return c.Path, candidates
+		}
+	}
+	return "", candidates
 }
 
-// LoadConfig loads the configuration from the config file
-func LoadConfig() (*Config, error) {
-	configPath := GetConfigFilePath()
+// path resolves a path from the config file against the directory of the config file
+func (c *Config) path(p string) string {
+	if p == "" || filepath.IsAbs(p) || c.dir == "" {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
 
-	// Check if config file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return DefaultConfig(), nil
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// LoadConfig loads the configuration from the config file at path.
+// An empty path yields an empty configuration; defaults are applied by Resolve.
+func LoadConfig(configPath string) (*Config, error) {
+	if configPath == "" {
+		return &Config{}, nil
 	}
 
 	// Read config file
@@ -62,22 +176,15 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	// Parse YAML
-	config := &Config{}
-	err = yaml.Unmarshal(data, config)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing config file: %w", err)
-	}
-
-	// Validate config
-	if config.Meshery.URL == "" {
-		config.Meshery.URL = "http://localhost:9081"
-	}
-
-	if config.Meshery.SnapshotEndpoint == "" {
-		config.Meshery.SnapshotEndpoint = "/api/k8scontext/manifest"
+	// Reject unknown keys and invalid values
+	dir := filepath.Dir(configPath)
+	if problems := Validate(data, dir); len(problems) > 0 {
+		return nil, &ValidationError{Path: configPath, Problems: problems}
 	}
 
+	// Parse YAML, expanding environment variables in values
+	config, _, _ := parse(data)
+	config.dir = dir
 	return config, nil
 }
 
@@ -86,7 +193,14 @@ func DefaultConfig() *Config {
 	return &Config{
 		Meshery: MesheryConfig{
 			URL:              "http://localhost:9081",
-			SnapshotEndpoint: "/api/k8scontext/manifest",
+			SnapshotEndpoint: "/api/pattern/import",
+		},
+		GitHub: GitHubConfig{
+			APIURL:   "https://api.github.com",
+			Owner:    "layer5labs",
+			Repo:     "kubectl-kanvas-snapshot",
+			Branch:   "master",
+			Workflow: "kanvas.yaml",
 		},
 		Defaults: DefaultsConfig{
 			SnapshotName:       "kubectl-snapshot",
@@ -95,3 +209,57 @@ func DefaultConfig() *Config {
 		},
 	}
 }
+
+// ApplyProfile overlays the settings of the named profile onto the configuration.
+// An empty name selects current_profile; if neither is set, the configuration is unchanged.
+func (c *Config) ApplyProfile(name string) (string, error) {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return name, fmt.Errorf("profile '%s' not found in config file", name)
+	}
+
+	overlay(&c.Meshery.URL, profile.URL)
+	overlay(&c.Meshery.SnapshotEndpoint, profile.SnapshotEndpoint)
+	overlay(&c.Meshery.TokenSource, profile.TokenSource)
+	overlayToken(&c.Meshery.TokenFromEnv, &c.Meshery.TokenFile, profile.TokenFromEnv, profile.TokenFile)
+	overlay(&c.GitHub.APIURL, profile.GitHub.APIURL)
+	overlay(&c.GitHub.Owner, profile.GitHub.Owner)
+	overlay(&c.GitHub.Repo, profile.GitHub.Repo)
+	overlay(&c.GitHub.Branch, profile.GitHub.Branch)
+	overlay(&c.GitHub.Workflow, profile.GitHub.Workflow)
+	overlay(&c.GitHub.TokenSource, profile.GitHub.TokenSource)
+	overlayToken(&c.GitHub.TokenFromEnv, &c.GitHub.TokenFile, profile.GitHub.TokenFromEnv, profile.GitHub.TokenFile)
+	return name, nil
+}
+
+// overlayToken replaces the token reference if the profile sets one, so a profile's
+// token_from_env is not shadowed by a top-level token_file or the other way around
+func overlayToken(fromEnv, file *string, profileFromEnv, profileFile string) {
+	if profileFromEnv != "" || profileFile != "" {
+		*fromEnv, *file = profileFromEnv, profileFile
+	}
+}
+
+// overlay replaces dst with value if value is set
+func overlay(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+// ParseTokenSource splits a token source such as mesheryctl:<context> into its kind and argument
+func ParseTokenSource(source string) (kind, arg string, err error) {
+	kind, arg, _ = strings.Cut(source, ":")
+	switch kind {
+	case TokenSourceAuto, TokenSourceEnv, TokenSourceKeyring, TokenSourceMesheryctl:
+		return kind, arg, nil
+	}
+	return kind, arg, fmt.Errorf("unknown token source '%s': must be one of env, keyring, mesheryctl[:<context>]", source)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// SetValue sets a dotted key such as meshery.url in the config file at path.
+// Comments and the order of existing keys are preserved; missing sections and a
+// missing file are created. The file is only written if the result is valid.
+func SetValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode}}}
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if node.Kind != yamlv3.MappingNode {
+			return fmt.Errorf("cannot set '%s': '%s' is not a section", key, strings.Join(parts[:i], "."))
+		}
+		child := mappingValue(node, part)
+		if child == nil {
+			child = &yamlv3.Node{Kind: yamlv3.MappingNode}
+			if i == len(parts)-1 {
+				child = &yamlv3.Node{Kind: yamlv3.ScalarNode}
+			}
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: part}, child)
+		}
+		node = child
+	}
+
+	if node.Kind != yamlv3.ScalarNode {
+		return fmt.Errorf("cannot set '%s': it is a section, not a value", key)
+	}
+	node.Value = value
+	node.Tag = ""
+	node.Style = 0
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("error encoding config file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if problems := Validate(buf.Bytes(), filepath.Dir(path)); len(problems) > 0 {
+		return &ValidationError{Path: path, Problems: problems}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// GetValue returns the value of a dotted key such as meshery.url in the config file at path.
+// The boolean is false if the key is not set.
+func GetValue(path, key string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return "", false, fmt.Errorf("error parsing config file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return "", false, nil
+	}
+
+	node := doc.Content[0]
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yamlv3.MappingNode {
+			return "", false, nil
+		}
+		if node = mappingValue(node, part); node == nil {
+			return "", false, nil
+		}
+	}
+
+	if node.Kind != yamlv3.ScalarNode {
+		// Print sections as YAML
+		out, err := yamlv3.Marshal(node)
+		if err != nil {
+			return "", false, err
+		}
+		return strings.TrimSuffix(string(out), "\n"), true, nil
+	}
+	return node.Value, true, nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil if key is absent
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
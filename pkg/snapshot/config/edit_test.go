@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetValue(t *testing.T) {
+	path := writeConfig(t, `# Plugin configuration
+meshery:
+  # Local Meshery
+  url: http://localhost:9081
+github:
+  owner: octo
+`)
+
+	tests := []struct {
+		key, value string
+	}{
+		{"meshery.url", "https://meshery.example.com"},
+		{"github.repo", "app"},
+		{"defaults.timeout_seconds", "60"},
+		{"profiles.prod.github.owner", "prod-org"},
+	}
+	for _, tt := range tests {
+		if err := SetValue(path, tt.key, tt.value); err != nil {
+			t.Fatalf("SetValue(%s): %v", tt.key, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Plugin configuration
+meshery:
+  # Local Meshery
+  url: https://meshery.example.com
+github:
+  owner: octo
+  repo: app
+defaults:
+  timeout_seconds: 60
+profiles:
+  prod:
+    github:
+      owner: prod-org
+`
+	if string(data) != want {
+		t.Errorf("config file:\n%s\nwant:\n%s", data, want)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Defaults.TimeoutSeconds != 60 || cfg.Profiles["prod"].GitHub.Owner != "prod-org" {
+		t.Errorf("LoadConfig = %+v", cfg)
+	}
+}
+
+func TestSetValueCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubectl-kanvas-snapshot", "config.yaml")
+	if err := SetValue(path, "meshery.url", "http://localhost:9081"); err != nil {
+		t.Fatalf("SetValue: %v", err)
+	}
+	if v, ok, err := GetValue(path, "meshery.url"); err != nil || !ok || v != "http://localhost:9081" {
+		t.Errorf("GetValue = %q, %v, %v", v, ok, err)
+	}
+}
+
+func TestSetValueErrors(t *testing.T) {
+	const original = "meshery:\n  url: http://localhost:9081\ncurrent_profile: prod\nprofiles:\n  prod: {}\n"
+	tests := []struct {
+		name, key, value, message string
+	}{
+		{"invalid value", "meshery.url", "localhost", "must be an absolute http or https URL"},
+		{"wrong type", "defaults.timeout_seconds", "soon", "cannot unmarshal"},
+		{"unknown key", "meshery.tokn", "abc", "field tokn not found"},
+		{"section", "meshery", "abc", "it is a section, not a value"},
+		{"below a value", "meshery.url.host", "abc", "'meshery.url' is not a section"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, original)
+			err := SetValue(path, tt.key, tt.value)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("SetValue(%s, %s) = %v, want %s", tt.key, tt.value, err, tt.message)
+			}
+			// The file is only written if the result is valid
+			if data, _ := os.ReadFile(path); string(data) != original {
+				t.Errorf("config file changed to:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestGetValue(t *testing.T) {
+	path := writeConfig(t, "meshery:\n  url: http://localhost:9081\nprofiles:\n  prod:\n    url: https://meshery.example.com\n")
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"meshery.url", "http://localhost:9081", true},
+		{"profiles.prod", "url: https://meshery.example.com", true},
+		{"meshery.token_file", "", false},
+		{"github.owner", "", false},
+		{"meshery.url.host", "", false},
+	}
+	for _, tt := range tests {
+		if v, ok, err := GetValue(path, tt.key); err != nil || v != tt.value || ok != tt.ok {
+			t.Errorf("GetValue(%s) = %q, %v, %v, want %q, %v", tt.key, v, ok, err, tt.value, tt.ok)
+		}
+	}
+	if _, _, err := GetValue(filepath.Join(t.TempDir(), "missing.yaml"), "meshery.url"); err == nil {
+		t.Error("GetValue of a missing file succeeded, want an error")
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// envRefRegex matches ${VAR} and ${VAR:-default} references
+var envRefRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// envNameRegex matches valid environment variable names
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExpandEnv replaces ${VAR} with the value of the environment variable VAR and
+// ${VAR:-default} with default if VAR is unset or empty. Unset variables without a
+// default expand to an empty string. Other uses of $ are left unchanged.
+func ExpandEnv(s string) string {
+	return envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		match := envRefRegex.FindStringSubmatch(ref)
+		if value := os.Getenv(match[1]); value != "" {
+			return value
+		}
+		return match[3]
+	})
+}
+
+// expandNode expands environment variable references in the values of a YAML document.
+// Keys are left unchanged.
+func expandNode(node *yamlv3.Node) {
+	switch node.Kind {
+	case yamlv3.DocumentNode, yamlv3.SequenceNode:
+		for _, child := range node.Content {
+			expandNode(child)
+		}
+	case yamlv3.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			expandNode(node.Content[i])
+		}
+	case yamlv3.ScalarNode:
+		expanded := ExpandEnv(node.Value)
+		if expanded == node.Value {
+			return
+		}
+		node.Value = expanded
+		// Resolve the type of plain scalars again, so ${TIMEOUT:-30} decodes as a number
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	}
+}
+
+// readToken returns the token held by the environment variable fromEnv or the file at path.
+// Relative paths are resolved against dir, the directory of the config file.
+func readToken(fromEnv, path, dir string) (string, error) {
+	if fromEnv != "" {
+		return os.Getenv(fromEnv), nil
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// MesheryToken returns the Meshery token referenced by token_from_env or token_file.
+// An unreadable token file yields an empty token; LoadConfig reports it as invalid.
+func (c *Config) MesheryToken() string {
+	token, _ := readToken(c.Meshery.TokenFromEnv, c.Meshery.TokenFile, c.dir)
+	return token
+}
+
+// GitHubToken returns the GitHub token referenced by github.token_from_env or github.token_file.
+// An unreadable token file yields an empty token; LoadConfig reports it as invalid.
+func (c *Config) GitHubToken() string {
+	token, _ := readToken(c.GitHub.TokenFromEnv, c.GitHub.TokenFile, c.dir)
+	return token
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unsetenv unsets an environment variable for the duration of the test
+func unsetenv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "")
+	os.Unsetenv(name)
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("KANVAS_TEST_HOST", "meshery.example.com")
+	t.Setenv("KANVAS_TEST_EMPTY", "")
+	unsetenv(t, "KANVAS_TEST_UNSET")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"${KANVAS_TEST_HOST}", "meshery.example.com"},
+		{"https://${KANVAS_TEST_HOST}:9081", "https://meshery.example.com:9081"},
+		{"${KANVAS_TEST_UNSET}", ""},
+		{"${KANVAS_TEST_EMPTY}", ""},
+		{"${KANVAS_TEST_HOST:-localhost}", "meshery.example.com"},
+		{"${KANVAS_TEST_UNSET:-localhost}", "localhost"},
+		{"${KANVAS_TEST_EMPTY:-localhost}", "localhost"},
+		{"${KANVAS_TEST_UNSET:-}", ""},
+		{"${KANVAS_TEST_UNSET:-http://localhost:9081}", "http://localhost:9081"},
+		{"${KANVAS_TEST_HOST}/${KANVAS_TEST_UNSET:-api}", "meshery.example.com/api"},
+		// Other uses of $ are left unchanged
+		{"$KANVAS_TEST_HOST", "$KANVAS_TEST_HOST"},
+		{"pa$$word", "pa$$word"},
+		{"${KANVAS-TEST}", "${KANVAS-TEST}"},
+		{"${KANVAS_TEST_HOST", "${KANVAS_TEST_HOST"},
+		{"${KANVAS_TEST_UNSET-default}", "${KANVAS_TEST_UNSET-default}"},
+	}
+	for _, tt := range tests {
+		if got := ExpandEnv(tt.in); got != tt.want {
+			t.Errorf("ExpandEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigExpandsEnv(t *testing.T) {
+	t.Setenv("KANVAS_TEST_URL", "https://meshery.example.com")
+	t.Setenv("KANVAS_TEST_EMPTY", "")
+	unsetenv(t, "KANVAS_TEST_UNSET")
+	path := writeConfig(t, `
+meshery:
+  url: ${KANVAS_TEST_URL}
+github:
+  # Quoted values stay strings
+  owner: "${KANVAS_TEST_UNSET:-007}"
+  repo: ${KANVAS_TEST_EMPTY:-app}
+defaults:
+  timeout_seconds: ${KANVAS_TEST_UNSET:-45}
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Meshery.URL != "https://meshery.example.com" || cfg.GitHub.Owner != "007" || cfg.GitHub.Repo != "app" || cfg.Defaults.TimeoutSeconds != 45 {
+		t.Errorf("LoadConfig = %+v", cfg)
+	}
+
+	// Values are validated after expansion
+	t.Setenv("KANVAS_TEST_URL", "meshery.example.com")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig with an expanded relative URL succeeded, want an error")
+	}
+}
+
+func TestTokenReferences(t *testing.T) {
+	t.Setenv("KANVAS_TEST_TOKEN", "env-token")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "github-token"), []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("meshery:\n  token_from_env: KANVAS_TEST_TOKEN\ngithub:\n  token_file: github-token\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if token := cfg.MesheryToken(); token != "env-token" {
+		t.Errorf("MesheryToken = %q, want the value of token_from_env", token)
+	}
+	// Relative to the config file, with the trailing newline trimmed
+	if token := cfg.GitHubToken(); token != "file-token" {
+		t.Errorf("GitHubToken = %q, want the content of token_file", token)
+	}
+}
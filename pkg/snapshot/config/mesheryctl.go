@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MesheryctlConfig represents the subset of mesheryctl's ~/.meshery/config.yaml used by the plugin
+type MesheryctlConfig struct {
+	Contexts       map[string]MesheryctlContext `yaml:"contexts"`
+	CurrentContext string                       `yaml:"current-context"`
+	Tokens         []MesheryctlToken            `yaml:"tokens"`
+}
+
+// MesheryctlContext represents a named mesheryctl context
+type MesheryctlContext struct {
+	Endpoint string `yaml:"endpoint"`
+	Token    string `yaml:"token"`
+	Provider string `yaml:"provider"`
+}
+
+// MesheryctlToken represents a named token entry pointing at an auth file
+type MesheryctlToken struct {
+	Name     string `yaml:"name"`
+	Location string `yaml:"location"`
+}
+
+// MesheryctlAuth represents the contents of a mesheryctl auth file such as auth.json
+type MesheryctlAuth struct {
+	Provider string `json:"meshery-provider"`
+	Token    string `json:"token"`
+}
+
+// mesheryctlDir returns mesheryctl's configuration directory
+func mesheryctlDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".meshery"), nil
+}
+
+// GetMesheryctlConfigPath returns the path to mesheryctl's config file
+func GetMesheryctlConfigPath() (string, error) {
+	dir, err := mesheryctlDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// LoadMesheryctlConfig loads mesheryctl's config file. It returns nil without error if the file does not exist.
+func LoadMesheryctlConfig() (*MesheryctlConfig, error) {
+	path, err := GetMesheryctlConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading mesheryctl config file: %w", err)
+	}
+
+	cfg := &MesheryctlConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing mesheryctl config file: %w", err)
+	}
+	return cfg, nil
+}
+
+// Context returns the named context, or the current context if name is empty
+func (c *MesheryctlConfig) Context(name string) (string, *MesheryctlContext, error) {
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("no current-context set in mesheryctl config")
+	}
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return name, nil, fmt.Errorf("context '%s' not found in mesheryctl config", name)
+	}
+	return name, &ctx, nil
+}
+
+// LoadAuth reads the auth file referenced by the context's token
+func (c *MesheryctlConfig) LoadAuth(ctx *MesheryctlContext) (*MesheryctlAuth, error) {
+	var location string
+	for _, t := range c.Tokens {
+		if t.Name == ctx.Token {
+			location = t.Location
+			break
+		}
+	}
+	if location == "" {
+		return nil, fmt.Errorf("token '%s' not found in mesheryctl config", ctx.Token)
+	}
+
+	// Relative locations are resolved against mesheryctl's config directory
+	if !filepath.IsAbs(location) {
+		dir, err := mesheryctlDir()
+		if err != nil {
+			return nil, err
+		}
+		location = filepath.Join(dir, location)
+	}
+
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, fmt.Errorf("error reading mesheryctl auth file: %w", err)
+	}
+
+	auth := &MesheryctlAuth{}
+	if err := json.Unmarshal(data, auth); err != nil {
+		return nil, fmt.Errorf("error parsing mesheryctl auth file: %w", err)
+	}
+	return auth, nil
+}
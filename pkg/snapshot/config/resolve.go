@@ -0,0 +1,229 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Sources a resolved value can come from, in order of precedence
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceDotEnv  = ".env"
+	SourceConfig  = "config"
+	SourceDefault = "default"
+	SourceUnset   = "unset"
+)
+
+// Setting describes a configuration value and the places it can be set
+type Setting struct {
+	// Key is the dotted name of the setting, matching the config file layout
+	Key string
+	// Flag is the command line flag setting the value, if any
+	Flag string
+	// Env is the environment variable (and .env key) setting the value, if any
+	Env string
+	// Secret marks values that must be masked when displayed
+	Secret bool
+	// Config returns the value from a configuration. It is used for both the
+	// config file and DefaultConfig. Nil if the setting has no config file key.
+	Config func(*Config) string
+}
+
+// Settings lists every value resolved by Resolve
+var Settings = []Setting{
+	{Key: "meshery.url", Flag: "meshery-url", Env: "MESHERY_API_URL", Config: func(c *Config) string { return c.Meshery.URL }},
+	{Key: "meshery.snapshot_endpoint", Config: func(c *Config) string { return c.Meshery.SnapshotEndpoint }},
+	{Key: "meshery.token", Flag: "meshery-token", Env: "MESHERY_TOKEN", Secret: true, Config: (*Config).MesheryToken},
+	{Key: "meshery.cloud_url", Env: "MESHERY_CLOUD_URL"},
+	{Key: "github.token", Env: "GITHUB_TOKEN", Secret: true, Config: (*Config).GitHubToken},
+	{Key: "github.api_url", Flag: "github-api-url", Env: "GITHUB_API_URL", Config: func(c *Config) string { return c.GitHub.APIURL }},
+	{Key: "github.owner", Flag: "repo-owner", Config: func(c *Config) string { return c.GitHub.Owner }},
+	{Key: "github.repo", Flag: "repo-name", Config: func(c *Config) string { return c.GitHub.Repo }},
+	{Key: "github.branch", Flag: "branch", Config: func(c *Config) string { return c.GitHub.Branch }},
+	{Key: "github.workflow", Flag: "workflow", Config: func(c *Config) string { return c.GitHub.Workflow }},
+	{Key: "http.proxy", Flag: "proxy", Config: func(c *Config) string { return c.HTTP.Proxy }},
+	{Key: "http.ca_file", Flag: "ca-file", Config: func(c *Config) string { return c.path(c.HTTP.CAFile) }},
+	{Key: "http.client_cert", Flag: "client-cert", Config: func(c *Config) string { return c.path(c.HTTP.ClientCert) }},
+	{Key: "http.client_key", Flag: "client-key", Config: func(c *Config) string { return c.path(c.HTTP.ClientKey) }},
+	{Key: "http.insecure_skip_tls_verify", Flag: "insecure-skip-tls-verify", Config: func(c *Config) string {
+		if c.HTTP.InsecureSkipTLSVerify {
+			return "true"
+		}
+		return ""
+	}},
+	{Key: "defaults.timeout_seconds", Config: func(c *Config) string {
+		if c.Defaults.TimeoutSeconds > 0 {
+			return strconv.Itoa(c.Defaults.TimeoutSeconds)
+		}
+		return ""
+	}},
+}
+
+// Sources holds the places values are resolved from
+type Sources struct {
+	// Flag returns the value of a flag if it was set on the command line
+	Flag func(name string) (string, bool)
+	// Env returns the value of an environment variable if it is set
+	Env func(name string) (string, bool)
+	// DotEnv holds the values read from a .env file
+	DotEnv map[string]string
+	// Config is the loaded config file with the selected profile applied
+	Config *Config
+	// Profile is the name of the applied profile, used to label values it set
+	Profile string
+	// Selected is consulted right after the flags, for values of an explicitly selected
+	// source that belong together, such as the URL and token of a mesheryctl context
+	Selected func(key string) (value, source string, err error)
+	// Fallback is consulted after the config file and before the defaults, for values
+	// stored outside the config file such as tokens in the credential store
+	Fallback func(key string) (value, source string, err error)
+}
+
+// Value is a resolved setting and where it came from
+type Value struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// Resolved holds the resolved value of every setting
+type Resolved struct {
+	Values []Value
+}
+
+// Get returns the resolved value of a setting
+func (r *Resolved) Get(key string) string {
+	for _, v := range r.Values {
+		if v.Key == key {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// Source returns where the resolved value of a setting came from
+func (r *Resolved) Source(key string) string {
+	for _, v := range r.Values {
+		if v.Key == key {
+			return v.Source
+		}
+	}
+	return SourceUnset
+}
+
+// Resolve determines every setting using the precedence
+// flags > selected > environment > .env > config file > fallback > defaults
+func Resolve(sources Sources) (*Resolved, error) {
+	resolved := &Resolved{}
+	defaults := DefaultConfig()
+
+	var profile *Config
+	if sources.Config != nil && sources.Profile != "" {
+		if p, ok := sources.Config.Profiles[sources.Profile]; ok {
+			profile = &Config{Meshery: p.MesheryConfig, GitHub: p.GitHub, dir: sources.Config.dir}
+		}
+	}
+
+	for _, setting := range Settings {
+		value, source, err := resolveSetting(setting, sources, profile, defaults)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Values = append(resolved.Values, Value{Key: setting.Key, Value: value, Source: source, Secret: setting.Secret})
+	}
+	return resolved, nil
+}
+
+// resolveSetting resolves a single setting from the first source that sets it
+func resolveSetting(setting Setting, sources Sources, profile, defaults *Config) (string, string, error) {
+	if setting.Flag != "" && sources.Flag != nil {
+		if v, ok := sources.Flag(setting.Flag); ok {
+			return v, SourceFlag, nil
+		}
+	}
+	if sources.Selected != nil {
+		v, source, err := sources.Selected(setting.Key)
+		if err != nil {
+			return "", "", err
+		}
+		if v != "" {
+			return v, source, nil
+		}
+	}
+	if setting.Env != "" {
+		if sources.Env != nil {
+			if v, ok := sources.Env(setting.Env); ok && v != "" {
+				return v, SourceEnv, nil
+			}
+		}
+		if v := sources.DotEnv[setting.Env]; v != "" {
+			return v, SourceDotEnv, nil
+		}
+	}
+	if setting.Config != nil && sources.Config != nil {
+		if v := setting.Config(sources.Config); v != "" {
+			if profile != nil && setting.Config(profile) != "" {
+				return v, fmt.Sprintf("%s (profile '%s')", SourceConfig, sources.Profile), nil
+			}
+			return v, SourceConfig, nil
+		}
+	}
+	if sources.Fallback != nil {
+		v, source, err := sources.Fallback(setting.Key)
+		if err != nil {
+			return "", "", err
+		}
+		if v != "" {
+			return v, source, nil
+		}
+	}
+	if setting.Config != nil {
+		if v := setting.Config(defaults); v != "" {
+			return v, SourceDefault, nil
+		}
+	}
+	return "", SourceUnset, nil
+}
+
+// LoadDotEnv reads KEY=value pairs from a .env file. A missing file yields an empty map.
+func LoadDotEnv(path string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Skip comments and empty lines
+		if strings.HasPrefix(strings.TrimSpace(line), "#") || len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		// Parse key=value
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		// Remove quotes if present
+		value := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading .env file: %w", err)
+	}
+	return values, nil
+}
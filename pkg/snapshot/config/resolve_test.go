@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes a config file to a temporary directory and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// lookup returns a Sources lookup function reading from values
+func lookup(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `
+meshery:
+  url: http://config.example.com
+profiles:
+  staging:
+    url: http://staging.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.ApplyProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each level sets meshery.url; a case leaves out the levels above it
+	levels := []struct {
+		name   string
+		set    func(*Sources)
+		value  string
+		source string
+	}{
+		{
+			name:   "flag",
+			set:    func(s *Sources) { s.Flag = lookup(map[string]string{"meshery-url": "http://flag.example.com"}) },
+			value:  "http://flag.example.com",
+			source: SourceFlag,
+		},
+		{
+			name: "mesheryctl context",
+			set: func(s *Sources) {
+				s.Selected = func(key string) (string, string, error) {
+					if key == "meshery.url" {
+						return "http://context.example.com", "mesheryctl context 'local'", nil
+					}
+					return "", "", nil
+				}
+			},
+			value:  "http://context.example.com",
+			source: "mesheryctl context 'local'",
+		},
+		{
+			name:   "env",
+			set:    func(s *Sources) { s.Env = lookup(map[string]string{"MESHERY_API_URL": "http://env.example.com"}) },
+			value:  "http://env.example.com",
+			source: SourceEnv,
+		},
+		{
+			name:   ".env",
+			set:    func(s *Sources) { s.DotEnv = map[string]string{"MESHERY_API_URL": "http://dotenv.example.com"} },
+			value:  "http://dotenv.example.com",
+			source: SourceDotEnv,
+		},
+		{
+			name:   "config and profile",
+			set:    func(s *Sources) { s.Config, s.Profile = cfg, "staging" },
+			value:  "http://staging.example.com",
+			source: "config (profile 'staging')",
+		},
+		{
+			name: "credential fallback",
+			set: func(s *Sources) {
+				s.Fallback = func(key string) (string, string, error) {
+					if key == "meshery.url" {
+						return "http://stored.example.com", "credential store", nil
+					}
+					return "", "", nil
+				}
+			},
+			value:  "http://stored.example.com",
+			source: "credential store",
+		},
+		{
+			name:   "default",
+			set:    func(*Sources) {},
+			value:  "http://localhost:9081",
+			source: SourceDefault,
+		},
+	}
+
+	for i, level := range levels {
+		t.Run(level.name, func(t *testing.T) {
+			var sources Sources
+			for _, lower := range levels[i:] {
+				lower.set(&sources)
+			}
+			resolved, err := Resolve(sources)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if v, s := resolved.Get("meshery.url"), resolved.Source("meshery.url"); v != level.value || s != level.source {
+				t.Errorf("meshery.url = %s from %s, want %s from %s", v, s, level.value, level.source)
+			}
+		})
+	}
+}
+
+func TestResolveSources(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `
+meshery:
+  url: http://config.example.com
+github:
+  owner: octo
+profiles:
+  staging:
+    url: http://staging.example.com
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.ApplyProfile("staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := Resolve(Sources{
+		// Empty environment variables are ignored
+		Env:     lookup(map[string]string{"MESHERY_TOKEN": "", "GITHUB_TOKEN": "gh-env"}),
+		DotEnv:  map[string]string{"MESHERY_TOKEN": "dotenv-token"},
+		Config:  cfg,
+		Profile: "staging",
+	})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	tests := []struct {
+		key, value, source string
+	}{
+		{"meshery.url", "http://staging.example.com", "config (profile 'staging')"},
+		{"meshery.token", "dotenv-token", SourceDotEnv},
+		{"github.token", "gh-env", SourceEnv},
+		// Set in the config file but not by the profile
+		{"github.owner", "octo", SourceConfig},
+		{"github.repo", "kubectl-kanvas-snapshot", SourceDefault},
+		{"meshery.cloud_url", "", SourceUnset},
+	}
+	for _, tt := range tests {
+		if v, s := resolved.Get(tt.key), resolved.Source(tt.key); v != tt.value || s != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.key, v, s, tt.value, tt.source)
+		}
+	}
+	for _, v := range resolved.Values {
+		if v.Secret != (v.Key == "meshery.token" || v.Key == "github.token") {
+			t.Errorf("%s: Secret = %v", v.Key, v.Secret)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	fail := func(string) (string, string, error) { return "", "", fmt.Errorf("locked") }
+	for name, sources := range map[string]Sources{
+		"selected": {Selected: fail},
+		"fallback": {Fallback: fail},
+	} {
+		if _, err := Resolve(sources); err == nil {
+			t.Errorf("Resolve with a failing %s source succeeded, want an error", name)
+		}
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("# comment\n\nMESHERY_API_URL = \"http://dotenv.example.com\"\nMESHERY_TOKEN='a=b'\ninvalid line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	values, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatalf("LoadDotEnv: %v", err)
+	}
+	if len(values) != 2 || values["MESHERY_API_URL"] != "http://dotenv.example.com" || values["MESHERY_TOKEN"] != "a=b" {
+		t.Errorf("LoadDotEnv = %v", values)
+	}
+
+	if values, err := LoadDotEnv(filepath.Join(t.TempDir(), ".env")); err != nil || len(values) != 0 {
+		t.Errorf("LoadDotEnv of a missing file = %v, %v, want no values", values, err)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Template is the commented configuration written by Init
+const Template = `# Configuration for kubectl-kanvas-snapshot plugin
+#
+# Settings are resolved in the order: flags, environment variables, .env file,
+# this file (with the selected profile applied), credential store, defaults.
+# A mesheryctl context selected with --meshery-context sets the Meshery URL and
+# token together, ranking with the flags.
+# Run 'kubectl kanvas-snapshot config view --resolved' to see the values in effect.
+#
+# Values may reference environment variables as ${VAR} or ${VAR:-default}.
+# Tokens are never stored here: point token_from_env or token_file at them instead.
+
+# Meshery server configuration
+meshery:
+  # URL of the Meshery server (MESHERY_API_URL, --meshery-url). When unset, the
+  # endpoint of the mesheryctl context is used, then http://localhost:9081.
+  # url: "http://localhost:9081"
+  # API endpoint for snapshot creation
+  snapshot_endpoint: "/api/pattern/import"
+  # Where the Meshery token is read from: env, keyring or mesheryctl[:<context>]
+  # (default: credential store, then the current mesheryctl context)
+  # token_source: "keyring"
+  # Read the Meshery token from an environment variable or a file
+  # (relative to this file). Set at most one of them.
+  # token_from_env: "CI_MESHERY_TOKEN"
+  # token_file: "meshery-token"
+
+# GitHub workflow used to render snapshot images
+github:
+  # GitHub REST API (GITHUB_API_URL, --github-api-url), e.g. https://github.example.com/api/v3
+  api_url: "https://api.github.com"
+  owner: "layer5labs"
+  repo: "kubectl-kanvas-snapshot"
+  branch: "master"
+  workflow: "kanvas.yaml"
+  # Where the GitHub token is read from: env or keyring (default: both)
+  # token_source: "keyring"
+  # token_from_env: "CI_GITHUB_TOKEN"
+
+# Proxy and TLS settings for requests to Meshery and GitHub.
+# Relative paths are resolved against the directory of this file.
+# http:
+#   # Proxy URL (default: HTTPS_PROXY, HTTP_PROXY and NO_PROXY)
+#   proxy: "http://proxy.example.com:3128"
+#   # Certificate authorities trusted in addition to the system ones
+#   ca_file: "corporate-ca.pem"
+#   # Client certificate and key for mutual TLS
+#   client_cert: "client.pem"
+#   client_key: "client-key.pem"
+#   # Disable server certificate verification (insecure, for testing only)
+#   insecure_skip_tls_verify: false
+
+# Default settings
+defaults:
+  # Default name for snapshots if not specified
+  snapshot_name: "kubectl-snapshot"
+  # Timeout in seconds for requests to Meshery and GitHub
+  timeout_seconds: 30
+  # Default notification settings
+  notify_on_completion: true
+
+# Profile applied when --profile is not set
+# current_profile: "playground"
+
+# Named profiles for multiple Meshery servers. Settings of the selected
+# profile override the top-level meshery and github settings.
+# profiles:
+#   playground:
+#     url: "https://playground.meshery.io"
+#   staging:
+#     url: "https://meshery.staging.example.com"
+#     token_source: "mesheryctl:staging"
+#     github:
+#       owner: "example"
+#       repo: "kanvas-snapshots"
+`
+
+// UserConfigPath returns the path of the config file in the user's home directory
+func UserConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".meshery", "kubectl-kanvas-snapshot", "config.yaml"), nil
+}
+
+// Init writes the commented configuration template to path.
+// An existing file is only replaced if force is set.
+func Init(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("config file %s already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+	return os.WriteFile(path, []byte(Template), 0644)
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Problem is an invalid setting found in a config file
+type Problem struct {
+	// Line is the line of the setting in the file, or 0 if unknown
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// ValidationError reports the problems found in a config file
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		if p.Line == 0 {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", e.Path, p.Message))
+		} else {
+			msgs = append(msgs, fmt.Sprintf("%s:%d: %s", e.Path, p.Line, p.Message))
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// yamlLineRegex matches the line prefix YAML decoders add to their error messages
+var yamlLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// parse decodes a config file, expanding environment variables in values.
+// Unknown keys and values of the wrong type are reported with their line numbers.
+func parse(data []byte) (*Config, *yamlv3.Node, []Problem) {
+	cfg := &Config{}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return cfg, &doc, decodeProblems(err)
+	}
+	if len(doc.Content) == 0 {
+		return cfg, &doc, nil
+	}
+
+	root := doc.Content[0]
+	problems := unknownFields(root, reflect.TypeOf(Config{}))
+	expandNode(root)
+	if err := root.Decode(cfg); err != nil {
+		problems = append(problems, decodeProblems(err)...)
+	}
+	return cfg, &doc, problems
+}
+
+// Validate checks the contents of a config file. Unknown keys and values of the
+// wrong type are rejected, as are malformed URLs, endpoints, token sources and token
+// references. dir is the directory of the config file, used to resolve token files.
+func Validate(data []byte, dir string) []Problem {
+	cfg, doc, problems := parse(data)
+	if len(problems) > 0 {
+		return problems
+	}
+	cfg.dir = dir
+
+	v := &validator{doc: doc, dir: dir}
+	v.checkURL("meshery.url", cfg.Meshery.URL)
+	v.checkEndpoint("meshery.snapshot_endpoint", cfg.Meshery.SnapshotEndpoint)
+	v.checkURL("github.api_url", cfg.GitHub.APIURL)
+	v.checkTokenSource("meshery.token_source", cfg.Meshery.TokenSource, true)
+	v.checkTokenSource("github.token_source", cfg.GitHub.TokenSource, false)
+	v.checkToken("meshery", cfg.Meshery.TokenFromEnv, cfg.Meshery.TokenFile)
+	v.checkToken("github", cfg.GitHub.TokenFromEnv, cfg.GitHub.TokenFile)
+	v.checkProxy("http.proxy", cfg.HTTP.Proxy)
+	v.checkFile("http.ca_file", cfg.HTTP.CAFile)
+	v.checkFile("http.client_cert", cfg.HTTP.ClientCert)
+	v.checkFile("http.client_key", cfg.HTTP.ClientKey)
+	if (cfg.HTTP.ClientCert == "") != (cfg.HTTP.ClientKey == "") {
+		v.addf("http", "set both client_cert and client_key for mutual TLS")
+	}
+	if cfg.Defaults.TimeoutSeconds < 0 {
+		v.addf("defaults.timeout_seconds", "must not be negative")
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := cfg.Profiles[name]
+		prefix := "profiles." + name
+		v.checkURL(prefix+".url", profile.URL)
+		v.checkEndpoint(prefix+".snapshot_endpoint", profile.SnapshotEndpoint)
+		v.checkURL(prefix+".github.api_url", profile.GitHub.APIURL)
+		v.checkTokenSource(prefix+".token_source", profile.TokenSource, true)
+		v.checkTokenSource(prefix+".github.token_source", profile.GitHub.TokenSource, false)
+		v.checkToken(prefix, profile.TokenFromEnv, profile.TokenFile)
+		v.checkToken(prefix+".github", profile.GitHub.TokenFromEnv, profile.GitHub.TokenFile)
+	}
+
+	if cfg.CurrentProfile != "" {
+		if _, ok := cfg.Profiles[cfg.CurrentProfile]; !ok {
+			v.addf("current_profile", "profile '%s' is not defined under profiles", cfg.CurrentProfile)
+		}
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool { return v.problems[i].Line < v.problems[j].Line })
+	return v.problems
+}
+
+// decodeProblems converts a YAML decoding error into problems with line numbers
+func decodeProblems(err error) []Problem {
+	var msgs []string
+	if typeErr, ok := err.(*yamlv3.TypeError); ok {
+		msgs = typeErr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	problems := make([]Problem, 0, len(msgs))
+	for _, msg := range msgs {
+		msg = strings.TrimSpace(msg)
+		if m := yamlLineRegex.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			problems = append(problems, Problem{Line: line, Message: m[2]})
+			continue
+		}
+		problems = append(problems, Problem{Message: strings.TrimPrefix(msg, "yaml: ")})
+	}
+	return problems
+}
+
+// validator collects problems, locating each setting in the parsed document
+type validator struct {
+	doc      *yamlv3.Node
+	dir      string
+	problems []Problem
+}
+
+// addf records a problem with the setting at the dotted key
+func (v *validator) addf(key, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Line:    lineOf(v.doc, key),
+		Message: fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, args...)),
+	})
+}
+
+// checkURL requires an absolute http or https URL
+func (v *validator) checkURL(key, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.addf(key, "invalid URL: %v", err)
+		return
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(key, "'%s' must be an absolute http or https URL", value)
+	}
+}
+
+// checkProxy requires an http, https or socks5 proxy URL
+func (v *validator) checkProxy(key, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		// The parse error quotes the URL, which may hold a password
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		v.addf(key, "invalid URL: %v", err)
+		return
+	}
+	if (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+		v.addf(key, "'%s' must be an http, https or socks5 URL", u.Redacted())
+	}
+}
+
+// checkFile requires a readable file, relative to the config file
+func (v *validator) checkFile(key, path string) {
+	if path == "" {
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(v.dir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		v.addf(key, "%v", err)
+	}
+}
+
+// checkEndpoint requires an API path
+func (v *validator) checkEndpoint(key, value string) {
+	if value != "" && !strings.HasPrefix(value, "/") {
+		v.addf(key, "'%s' must be a path starting with '/'", value)
+	}
+}
+
+// checkTokenSource requires a supported token source
+func (v *validator) checkTokenSource(key, value string, allowMesheryctl bool) {
+	kind, _, err := ParseTokenSource(value)
+	if err != nil {
+		v.addf(key, "%v", err)
+		return
+	}
+	if kind == TokenSourceMesheryctl && !allowMesheryctl {
+		v.addf(key, "mesheryctl only provides Meshery tokens: must be one of env, keyring")
+	}
+}
+
+// checkToken requires at most one valid token reference
+func (v *validator) checkToken(prefix, fromEnv, file string) {
+	if fromEnv != "" && file != "" {
+		v.addf(prefix+".token_file", "set only one of token_from_env and token_file")
+		return
+	}
+	if fromEnv != "" && !envNameRegex.MatchString(fromEnv) {
+		v.addf(prefix+".token_from_env", "'%s' is not a valid environment variable name", fromEnv)
+	}
+	if file != "" {
+		if _, err := readToken("", file, v.dir); err != nil {
+			v.addf(prefix+".token_file", "%v", err)
+		}
+	}
+}
+
+// unknownFields reports keys of a mapping that do not match a field of the target type
+func unknownFields(node *yamlv3.Node, t reflect.Type) []Problem {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+
+	var problems []Problem
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, Problem{Line: key.Line, Message: fmt.Sprintf("field %s not found in type %s", key.Value, t)})
+				continue
+			}
+			problems = append(problems, unknownFields(value, fieldType)...)
+		}
+	case reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			problems = append(problems, unknownFields(node.Content[i], t.Elem())...)
+		}
+	}
+	return problems
+}
+
+// yamlFields returns the types of a struct's fields by YAML key, including inlined structs
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(field.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// lineOf returns the line of the value at a dotted key, or 0 if it is not in the document
+func lineOf(doc *yamlv3.Node, key string) int {
+	if len(doc.Content) == 0 {
+		return 0
+	}
+	node := doc.Content[0]
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yamlv3.MappingNode {
+			return 0
+		}
+		if node = mappingValue(node, part); node == nil {
+			return 0
+		}
+	}
+	return node.Line
+}
@@ -0,0 +1,123 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// EncryptedFileStore stores credentials in a file encrypted with AES-256-GCM.
+// The key is kept in a separate file readable only by the user, so the credentials
+// file alone (e.g. attached to a support bundle) does not reveal the tokens. Both files
+// sit in the same directory with the same permissions, so against anyone who can read
+// that directory, such as a backup of it, this is no safer than a 0600 plaintext file.
+type EncryptedFileStore struct {
+	path    string
+	keyPath string
+}
+
+// NewEncryptedFileStore returns a store backed by the encrypted file at path, using the key at keyPath
+func NewEncryptedFileStore(path, keyPath string) *EncryptedFileStore {
+	return &EncryptedFileStore{path: path, keyPath: keyPath}
+}
+
+// Name describes the store for log messages
+func (s *EncryptedFileStore) Name() string {
+	return fmt.Sprintf("encrypted file %s", s.path)
+}
+
+// Get returns the stored value, or ErrNotFound if there is none
+func (s *EncryptedFileStore) Get(key string) (string, error) {
+	values, err := readValues(s.path, s.decrypt)
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores the value, replacing any previous one
+func (s *EncryptedFileStore) Set(key, value string) error {
+	return updateValues(s.path, s.decrypt, s.encrypt, func(values map[string]string) {
+		values[key] = value
+	})
+}
+
+// Delete removes the value. Deleting an absent value is not an error.
+func (s *EncryptedFileStore) Delete(key string) error {
+	return updateValues(s.path, s.decrypt, s.encrypt, func(values map[string]string) {
+		delete(values, key)
+	})
+}
+
+// key returns the encryption key, generating and saving a new one if none exists yet
+func (s *EncryptedFileStore) key() ([]byte, error) {
+	key, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("invalid key length in %s", s.keyPath)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.keyPath), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(s.keyPath, key, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// gcm returns the AES-GCM cipher for the store's key
+func (s *EncryptedFileStore) gcm() (cipher.AEAD, error) {
+	key, err := s.key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals data, prefixing the result with a random nonce
+func (s *EncryptedFileStore) encrypt(data []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// decrypt opens data sealed by encrypt
+func (s *EncryptedFileStore) decrypt(data []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,103 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileStore stores credentials unencrypted in a JSON file readable only by the user.
+// It is a test double for the other stores and should not hold real tokens.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a store backed by the plain JSON file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Name describes the store for log messages
+func (s *FileStore) Name() string {
+	return fmt.Sprintf("file %s", s.path)
+}
+
+// Get returns the stored value, or ErrNotFound if there is none
+func (s *FileStore) Get(key string) (string, error) {
+	values, err := readValues(s.path, nil)
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Set stores the value, replacing any previous one
+func (s *FileStore) Set(key, value string) error {
+	return updateValues(s.path, nil, nil, func(values map[string]string) {
+		values[key] = value
+	})
+}
+
+// Delete removes the value. Deleting an absent value is not an error.
+func (s *FileStore) Delete(key string) error {
+	return updateValues(s.path, nil, nil, func(values map[string]string) {
+		delete(values, key)
+	})
+}
+
+// readValues reads the JSON map stored at path, decoding the file contents with decode if set
+func readValues(path string, decode func([]byte) ([]byte, error)) (map[string]string, error) {
+	values := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading credentials file: %w", err)
+	}
+
+	if decode != nil {
+		if data, err = decode(data); err != nil {
+			return nil, fmt.Errorf("error decoding credentials file: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("error parsing credentials file: %w", err)
+	}
+	return values, nil
+}
+
+// updateValues applies update to the JSON map stored at path and writes it back with 0600 permissions
+func updateValues(path string, decode, encode func([]byte) ([]byte, error), update func(map[string]string)) error {
+	values, err := readValues(path, decode)
+	if err != nil {
+		return err
+	}
+	update(values)
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if encode != nil {
+		if data, err = encode(data); err != nil {
+			return fmt.Errorf("error encoding credentials file: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating credentials directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing credentials file: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file, so enforce it explicitly
+	return os.Chmod(path, 0600)
+}
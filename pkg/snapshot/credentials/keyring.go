@@ -0,0 +1,52 @@
+package credentials
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringStore stores credentials in the OS keyring: the Secret Service (libsecret) on Linux,
+// the Keychain on macOS and the Credential Manager on Windows
+type KeyringStore struct {
+	service string
+}
+
+// NewKeyringStore returns a store backed by the OS keyring
+func NewKeyringStore() *KeyringStore {
+	return &KeyringStore{service: serviceName}
+}
+
+// Name describes the store for log messages
+func (s *KeyringStore) Name() string {
+	return "OS keyring"
+}
+
+// Available reports whether the keyring can be reached, e.g. a Secret Service is running on Linux
+func (s *KeyringStore) Available() bool {
+	_, err := keyring.Get(s.service, KeyMesheryToken)
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+// Get returns the stored value, or ErrNotFound if there is none
+func (s *KeyringStore) Get(key string) (string, error) {
+	value, err := keyring.Get(s.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+// Set stores the value, replacing any previous one
+func (s *KeyringStore) Set(key, value string) error {
+	return keyring.Set(s.service, key, value)
+}
+
+// Delete removes the value. Deleting an absent value is not an error.
+func (s *KeyringStore) Delete(key string) error {
+	err := keyring.Delete(s.service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
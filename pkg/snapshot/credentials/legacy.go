@@ -0,0 +1,66 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// legacyCredentials is the layout of credentials.json, written by login before the
+// credential stores existed
+type legacyCredentials struct {
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+}
+
+// LegacyPath returns the plaintext credentials file of earlier versions,
+// ~/.meshery/kubectl-kanvas-snapshot/credentials.json
+func LegacyPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.json"), nil
+}
+
+// MigrateLegacyFile moves the Meshery token and provider of the plaintext credentials file
+// at path into store and deletes the file. A token already in the store is kept. It reports
+// whether a token was imported; a missing file is not an error.
+func MigrateLegacyFile(path string, store Store) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading legacy credentials file: %w", err)
+	}
+	var legacy legacyCredentials
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return false, fmt.Errorf("error parsing legacy credentials file %s: %w", path, err)
+	}
+
+	imported := false
+	if legacy.Token != "" {
+		_, err := store.Get(KeyMesheryToken)
+		switch {
+		case err == ErrNotFound:
+			if err := store.Set(KeyMesheryToken, legacy.Token); err != nil {
+				return false, err
+			}
+			if legacy.Provider != "" {
+				if err := store.Set(KeyMesheryProvider, legacy.Provider); err != nil {
+					return false, err
+				}
+			}
+			imported = true
+		case err != nil:
+			return false, err
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return imported, fmt.Errorf("error removing legacy credentials file: %w", err)
+	}
+	return imported, nil
+}
@@ -0,0 +1,77 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeLegacyFile writes a credentials.json as saved by earlier versions of login
+func writeLegacyFile(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "credentials.json")
+	data := `{"provider": "Layer5", "provider_url": "https://cloud.layer5.io", "token": "legacy-token"}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrateLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeLegacyFile(t, dir)
+	store := NewFileStore(filepath.Join(dir, "store.json"))
+
+	imported, err := MigrateLegacyFile(path, store)
+	if err != nil || !imported {
+		t.Fatalf("MigrateLegacyFile = %v, %v, want the token imported", imported, err)
+	}
+	if got, _ := store.Get(KeyMesheryToken); got != "legacy-token" {
+		t.Errorf("token = %q, want %q", got, "legacy-token")
+	}
+	if got, _ := store.Get(KeyMesheryProvider); got != "Layer5" {
+		t.Errorf("provider = %q, want %q", got, "Layer5")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("legacy file still exists: %v", err)
+	}
+
+	// Later runs find nothing to import
+	if imported, err := MigrateLegacyFile(path, store); err != nil || imported {
+		t.Errorf("second MigrateLegacyFile = %v, %v, want nothing imported", imported, err)
+	}
+}
+
+func TestMigrateLegacyFileKeepsStoredToken(t *testing.T) {
+	dir := t.TempDir()
+	path := writeLegacyFile(t, dir)
+	store := NewFileStore(filepath.Join(dir, "store.json"))
+	if err := store.Set(KeyMesheryToken, "current-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := MigrateLegacyFile(path, store)
+	if err != nil || imported {
+		t.Fatalf("MigrateLegacyFile = %v, %v, want nothing imported", imported, err)
+	}
+	if got, _ := store.Get(KeyMesheryToken); got != "current-token" {
+		t.Errorf("token = %q, want the stored one kept", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("legacy file still exists: %v", err)
+	}
+}
+
+func TestMigrateLegacyFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateLegacyFile(path, NewFileStore(filepath.Join(dir, "store.json"))); err == nil {
+		t.Error("MigrateLegacyFile succeeded, want a parse error")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("unparsable legacy file was removed: %v", err)
+	}
+}
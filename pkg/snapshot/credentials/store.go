@@ -0,0 +1,74 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Names of the credentials managed by the plugin
+const (
+	// KeyMesheryToken is the Meshery token used to authenticate API requests
+	KeyMesheryToken = "meshery-token"
+	// KeyMesheryProvider is the remote provider that issued the Meshery token
+	KeyMesheryProvider = "meshery-provider"
+	// KeyGitHubToken is the GitHub token used to dispatch the snapshot workflow
+	KeyGitHubToken = "github-token"
+)
+
+// serviceName identifies the plugin's entries in the OS keyring
+const serviceName = "kubectl-kanvas-snapshot"
+
+// ErrNotFound is returned by Get when no value is stored for a key
+var ErrNotFound = errors.New("credential not found")
+
+// Store is a place credentials can be saved to and read from
+type Store interface {
+	// Name describes the store for log messages
+	Name() string
+	// Get returns the stored value, or ErrNotFound if there is none
+	Get(key string) (string, error)
+	// Set stores the value, replacing any previous one
+	Set(key, value string) error
+	// Delete removes the value. Deleting an absent value is not an error.
+	Delete(key string) error
+}
+
+// Keys returns the names of the credentials that can be managed
+func Keys() []string {
+	return []string{KeyMesheryToken, KeyMesheryProvider, KeyGitHubToken}
+}
+
+// IsValidKey reports whether key names a managed credential
+func IsValidKey(key string) bool {
+	for _, k := range Keys() {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir returns the per-user directory file based stores keep their data in
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".meshery", "kubectl-kanvas-snapshot"), nil
+}
+
+// Default returns the OS keyring if it is usable, falling back to an encrypted file
+func Default() (Store, error) {
+	keyring := NewKeyringStore()
+	if keyring.Available() {
+		return keyring, nil
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return nil, fmt.Errorf("no usable credential store: %w", err)
+	}
+	return NewEncryptedFileStore(filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key")), nil
+}
@@ -0,0 +1,128 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+// testStore exercises set, get and clear on a store
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+
+	if _, err := store.Get(KeyMesheryToken); err != ErrNotFound {
+		t.Fatalf("Get on an empty store = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Set(KeyMesheryToken, "first"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := store.Set(KeyGitHubToken, "ghp_token"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := store.Set(KeyMesheryToken, "second"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := store.Get(KeyMesheryToken); err != nil || got != "second" {
+		t.Errorf("Get(%s) = %q, %v, want the replaced value %q", KeyMesheryToken, got, err, "second")
+	}
+
+	if err := store.Delete(KeyMesheryToken); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(KeyMesheryToken); err != ErrNotFound {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(KeyMesheryToken); err != nil {
+		t.Errorf("Delete of an absent value = %v, want nil", err)
+	}
+	if got, err := store.Get(KeyGitHubToken); err != nil || got != "ghp_token" {
+		t.Errorf("Get(%s) = %q, %v, want it kept", KeyGitHubToken, got, err)
+	}
+}
+
+// assertPrivate fails if the file is readable by other users
+func assertPrivate(t *testing.T, path string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("%s has mode %o, want 600", path, mode)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "credentials.json")
+	testStore(t, NewFileStore(path))
+	assertPrivate(t, path)
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir := t.TempDir()
+	path, keyPath := filepath.Join(dir, "credentials.enc"), filepath.Join(dir, "credentials.key")
+	store := NewEncryptedFileStore(path, keyPath)
+	testStore(t, store)
+	assertPrivate(t, path)
+	assertPrivate(t, keyPath)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("ghp_token")) {
+		t.Error("credentials file holds the token in plaintext")
+	}
+
+	// Another key cannot decrypt the file
+	other := NewEncryptedFileStore(path, filepath.Join(dir, "other.key"))
+	if _, err := other.Get(KeyGitHubToken); err == nil {
+		t.Error("Get with another key succeeded, want a decryption error")
+	}
+}
+
+func TestKeyringStore(t *testing.T) {
+	keyring.MockInit()
+	store := NewKeyringStore()
+	if !store.Available() {
+		t.Fatal("mock keyring is not available")
+	}
+	testStore(t, store)
+}
+
+func TestDefaultUsesKeyring(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	if _, ok := store.(*KeyringStore); !ok {
+		t.Errorf("Default = %s, want the OS keyring", store.Name())
+	}
+}
+
+func TestDefaultFallsBackToEncryptedFile(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no Secret Service"))
+	t.Cleanup(keyring.MockInit)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	store, err := Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	if _, ok := store.(*EncryptedFileStore); !ok {
+		t.Fatalf("Default = %s, want the encrypted file", store.Name())
+	}
+	if err := store.Set(KeyMesheryToken, "token"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	assertPrivate(t, filepath.Join(home, ".meshery", "kubectl-kanvas-snapshot", "credentials.enc"))
+}
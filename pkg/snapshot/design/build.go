@@ -0,0 +1,58 @@
+package design
+
+import (
+	"crypto/sha1"
+	"fmt"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema versions of designs built from manifests
+const (
+	DesignSchemaVersion    = "designs.meshery.io/v1beta1"
+	ComponentSchemaVersion = "components.meshery.io/v1beta1"
+)
+
+// FromResources builds a v1beta1 design with one Kubernetes component per resource.
+// Component IDs are derived from the resource identity so they stay the same when
+// the design is rebuilt from edited manifests.
+func FromResources(id, name string, resources []manifest.Resource) *Design {
+	d := &Design{
+		ID:            id,
+		Name:          name,
+		SchemaVersion: DesignSchemaVersion,
+		Version:       "0.0.1",
+		Components:    make([]Component, 0, len(resources)),
+	}
+
+	for _, r := range resources {
+		d.Components = append(d.Components, Component{
+			ID:            stableID(r.APIVersion, r.Kind, r.Namespace, r.Name),
+			SchemaVersion: ComponentSchemaVersion,
+			DisplayName:   r.Name,
+			Component:     ComponentKind{Kind: r.Kind, Version: r.APIVersion},
+			Model:         ComponentModel{Name: "kubernetes"},
+			Configuration: resourceConfiguration(r),
+		})
+	}
+	return d
+}
+
+// Marshal renders the design as YAML
+func (d *Design) Marshal() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+// stableID returns a name-based (version 5 style) UUID for the given parts
+func stableID(parts ...string) string {
+	h := sha1.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	b := h.Sum(nil)[:16]
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
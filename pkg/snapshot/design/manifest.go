@@ -0,0 +1,161 @@
+package design
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest represents a single Kubernetes resource extracted from a design
+type Manifest struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	Object     map[string]interface{}
+}
+
+// Design represents the subset of a Meshery design file needed for export
+type Design struct {
+	ID            string                 `yaml:"id,omitempty"`
+	Name          string                 `yaml:"name"`
+	SchemaVersion string                 `yaml:"schemaVersion,omitempty"`
+	Version       string                 `yaml:"version,omitempty"`
+	Components    []Component            `yaml:"components"`
+	Services      map[string]LegacyEntry `yaml:"services,omitempty"`
+}
+
+// Component represents a v1beta1 design component
+type Component struct {
+	ID            string                 `yaml:"id"`
+	SchemaVersion string                 `yaml:"schemaVersion,omitempty"`
+	DisplayName   string                 `yaml:"displayName"`
+	Component     ComponentKind          `yaml:"component"`
+	Model         ComponentModel         `yaml:"model"`
+	Configuration map[string]interface{} `yaml:"configuration"`
+	// Styles overrides how Kanvas draws the component, e.g. its border color
+	Styles map[string]interface{} `yaml:"styles,omitempty"`
+}
+
+// ComponentKind identifies the Kubernetes kind and API version of a component
+type ComponentKind struct {
+	Kind    string `yaml:"kind"`
+	Version string `yaml:"version"`
+}
+
+// ComponentModel identifies the model a component belongs to
+type ComponentModel struct {
+	Name string `yaml:"name"`
+}
+
+// LegacyEntry represents a service entry in the v1alpha2 design format
+type LegacyEntry struct {
+	Name       string                 `yaml:"name"`
+	Type       string                 `yaml:"type"`
+	APIVersion string                 `yaml:"apiVersion"`
+	Namespace  string                 `yaml:"namespace"`
+	Model      string                 `yaml:"model"`
+	Settings   map[string]interface{} `yaml:"settings"`
+}
+
+// Parse parses a Meshery design file
+func Parse(patternFile []byte) (*Design, error) {
+	d := &Design{}
+	if err := yaml.Unmarshal(patternFile, d); err != nil {
+		return nil, fmt.Errorf("error parsing design file: %w", err)
+	}
+	return d, nil
+}
+
+// ToManifests converts the Kubernetes components of a design into manifests.
+// Components from non-Kubernetes models (comments, shapes, etc.) are skipped.
+func (d *Design) ToManifests() []Manifest {
+	var manifests []Manifest
+
+	for _, c := range d.Components {
+		if !isKubernetesModel(c.Model.Name) || c.Component.Kind == "" || c.Component.Version == "" {
+			continue
+		}
+		manifests = append(manifests, newManifest(c.Component.Version, c.Component.Kind, c.DisplayName, "", c.Configuration))
+	}
+
+	// Sort legacy service keys so the output order is stable
+	keys := make([]string, 0, len(d.Services))
+	for k := range d.Services {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := d.Services[k]
+		if !isKubernetesModel(s.Model) || s.Type == "" || s.APIVersion == "" {
+			continue
+		}
+		name := s.Name
+		if name == "" {
+			name = k
+		}
+		manifests = append(manifests, newManifest(s.APIVersion, s.Type, name, s.Namespace, s.Settings))
+	}
+
+	return manifests
+}
+
+// isKubernetesModel reports whether components of the model map to Kubernetes resources
+func isKubernetesModel(model string) bool {
+	return !strings.HasPrefix(model, "meshery-")
+}
+
+// newManifest builds a Kubernetes object from a component's configuration
+func newManifest(apiVersion, kind, name, namespace string, configuration map[string]interface{}) Manifest {
+	obj := make(map[string]interface{}, len(configuration)+2)
+	for k, v := range configuration {
+		obj[k] = v
+	}
+	obj["apiVersion"] = apiVersion
+	obj["kind"] = kind
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if n, ok := metadata["name"].(string); ok && n != "" {
+		name = n
+	} else if name != "" {
+		metadata["name"] = name
+	}
+	if ns, ok := metadata["namespace"].(string); ok && ns != "" {
+		namespace = ns
+	} else if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	obj["metadata"] = metadata
+
+	return Manifest{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       name,
+		Namespace:  namespace,
+		Object:     obj,
+	}
+}
+
+// Marshal serializes the manifest as YAML with apiVersion, kind and metadata first
+func (m Manifest) Marshal() ([]byte, error) {
+	return manifest.MarshalObject(m.Object)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9.\-]+`)
+
+// FileName returns a file name for the manifest in the form <kind>-<name>.yaml
+func (m Manifest) FileName() string {
+	return SafeFileName(fmt.Sprintf("%s-%s", m.Kind, m.Name))
+}
+
+// SafeFileName turns an arbitrary name into a lowercase YAML file name
+func SafeFileName(name string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(name), "-"), "-") + ".yaml"
+}
@@ -0,0 +1,125 @@
+package design
+
+import (
+	"fmt"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// Update applies the resources to an existing design file and returns the new design file.
+// Kubernetes components are matched to resources by apiVersion, kind, namespace and name:
+// matched components get the resource's configuration and keep everything else, such as
+// their ID, model and position; resources without a component are added and components
+// without a resource are removed. Other components, like comments and shapes, are kept.
+func Update(patternFile []byte, resources []manifest.Resource) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(patternFile, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing design file: %w", err)
+	}
+	existing, _ := doc["components"].([]interface{})
+
+	byIdentity := make(map[string]map[string]interface{}, len(existing))
+	models := make(map[string]interface{})
+	for _, c := range existing {
+		comp, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if key, apiVersion, ok := componentIdentity(comp); ok {
+			byIdentity[key] = comp
+			if _, seen := models[apiVersion]; !seen {
+				models[apiVersion] = comp["model"]
+			}
+		}
+	}
+
+	matched := make(map[string]bool, len(resources))
+	var added []interface{}
+	for _, r := range resources {
+		key := identity(r.APIVersion, r.Kind, r.Namespace, r.Name)
+		if comp, ok := byIdentity[key]; ok && !matched[key] {
+			comp["configuration"] = resourceConfiguration(r)
+			matched[key] = true
+			continue
+		}
+		comp, err := newComponentMap(r, models[r.APIVersion])
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, comp)
+	}
+
+	components := make([]interface{}, 0, len(existing)+len(added))
+	for _, c := range existing {
+		comp, ok := c.(map[string]interface{})
+		if !ok {
+			components = append(components, c)
+			continue
+		}
+		if key, _, ok := componentIdentity(comp); ok && !matched[key] {
+			continue
+		}
+		components = append(components, c)
+	}
+	doc["components"] = append(components, added...)
+
+	return yaml.Marshal(doc)
+}
+
+// componentIdentity returns the resource identity of a Kubernetes component and its apiVersion
+func componentIdentity(comp map[string]interface{}) (string, string, bool) {
+	model, _ := comp["model"].(map[string]interface{})
+	modelName, _ := model["name"].(string)
+	kind, _ := comp["component"].(map[string]interface{})
+	k, _ := kind["kind"].(string)
+	apiVersion, _ := kind["version"].(string)
+	if !isKubernetesModel(modelName) || k == "" || apiVersion == "" {
+		return "", "", false
+	}
+
+	name, _ := comp["displayName"].(string)
+	namespace := ""
+	configuration, _ := comp["configuration"].(map[string]interface{})
+	metadata, _ := configuration["metadata"].(map[string]interface{})
+	if n, ok := metadata["name"].(string); ok && n != "" {
+		name = n
+	}
+	if ns, ok := metadata["namespace"].(string); ok {
+		namespace = ns
+	}
+	return identity(apiVersion, k, namespace, name), apiVersion, true
+}
+
+// identity returns the key components and resources are matched by
+func identity(apiVersion, kind, namespace, name string) string {
+	return apiVersion + "\x00" + kind + "\x00" + namespace + "\x00" + name
+}
+
+// resourceConfiguration returns the resource without apiVersion and kind, which the component holds
+func resourceConfiguration(r manifest.Resource) map[string]interface{} {
+	configuration := make(map[string]interface{}, len(r.Object))
+	for k, v := range r.Object {
+		if k != "apiVersion" && k != "kind" {
+			configuration[k] = v
+		}
+	}
+	return configuration
+}
+
+// newComponentMap builds a component for a new resource, using the model of existing
+// components with the same apiVersion when there is one
+func newComponentMap(r manifest.Resource, model interface{}) (map[string]interface{}, error) {
+	out, err := yaml.Marshal(FromResources("", "", []manifest.Resource{r}).Components[0])
+	if err != nil {
+		return nil, err
+	}
+	comp := map[string]interface{}{}
+	if err := yaml.Unmarshal(out, &comp); err != nil {
+		return nil, err
+	}
+	if model != nil {
+		comp["model"] = model
+	}
+	return comp, nil
+}
@@ -0,0 +1,97 @@
+package design
+
+import (
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// importedDesign is a design as saved by Meshery after importing two manifests and
+// adding a comment in Kanvas
+const importedDesign = `
+id: 9b0e5c1a-0000-4000-8000-000000000001
+name: shop
+schemaVersion: designs.meshery.io/v1beta1
+components:
+  - id: server-id-web
+    displayName: web
+    component: {kind: Deployment, version: apps/v1}
+    model: {name: kubernetes, version: v1.30.0, registrant: {kind: github}}
+    styles: {position: {x: 120, y: 40}}
+    configuration:
+      metadata: {name: web, namespace: shop}
+      spec: {replicas: 1}
+  - id: server-id-db
+    displayName: db
+    component: {kind: Service, version: v1}
+    model: {name: kubernetes, version: v1.30.0}
+    configuration:
+      metadata: {name: db, namespace: shop}
+  - id: server-id-note
+    displayName: note
+    component: {kind: Comment, version: core.meshery.io/v1alpha1}
+    model: {name: meshery-core}
+    configuration: {text: keep me}
+relationships:
+  - id: rel-1
+`
+
+func resource(apiVersion, kind, namespace, name string, spec map[string]interface{}) manifest.Resource {
+	obj := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+	}
+	if spec != nil {
+		obj["spec"] = spec
+	}
+	return manifest.Resource{APIVersion: apiVersion, Kind: kind, Namespace: namespace, Name: name, Object: obj}
+}
+
+func TestUpdate(t *testing.T) {
+	resources := []manifest.Resource{
+		resource("apps/v1", "Deployment", "shop", "web", map[string]interface{}{"replicas": 3}),
+		resource("apps/v1", "Deployment", "shop", "worker", nil),
+	}
+
+	out, err := Update([]byte(importedDesign), resources)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["id"] != "9b0e5c1a-0000-4000-8000-000000000001" || doc["relationships"] == nil {
+		t.Errorf("design fields not kept: %v", doc)
+	}
+
+	components := map[string]map[string]interface{}{}
+	for _, c := range doc["components"].([]interface{}) {
+		comp := c.(map[string]interface{})
+		components[comp["displayName"].(string)] = comp
+	}
+	if len(components) != 3 {
+		t.Fatalf("got components %v, want web, worker and note", components)
+	}
+
+	web := components["web"]
+	if web["id"] != "server-id-web" || web["styles"] == nil {
+		t.Errorf("web lost its ID or layout: %v", web)
+	}
+	if replicas := web["configuration"].(map[string]interface{})["spec"].(map[string]interface{})["replicas"]; replicas != 3 {
+		t.Errorf("web replicas = %v, want 3", replicas)
+	}
+
+	worker := components["worker"]
+	if worker["id"] == "" || worker["model"].(map[string]interface{})["version"] != "v1.30.0" {
+		t.Errorf("worker = %v, want a new component with the existing apps/v1 model", worker)
+	}
+	if _, ok := components["db"]; ok {
+		t.Error("db was removed from the manifests but kept in the design")
+	}
+	if _, ok := components["note"]; !ok {
+		t.Error("the comment was removed")
+	}
+}
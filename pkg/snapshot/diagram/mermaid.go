@@ -0,0 +1,112 @@
+// Package diagram renders resources and their relationships as Mermaid flowcharts,
+// which GitHub, GitLab and most Markdown viewers display as diagrams.
+package diagram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node is a box in the diagram
+type Node struct {
+	// ID identifies the node in edges and must be unique
+	ID    string
+	Label string
+	// Group draws the node inside a box with this title, e.g. its namespace
+	Group string
+	// Class is the name of a style defined in Graph.Classes
+	Class string
+}
+
+// Edge is an arrow between two nodes
+type Edge struct {
+	From  string
+	To    string
+	Label string
+	// Dashed draws a dotted arrow
+	Dashed bool
+	// Style is a Mermaid link style, e.g. stroke:#cf222e
+	Style string
+}
+
+// Graph is a left-to-right flowchart
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+	// Classes maps class names to Mermaid styles, e.g. fill:#dafbe1,stroke:#2da44e
+	Classes map[string]string
+}
+
+// Mermaid returns the Mermaid source of the graph. Groups are drawn in the order
+// their first node appears, and nodes without a group after them.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	classes := make([]string, 0, len(g.Classes))
+	for name := range g.Classes {
+		classes = append(classes, name)
+	}
+	sort.Strings(classes)
+	for _, name := range classes {
+		fmt.Fprintf(&b, "  classDef %s %s\n", name, g.Classes[name])
+	}
+
+	var groups []string
+	members := make(map[string][]Node)
+	for _, n := range g.Nodes {
+		if _, ok := members[n.Group]; !ok && n.Group != "" {
+			groups = append(groups, n.Group)
+		}
+		members[n.Group] = append(members[n.Group], n)
+	}
+	for i, group := range groups {
+		fmt.Fprintf(&b, "  subgraph group%d[\"%s\"]\n", i, escape(group))
+		for _, n := range members[group] {
+			writeNode(&b, "    ", n)
+		}
+		b.WriteString("  end\n")
+	}
+	for _, n := range members[""] {
+		writeNode(&b, "  ", n)
+	}
+
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Dashed {
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			fmt.Fprintf(&b, "  %s %s|%s| %s\n", e.From, arrow, escape(e.Label), e.To)
+		} else {
+			fmt.Fprintf(&b, "  %s %s %s\n", e.From, arrow, e.To)
+		}
+	}
+	// Links are styled by their position among the edges
+	for i, e := range g.Edges {
+		if e.Style != "" {
+			fmt.Fprintf(&b, "  linkStyle %d %s\n", i, e.Style)
+		}
+	}
+	return b.String()
+}
+
+// Markdown returns the graph as a fenced Mermaid code block
+func (g *Graph) Markdown() string {
+	return "```mermaid\n" + g.Mermaid() + "```\n"
+}
+
+func writeNode(b *strings.Builder, indent string, n Node) {
+	fmt.Fprintf(b, "%s%s[\"%s\"]", indent, n.ID, escape(n.Label))
+	if n.Class != "" {
+		fmt.Fprintf(b, ":::%s", n.Class)
+	}
+	b.WriteString("\n")
+}
+
+// escape replaces the characters that end a Mermaid label with entity codes.
+// Line breaks become <br/>.
+func escape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "\n", "<br/>").Replace(s)
+}
@@ -0,0 +1,251 @@
+// Package diff compares two sets of Kubernetes resources and the relationships inferred between them.
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// Change status of a resource or relationship
+const (
+	StatusAdded     = "added"
+	StatusRemoved   = "removed"
+	StatusChanged   = "changed"
+	StatusUnchanged = "unchanged"
+)
+
+// ignoredFields are set by the API server rather than by the manifest author, so they
+// do not count as changes when comparing, e.g., manifests exported from a cluster
+var ignoredFields = map[string]bool{
+	"status":                     true,
+	"metadata.uid":               true,
+	"metadata.resourceVersion":   true,
+	"metadata.generation":        true,
+	"metadata.creationTimestamp": true,
+	"metadata.managedFields":     true,
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration": true,
+}
+
+// ResourceChange is a resource present in either set with its change status
+type ResourceChange struct {
+	Ref       string `json:"ref" yaml:"ref"`
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Status    string `json:"status" yaml:"status"`
+	// Fields are the paths of the fields that differ, for changed resources
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty"`
+	// Resource is the new version, or the old one for removed resources
+	Resource manifest.Resource `json:"-" yaml:"-"`
+}
+
+// RelationshipChange is a relationship inferred in either set with its change status.
+// From and To are resource references.
+type RelationshipChange struct {
+	From   string `json:"from" yaml:"from"`
+	To     string `json:"to" yaml:"to"`
+	Type   string `json:"type" yaml:"type"`
+	Status string `json:"status" yaml:"status"`
+}
+
+// Counts is the number of resources or relationships with each status
+type Counts struct {
+	Added     int `json:"added" yaml:"added"`
+	Removed   int `json:"removed" yaml:"removed"`
+	Changed   int `json:"changed" yaml:"changed"`
+	Unchanged int `json:"unchanged" yaml:"unchanged"`
+}
+
+// Result is the comparison of two sets of resources. Resources are listed in the order
+// of the new set, followed by the removed resources in the order of the old set.
+type Result struct {
+	Resources     []ResourceChange
+	Relationships []RelationshipChange
+}
+
+// Compare compares the old and new resources. Resources are matched by kind, namespace
+// and name; if a set defines a resource more than once, it is listed once, at its first
+// position, with its last definition.
+func Compare(oldResources, newResources []manifest.Resource) *Result {
+	oldRefs := lastByRef(oldResources)
+	newRefs := lastByRef(newResources)
+	result := &Result{}
+
+	listed := make(map[string]bool, len(newRefs))
+	for _, r := range newResources {
+		ref := r.Ref()
+		if listed[ref] {
+			continue
+		}
+		listed[ref] = true
+		r = newRefs[ref]
+		change := ResourceChange{Ref: ref, Kind: r.Kind, Namespace: r.EffectiveNamespace(), Name: r.Name, Resource: r}
+		if old, ok := oldRefs[ref]; !ok {
+			change.Status = StatusAdded
+		} else if change.Fields = changedFields(old.Object, r.Object); len(change.Fields) > 0 {
+			change.Status = StatusChanged
+		} else {
+			change.Status = StatusUnchanged
+		}
+		result.Resources = append(result.Resources, change)
+	}
+	for _, r := range oldResources {
+		ref := r.Ref()
+		if listed[ref] {
+			continue
+		}
+		listed[ref] = true
+		r = oldRefs[ref]
+		result.Resources = append(result.Resources, ResourceChange{Ref: ref, Kind: r.Kind, Namespace: r.EffectiveNamespace(), Name: r.Name, Status: StatusRemoved, Resource: r})
+	}
+
+	oldRels := relationshipKeys(oldResources)
+	newRels := relationshipKeys(newResources)
+	for _, rel := range newRels.ordered {
+		status := StatusUnchanged
+		if !oldRels.set[rel] {
+			status = StatusAdded
+		}
+		result.Relationships = append(result.Relationships, RelationshipChange{From: rel.from, To: rel.to, Type: rel.relType, Status: status})
+	}
+	for _, rel := range oldRels.ordered {
+		if !newRels.set[rel] {
+			result.Relationships = append(result.Relationships, RelationshipChange{From: rel.from, To: rel.to, Type: rel.relType, Status: StatusRemoved})
+		}
+	}
+	return result
+}
+
+// ResourceCounts returns the number of resources with each status
+func (r *Result) ResourceCounts() Counts {
+	var c Counts
+	for _, change := range r.Resources {
+		c.add(change.Status)
+	}
+	return c
+}
+
+// RelationshipCounts returns the number of relationships with each status
+func (r *Result) RelationshipCounts() Counts {
+	var c Counts
+	for _, change := range r.Relationships {
+		c.add(change.Status)
+	}
+	return c
+}
+
+// Empty reports whether nothing was added, removed or changed
+func (r *Result) Empty() bool {
+	res, rel := r.ResourceCounts(), r.RelationshipCounts()
+	return res.Added+res.Removed+res.Changed+rel.Added+rel.Removed == 0
+}
+
+func (c *Counts) add(status string) {
+	switch status {
+	case StatusAdded:
+		c.Added++
+	case StatusRemoved:
+		c.Removed++
+	case StatusChanged:
+		c.Changed++
+	default:
+		c.Unchanged++
+	}
+}
+
+// lastByRef returns the last definition of each resource by reference
+func lastByRef(resources []manifest.Resource) map[string]manifest.Resource {
+	refs := make(map[string]manifest.Resource, len(resources))
+	for _, r := range resources {
+		refs[r.Ref()] = r
+	}
+	return refs
+}
+
+type relationshipKey struct {
+	from, to, relType string
+}
+
+type relationshipSet struct {
+	ordered []relationshipKey
+	set     map[relationshipKey]bool
+}
+
+// relationshipKeys returns the relationships inferred between the resources by reference,
+// so relationships of the old and new sets can be matched
+func relationshipKeys(resources []manifest.Resource) relationshipSet {
+	s := relationshipSet{set: make(map[relationshipKey]bool)}
+	for _, rel := range manifest.InferRelationships(resources) {
+		key := relationshipKey{from: resources[rel.From].Ref(), to: resources[rel.To].Ref(), relType: rel.Type}
+		if !s.set[key] {
+			s.set[key] = true
+			s.ordered = append(s.ordered, key)
+		}
+	}
+	return s
+}
+
+// changedFields returns the sorted paths of the fields that differ between two objects,
+// e.g. spec.template.spec.containers[0].image
+func changedFields(oldObj, newObj map[string]interface{}) []string {
+	var fields []string
+	compareValues("", oldObj, newObj, &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func compareValues(path string, oldValue, newValue interface{}, fields *[]string) {
+	if ignoredFields[path] {
+		return
+	}
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make(map[string]bool, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		for k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			compareValues(child, oldMap[k], newMap[k], fields)
+		}
+		return
+	}
+
+	// A map that is only set on one side is changed unless it only holds ignored fields,
+	// e.g. annotations holding just the last applied configuration
+	if (oldIsMap && newValue == nil) || (newIsMap && oldValue == nil) {
+		var children []string
+		if oldIsMap {
+			compareValues(path, oldMap, map[string]interface{}{}, &children)
+		} else {
+			compareValues(path, map[string]interface{}{}, newMap, &children)
+		}
+		if len(children) > 0 {
+			*fields = append(*fields, path)
+		}
+		return
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList && len(oldList) == len(newList) {
+		for i := range oldList {
+			compareValues(fmt.Sprintf("%s[%d]", path, i), oldList[i], newList[i], fields)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(oldValue, newValue) {
+		*fields = append(*fields, path)
+	}
+}
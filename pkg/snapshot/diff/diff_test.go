@@ -0,0 +1,267 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// parse parses a multi-document manifest
+func parse(t *testing.T, data string) []manifest.Resource {
+	t.Helper()
+	resources, err := manifest.Parse("test.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resources
+}
+
+const baseManifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+data: {mode: fast}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {matchLabels: {app: web}}
+  template:
+    metadata: {labels: {app: web}}
+    spec:
+      containers:
+        - name: web
+          image: web:1
+          ports: [{containerPort: 8080}]
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {app: web}
+  ports: [{port: 80}]
+`
+
+func statuses(r *Result) map[string]string {
+	out := make(map[string]string, len(r.Resources))
+	for _, c := range r.Resources {
+		out[c.Ref] = c.Status
+	}
+	return out
+}
+
+func TestCompareResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []ResourceChange
+	}{
+		{
+			name: "unchanged",
+			old:  baseManifests,
+			new:  baseManifests,
+			want: []ResourceChange{
+				{Ref: "ConfigMap/default/settings", Status: StatusUnchanged},
+				{Ref: "Deployment/shop/web", Status: StatusUnchanged},
+				{Ref: "Service/shop/web", Status: StatusUnchanged},
+			},
+		},
+		{
+			name: "added, removed and changed",
+			old:  baseManifests,
+			new: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: shop}
+spec:
+  replicas: 2
+  selector: {matchLabels: {app: web}}
+  template:
+    metadata: {labels: {app: web}}
+    spec:
+      containers:
+        - name: web
+          image: web:2
+          ports: [{containerPort: 8080}]
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {app: web}
+  ports: [{port: 80}]
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: token, namespace: shop}
+`,
+			want: []ResourceChange{
+				{Ref: "Deployment/shop/web", Status: StatusChanged, Fields: []string{"spec.replicas", "spec.template.spec.containers[0].image"}},
+				{Ref: "Service/shop/web", Status: StatusUnchanged},
+				{Ref: "Secret/shop/token", Status: StatusAdded},
+				{Ref: "ConfigMap/default/settings", Status: StatusRemoved},
+			},
+		},
+		{
+			name: "duplicated refs",
+			old: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a}
+data: {v: "1"}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: b}
+`,
+			new: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a}
+data: {v: "0"}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: b}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a}
+data: {v: "1"}
+`,
+			// a is listed once, at its first position, with its last definition
+			want: []ResourceChange{
+				{Ref: "ConfigMap/default/a", Status: StatusUnchanged},
+				{Ref: "ConfigMap/default/b", Status: StatusUnchanged},
+			},
+		},
+		{
+			name: "ignored server fields",
+			old: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a}
+data: {v: "1"}
+`,
+			new: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  uid: 0d5f
+  resourceVersion: "812"
+  generation: 3
+  creationTimestamp: "2026-01-01T00:00:00Z"
+  managedFields: [{manager: kubectl}]
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+data: {v: "1"}
+status: {phase: Active}
+`,
+			want: []ResourceChange{{Ref: "ConfigMap/default/a", Status: StatusUnchanged}},
+		},
+		{
+			name: "list length changes",
+			old: `
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec:
+  ports: [{port: 80}]
+`,
+			new: `
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec:
+  ports: [{port: 80}, {port: 443}]
+`,
+			want: []ResourceChange{{Ref: "Service/default/web", Status: StatusChanged, Fields: []string{"spec.ports"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Compare(parse(t, tt.old), parse(t, tt.new))
+			if len(result.Resources) != len(tt.want) {
+				t.Fatalf("got %d resources %v, want %d", len(result.Resources), statuses(result), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := result.Resources[i]
+				if got.Ref != want.Ref || got.Status != want.Status || !reflect.DeepEqual(got.Fields, want.Fields) {
+					t.Errorf("resource %d = %s %s %v, want %s %s %v", i, got.Ref, got.Status, got.Fields, want.Ref, want.Status, want.Fields)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareDuplicateKeepsLastDefinition(t *testing.T) {
+	newResources := parse(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a}
+data: {v: first}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a}
+data: {v: last}
+`)
+	result := Compare(nil, newResources)
+	if len(result.Resources) != 1 {
+		t.Fatalf("got %d resources, want 1", len(result.Resources))
+	}
+	if v := result.Resources[0].Resource.Object["data"].(map[string]interface{})["v"]; v != "last" {
+		t.Errorf("resource holds %v, want the last definition", v)
+	}
+}
+
+func TestCompareRelationships(t *testing.T) {
+	oldResources := parse(t, baseManifests)
+	// The Service no longer selects the Deployment, and an HPA now scales it
+	newResources := parse(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {matchLabels: {app: web}}
+  template:
+    metadata: {labels: {app: web}}
+    spec:
+      containers: [{name: web, image: web:1, ports: [{containerPort: 8080}]}]
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {app: api}
+  ports: [{port: 80}]
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata: {name: web, namespace: shop}
+spec:
+  scaleTargetRef: {apiVersion: apps/v1, kind: Deployment, name: web}
+`)
+
+	result := Compare(oldResources, newResources)
+	want := []RelationshipChange{
+		{From: "HorizontalPodAutoscaler/shop/web", To: "Deployment/shop/web", Type: manifest.RelScale, Status: StatusAdded},
+		{From: "Service/shop/web", To: "Deployment/shop/web", Type: manifest.RelNetwork, Status: StatusRemoved},
+	}
+	if !reflect.DeepEqual(result.Relationships, want) {
+		t.Errorf("relationships = %+v, want %+v", result.Relationships, want)
+	}
+	if counts := result.RelationshipCounts(); counts != (Counts{Added: 1, Removed: 1}) {
+		t.Errorf("counts = %+v", counts)
+	}
+	if result.Empty() {
+		t.Error("Empty = true with changed relationships")
+	}
+	if !Compare(oldResources, oldResources).Empty() {
+		t.Error("Empty = false comparing a set with itself")
+	}
+}
@@ -2,6 +2,8 @@ package errors
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/layer5io/meshkit/errors"
 )
@@ -21,6 +23,62 @@ var (
 	ErrGeneratingSnapshotCode = "kubectl-kanvas-snapshot-1006"
 	// ErrReadingManifestFileCode represents manifest file reading failures
 	ErrReadingManifestFileCode = "kubectl-kanvas-snapshot-1007"
+	// ErrFetchingDesignCode represents Meshery design retrieval failures
+	ErrFetchingDesignCode = "kubectl-kanvas-snapshot-1008"
+	// ErrExportingDesignCode represents design to manifest conversion failures
+	ErrExportingDesignCode = "kubectl-kanvas-snapshot-1009"
+	// ErrAuthenticationFailedCode represents rejected Meshery credentials
+	ErrAuthenticationFailedCode = "kubectl-kanvas-snapshot-1010"
+	// ErrTokenExpiredCode represents an expired Meshery token
+	ErrTokenExpiredCode = "kubectl-kanvas-snapshot-1011"
+	// ErrInvalidTokenCode represents a Meshery token the server does not accept
+	ErrInvalidTokenCode = "kubectl-kanvas-snapshot-1012"
+	// ErrWrongProviderCode represents a token issued by a different provider than the server uses
+	ErrWrongProviderCode = "kubectl-kanvas-snapshot-1013"
+	// ErrInsufficientPermissionsCode represents a valid token lacking the required permissions
+	ErrInsufficientPermissionsCode = "kubectl-kanvas-snapshot-1014"
+	// ErrLoginCode represents login flow failures
+	ErrLoginCode = "kubectl-kanvas-snapshot-1015"
+	// ErrCredentialsCode represents failures reading or writing stored credentials
+	ErrCredentialsCode = "kubectl-kanvas-snapshot-1016"
+	// ErrInvalidConfigCode represents invalid or unusable configuration
+	ErrInvalidConfigCode = "kubectl-kanvas-snapshot-1017"
+	// ErrHTTPClientConfigCode represents invalid proxy or TLS settings
+	ErrHTTPClientConfigCode = "kubectl-kanvas-snapshot-1018"
+	// ErrInvalidSplitCode represents an invalid --split-by value
+	ErrInvalidSplitCode = "kubectl-kanvas-snapshot-1019"
+	// ErrWatchManifestsCode represents failures watching manifest files for changes
+	ErrWatchManifestsCode = "kubectl-kanvas-snapshot-1020"
+	// ErrUpdatingMesheryDesignCode represents failures saving changes to an existing Meshery design
+	ErrUpdatingMesheryDesignCode = "kubectl-kanvas-snapshot-1021"
+	// ErrHistoryCode represents failures reading or changing the run history
+	ErrHistoryCode = "kubectl-kanvas-snapshot-1022"
+	// ErrMesheryUnreachableCode represents requests that could not reach the Meshery server
+	ErrMesheryUnreachableCode = "kubectl-kanvas-snapshot-1023"
+	// ErrGitHubUnreachableCode represents workflow dispatches that could not reach GitHub
+	ErrGitHubUnreachableCode = "kubectl-kanvas-snapshot-1024"
+	// ErrSpoolCode represents failures reading or writing queued uploads
+	ErrSpoolCode = "kubectl-kanvas-snapshot-1025"
+	// ErrGitDiffCode represents failures finding the manifests changed in git
+	ErrGitDiffCode = "kubectl-kanvas-snapshot-1026"
+	// ErrDiffCode represents failures loading the manifests or designs to compare
+	ErrDiffCode = "kubectl-kanvas-snapshot-1027"
+	// ErrPRCommentCode represents failures commenting on a pull request
+	ErrPRCommentCode = "kubectl-kanvas-snapshot-1028"
+	// ErrSnapshotTimeoutCode represents snapshots not rendered within --wait-timeout
+	ErrSnapshotTimeoutCode = "kubectl-kanvas-snapshot-1029"
+	// ErrWritingReportCode represents failures writing the --report file
+	ErrWritingReportCode = "kubectl-kanvas-snapshot-1030"
+	// ErrInvalidManifestsCode represents manifests that do not match their schemas
+	ErrInvalidManifestsCode = "kubectl-kanvas-snapshot-1031"
+	// ErrLoadingSchemasCode represents an unsupported --kube-version or unreadable --crd files
+	ErrLoadingSchemasCode = "kubectl-kanvas-snapshot-1032"
+	// ErrDuplicateResourcesCode represents resources defined more than once with --on-duplicate=error
+	ErrDuplicateResourcesCode = "kubectl-kanvas-snapshot-1033"
+	// ErrInvalidFlagsCode represents invalid flag values or combinations
+	ErrInvalidFlagsCode = "kubectl-kanvas-snapshot-1034"
+	// ErrDesignNotFoundCode represents a design ID that Meshery does not know
+	ErrDesignNotFoundCode = "kubectl-kanvas-snapshot-1035"
 )
 
 // ErrDecodingAPI returns error for API decoding failures
@@ -109,3 +167,370 @@ func ErrReadingManifestFile(err error) error {
 		"Verify the path to the manifest file is correct",
 	}, []string{})
 }
+
+// ErrFetchingDesign returns error for Meshery design retrieval failures
+func ErrFetchingDesign(designID string, err error) error {
+	return errors.New(ErrFetchingDesignCode, errors.Alert, []string{
+		fmt.Sprintf("error fetching Meshery design '%s': %v", designID, err),
+	}, []string{
+		"Failed to retrieve the design from Meshery",
+	}, []string{
+		"Verify the design ID is correct",
+		"Check if you have permissions to view the design in Meshery",
+		"Ensure Meshery API server is running and accessible",
+	}, []string{})
+}
+
+// ErrDesignNotFound returns an error for a design that does not exist in Meshery
+func ErrDesignNotFound(designID string) error {
+	return errors.New(ErrDesignNotFoundCode, errors.Alert, []string{
+		fmt.Sprintf("Meshery design '%s' not found", designID),
+	}, []string{
+		"The design does not exist in Meshery or was deleted",
+	}, []string{
+		"Verify the design ID is correct",
+		"Check that the design was created on the Meshery server in use",
+	}, []string{})
+}
+
+// IsDesignNotFound reports whether err is a design Meshery does not know
+func IsDesignNotFound(err error) bool {
+	e, ok := asError(err)
+	return ok && e.Code == ErrDesignNotFoundCode
+}
+
+// ErrExportingDesign returns error for design to manifest conversion failures
+func ErrExportingDesign(err error) error {
+	return errors.New(ErrExportingDesignCode, errors.Alert, []string{
+		fmt.Sprintf("error exporting design as Kubernetes manifests: %v", err),
+	}, []string{
+		"Failed to convert the design or write the resulting manifests",
+	}, []string{
+		"Ensure the output directory is writable",
+		"Verify the design contains Kubernetes components",
+	}, []string{})
+}
+
+// ErrAuthenticationFailed returns error for requests made without Meshery credentials
+func ErrAuthenticationFailed(err error) error {
+	return errors.New(ErrAuthenticationFailedCode, errors.Alert, []string{
+		fmt.Sprintf("authentication with Meshery failed: %v", err),
+	}, []string{
+		"No Meshery token was provided and the server requires authentication",
+	}, []string{
+		"Set MESHERY_TOKEN or pass --meshery-token",
+		"Obtain a token from your Meshery or Meshery Cloud profile",
+	}, []string{})
+}
+
+// ErrTokenExpired returns error for an expired Meshery token
+func ErrTokenExpired(err error) error {
+	return errors.New(ErrTokenExpiredCode, errors.Alert, []string{
+		fmt.Sprintf("Meshery token has expired: %v", err),
+	}, []string{
+		"The token's validity period has ended",
+	}, []string{
+		"Obtain a new token from your Meshery or Meshery Cloud profile",
+		"Update MESHERY_TOKEN or --meshery-token with the new token",
+	}, []string{})
+}
+
+// ErrInvalidToken returns error for a Meshery token the server does not accept
+func ErrInvalidToken(err error) error {
+	return errors.New(ErrInvalidTokenCode, errors.Alert, []string{
+		fmt.Sprintf("Meshery token is invalid: %v", err),
+	}, []string{
+		"The token is malformed, was revoked or was issued for a different Meshery server",
+	}, []string{
+		"Verify the token was copied completely, without surrounding quotes or whitespace",
+		"Ensure the token belongs to the Meshery server set by --meshery-url",
+		"Obtain a new token from your Meshery or Meshery Cloud profile",
+	}, []string{})
+}
+
+// ErrWrongProvider returns error for a token issued by a different provider than the server uses
+func ErrWrongProvider(err error) error {
+	return errors.New(ErrWrongProviderCode, errors.Alert, []string{
+		fmt.Sprintf("Meshery provider mismatch: %v", err),
+	}, []string{
+		"The Meshery server redirected to provider selection, so it does not recognize the token's provider",
+	}, []string{
+		"Ensure the Meshery server is configured to use the Meshery remote provider",
+		"Obtain a token from the provider the Meshery server is using",
+	}, []string{})
+}
+
+// ErrInsufficientPermissions returns error for a valid token lacking the required permissions
+func ErrInsufficientPermissions(err error) error {
+	return errors.New(ErrInsufficientPermissionsCode, errors.Alert, []string{
+		fmt.Sprintf("insufficient permissions: %v", err),
+	}, []string{
+		"The token is valid but its user is not allowed to perform this operation",
+	}, []string{
+		"Ask an administrator of your Meshery organization to grant design permissions",
+		"Verify you are using the token of the intended user",
+	}, []string{})
+}
+
+// ErrLogin returns error for login flow failures
+func ErrLogin(err error) error {
+	return errors.New(ErrLoginCode, errors.Alert, []string{
+		fmt.Sprintf("error logging in to Meshery: %v", err),
+	}, []string{
+		"The login flow did not complete",
+	}, []string{
+		"Complete the login in the browser window before the timeout expires",
+		"Use --no-browser and open the printed URL manually if no browser is available",
+		"Pipe an existing token to 'login --with-token' on headless machines",
+	}, []string{})
+}
+
+// ErrCredentials returns error for failures reading or writing stored credentials
+func ErrCredentials(err error) error {
+	return errors.New(ErrCredentialsCode, errors.Alert, []string{
+		fmt.Sprintf("error accessing stored credentials: %v", err),
+	}, []string{
+		"The credential store could not be read or written",
+	}, []string{
+		"Ensure the OS keyring is unlocked, or that your home directory is writable",
+		"Clear the stored credentials with 'credentials clear' and store them again",
+	}, []string{})
+}
+
+// ErrInvalidConfig returns error for invalid or unusable configuration
+func ErrInvalidConfig(err error) error {
+	return errors.New(ErrInvalidConfigCode, errors.Alert, []string{
+		fmt.Sprintf("invalid configuration: %v", err),
+	}, []string{
+		"The configuration file contains invalid settings or the selected profile does not exist",
+	}, []string{
+		"Check the configuration file for typos",
+		"List the profiles defined in the configuration file under 'profiles:'",
+	}, []string{})
+}
+
+// ErrHTTPClientConfig returns error for invalid proxy or TLS settings
+func ErrHTTPClientConfig(err error) error {
+	return errors.New(ErrHTTPClientConfigCode, errors.Alert, []string{
+		fmt.Sprintf("invalid proxy or TLS settings: %v", err),
+	}, []string{
+		"The proxy, CA bundle or client certificate settings could not be applied",
+	}, []string{
+		"Check that the proxy URL is an http, https or socks5 URL",
+		"Ensure the CA bundle and client certificate files exist and contain PEM data",
+		"Set the client certificate and the client key together",
+	}, []string{})
+}
+
+// ErrInvalidSplit returns error for an invalid --split-by value
+func ErrInvalidSplit(err error) error {
+	return errors.New(ErrInvalidSplitCode, errors.Alert, []string{
+		fmt.Sprintf("invalid --split-by value: %v", err),
+	}, []string{
+		"The manifests could not be split into groups",
+	}, []string{
+		"Use one of --split-by dir, file, namespace or label=<key>",
+	}, []string{})
+}
+
+// Details returns the description of a plugin error, which includes the underlying error,
+// or an empty string for other errors. The terminal logger only prints err.Error(), so
+// callers print the details separately.
+func Details(err error) string {
+	if e, ok := asError(err); ok {
+		return strings.Join(e.ShortDescription, ". ")
+	}
+	return ""
+}
+
+// IsUnreachable reports whether err is a request that did not reach Meshery or GitHub,
+// as opposed to one the server rejected
+func IsUnreachable(err error) bool {
+	e, ok := asError(err)
+	return ok && (e.Code == ErrMesheryUnreachableCode || e.Code == ErrGitHubUnreachableCode)
+}
+
+// ErrWatchManifests returns an error for failures watching manifest files
+func ErrWatchManifests(err error) error {
+	return errors.New(ErrWatchManifestsCode, errors.Alert, []string{
+		fmt.Sprintf("error watching manifest files: %v", err),
+	}, []string{
+		"Changes to the manifest files could not be watched",
+	}, []string{
+		"Ensure the manifest path exists and is readable",
+		"Raise the inotify watch limit (fs.inotify.max_user_watches) for large directories",
+	}, []string{})
+}
+
+// ErrUpdatingMesheryDesign returns an error for failures saving an existing design
+func ErrUpdatingMesheryDesign(designID string, err error) error {
+	return errors.New(ErrUpdatingMesheryDesignCode, errors.Alert, []string{
+		fmt.Sprintf("error updating Meshery design %s: %v", designID, err),
+	}, []string{
+		"The Meshery design could not be updated",
+	}, []string{
+		"Ensure the design still exists and your token can edit it",
+	}, []string{})
+}
+
+// ErrHistory returns an error for failures reading or changing the run history
+func ErrHistory(err error) error {
+	return errors.New(ErrHistoryCode, errors.Alert, []string{
+		fmt.Sprintf("error accessing history: %v", err),
+	}, []string{
+		"The history of created designs could not be read or changed",
+	}, []string{
+		"Ensure ~/.meshery/kubectl-kanvas-snapshot is writable",
+		"Run 'kubectl kanvas-snapshot history list' to see the valid entry numbers",
+	}, []string{})
+}
+
+// ErrMesheryUnreachable returns an error for requests that could not reach the Meshery server
+func ErrMesheryUnreachable(url string, err error) error {
+	return errors.New(ErrMesheryUnreachableCode, errors.Alert, []string{
+		fmt.Sprintf("could not reach Meshery at %s: %v", url, err),
+	}, []string{
+		"The Meshery server could not be reached",
+	}, []string{
+		"Check your network connection and that Meshery is running at the configured URL",
+		"Run 'kubectl kanvas-snapshot flush' to retry queued uploads",
+	}, []string{})
+}
+
+// ErrGitHubUnreachable returns an error for workflow dispatches that could not reach GitHub
+func ErrGitHubUnreachable(err error) error {
+	return errors.New(ErrGitHubUnreachableCode, errors.Alert, []string{
+		fmt.Sprintf("could not reach GitHub: %v", err),
+	}, []string{
+		"The snapshot workflow could not be triggered because GitHub could not be reached",
+	}, []string{
+		"Check your network connection and proxy settings",
+		"Run 'kubectl kanvas-snapshot flush' to retry queued workflow dispatches",
+	}, []string{})
+}
+
+// ErrSpool returns an error for failures reading or writing queued uploads
+func ErrSpool(err error) error {
+	return errors.New(ErrSpoolCode, errors.Alert, []string{
+		fmt.Sprintf("error accessing queued uploads: %v", err),
+	}, []string{
+		"The queue of uploads waiting for Meshery could not be read or changed",
+	}, []string{
+		"Ensure ~/.meshery/kubectl-kanvas-snapshot/spool is writable",
+		"Run 'kubectl kanvas-snapshot flush --list' to see the queued uploads",
+	}, []string{})
+}
+
+// ErrGitDiff returns an error for failures finding the manifests changed relative to a git ref
+func ErrGitDiff(err error) error {
+	return errors.New(ErrGitDiffCode, errors.Alert, []string{
+		fmt.Sprintf("error comparing manifests with git: %v", err),
+	}, []string{
+		"The manifests changed relative to the git ref could not be determined",
+	}, []string{
+		"Ensure the manifest path is inside a git repository and git is installed",
+		"Ensure the base ref exists locally, e.g. run 'git fetch origin main' in CI",
+	}, []string{})
+}
+
+// ErrDiff returns an error for failures loading one side of a comparison
+func ErrDiff(source string, err error) error {
+	return errors.New(ErrDiffCode, errors.Alert, []string{
+		fmt.Sprintf("error loading '%s' for comparison: %v", source, err),
+	}, []string{
+		"The manifests or design to compare could not be loaded",
+	}, []string{
+		"Pass two manifest paths, two git refs or two Meshery design IDs",
+		"Use --type to say which kind of argument was passed if it is ambiguous",
+	}, []string{})
+}
+
+// ErrPRComment returns an error for failures posting the snapshot comment on a pull request
+func ErrPRComment(err error) error {
+	return errors.New(ErrPRCommentCode, errors.Alert, []string{
+		fmt.Sprintf("error commenting on pull request: %v", err),
+	}, []string{
+		"The snapshot comment could not be posted on the pull request",
+	}, []string{
+		"Ensure GITHUB_TOKEN may write pull request comments (pull-requests: write in GitHub Actions)",
+		"Pass the pull request with --pr owner/repo#number if it cannot be detected",
+		"Set github.api_url or GITHUB_API_URL for GitHub Enterprise Server",
+	}, []string{})
+}
+
+// ErrSnapshotTimeout returns an error for snapshots that were not rendered in time
+func ErrSnapshotTimeout(assetLocation string, timeout time.Duration) error {
+	return errors.New(ErrSnapshotTimeoutCode, errors.Alert, []string{
+		fmt.Sprintf("snapshot %s was not available after %s", assetLocation, timeout),
+	}, []string{
+		"The snapshot workflow did not publish the snapshot image in time",
+	}, []string{
+		"Check the snapshot workflow runs for failures",
+		"Increase --wait-timeout",
+	}, []string{})
+}
+
+// ErrWritingReport returns an error for a report that could not be written
+func ErrWritingReport(path string, err error) error {
+	return errors.New(ErrWritingReportCode, errors.Alert, []string{
+		fmt.Sprintf("error writing report %s: %v", path, err),
+	}, []string{
+		"The report could not be written",
+	}, []string{
+		"Ensure the directory of the report exists and is writable",
+		"Use a .md or .html file name",
+	}, []string{})
+}
+
+// ErrInvalidManifests returns an error for manifests that failed validation. Each
+// problem is logged before, first is shown in the error.
+func ErrInvalidManifests(count int, first string) error {
+	return errors.New(ErrInvalidManifestsCode, errors.Alert, []string{
+		fmt.Sprintf("%d error(s) in the manifests, the first is %s", count, first),
+	}, []string{
+		"The manifests do not match the Kubernetes API schemas",
+	}, []string{
+		"Fix the fields reported at each file and line",
+		"Select the Kubernetes version of the cluster with --kube-version",
+		"Pass the CustomResourceDefinitions of custom resources with --crd",
+		"Skip the validation with --no-validate",
+	}, []string{})
+}
+
+// ErrLoadingSchemas returns an error for schemas that could not be loaded
+func ErrLoadingSchemas(err error) error {
+	return errors.New(ErrLoadingSchemasCode, errors.Alert, []string{
+		fmt.Sprintf("error loading schemas: %v", err),
+	}, []string{
+		"The schemas to validate the manifests with could not be loaded",
+	}, []string{
+		"Pass a Kubernetes version the schemas are bundled for with --kube-version",
+		"Ensure the --crd paths exist and contain CustomResourceDefinitions",
+	}, []string{})
+}
+
+// ErrDuplicateResources returns an error for resources defined more than once. Each
+// duplicate is logged before, first is shown in the error.
+func ErrDuplicateResources(count int, first string) error {
+	return errors.New(ErrDuplicateResourcesCode, errors.Alert, []string{
+		fmt.Sprintf("%d resource(s) are defined more than once, the first is %s", count, first),
+	}, []string{
+		"The manifests define the same resource in several places",
+	}, []string{
+		"Remove all but one definition of each resource",
+		"Pass --on-duplicate=last-wins to keep the last definition, as kubectl apply would",
+		"Pass --on-duplicate=warn to upload all definitions",
+	}, []string{})
+}
+
+// ErrInvalidFlags returns an error for an invalid flag value or combination of flags
+func ErrInvalidFlags(err error) error {
+	return errors.New(ErrInvalidFlagsCode, errors.Alert, []string{
+		fmt.Sprintf("invalid flags: %v", err),
+	}, []string{
+		"A flag has an unsupported value or cannot be combined with another flag",
+	}, []string{
+		"Run the command with --help to see the supported values",
+		"Remove one of the conflicting flags",
+	}, []string{})
+}
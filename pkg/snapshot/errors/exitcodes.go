@@ -0,0 +1,80 @@
+package errors
+
+import (
+	goerrors "errors"
+
+	"github.com/layer5io/meshkit/errors"
+)
+
+// Exit codes returned by the plugin, grouped by failure class
+const (
+	// ExitOK indicates success
+	ExitOK = 0
+	// ExitGeneric indicates an unclassified failure, including command line usage errors
+	ExitGeneric = 1
+	// ExitInvalidInput indicates unreadable or invalid manifests, or invalid flag values
+	ExitInvalidInput = 2
+	// ExitAuthFailure indicates Meshery rejected the provided credentials
+	ExitAuthFailure = 3
+	// ExitServerError indicates Meshery could not be reached or returned an error
+	ExitServerError = 4
+	// ExitWorkflowFailure indicates the snapshot workflow could not be dispatched or rendered,
+	// or the pull request comment could not be posted
+	ExitWorkflowFailure = 5
+)
+
+// exitCodes maps error codes to the exit code of their failure class
+var exitCodes = map[string]int{
+	ErrReadingManifestFileCode:     ExitInvalidInput,
+	ErrInvalidEmailFormatCode:      ExitInvalidInput,
+	ErrInvalidConfigCode:           ExitInvalidInput,
+	ErrHTTPClientConfigCode:        ExitInvalidInput,
+	ErrInvalidSplitCode:            ExitInvalidInput,
+	ErrGitDiffCode:                 ExitInvalidInput,
+	ErrDiffCode:                    ExitInvalidInput,
+	ErrInvalidManifestsCode:        ExitInvalidInput,
+	ErrLoadingSchemasCode:          ExitInvalidInput,
+	ErrDuplicateResourcesCode:      ExitInvalidInput,
+	ErrInvalidFlagsCode:            ExitInvalidInput,
+	ErrExportingDesignCode:         ExitInvalidInput,
+	ErrDesignNotFoundCode:          ExitInvalidInput,
+	ErrAuthenticationFailedCode:    ExitAuthFailure,
+	ErrTokenExpiredCode:            ExitAuthFailure,
+	ErrInvalidTokenCode:            ExitAuthFailure,
+	ErrWrongProviderCode:           ExitAuthFailure,
+	ErrInsufficientPermissionsCode: ExitAuthFailure,
+	ErrLoginCode:                   ExitAuthFailure,
+	ErrHTTPPostRequestCode:         ExitServerError,
+	ErrDecodingAPICode:             ExitServerError,
+	ErrUnexpectedResponseCodeCode:  ExitServerError,
+	ErrCreatingMesheryDesignCode:   ExitServerError,
+	ErrUpdatingMesheryDesignCode:   ExitServerError,
+	ErrFetchingDesignCode:          ExitServerError,
+	ErrMesheryUnreachableCode:      ExitServerError,
+	ErrGeneratingSnapshotCode:      ExitWorkflowFailure,
+	ErrGitHubUnreachableCode:       ExitWorkflowFailure,
+	ErrPRCommentCode:               ExitWorkflowFailure,
+	ErrSnapshotTimeoutCode:         ExitWorkflowFailure,
+}
+
+// ExitCode returns the process exit code for err, looking through wrapped errors
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	e, ok := asError(err)
+	if !ok {
+		return ExitGeneric
+	}
+	if code, ok := exitCodes[e.Code]; ok {
+		return code
+	}
+	return ExitGeneric
+}
+
+// asError returns the plugin error in the chain of err
+func asError(err error) (*errors.Error, bool) {
+	var e *errors.Error
+	ok := goerrors.As(err, &e)
+	return e, ok
+}
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain error", fmt.Errorf("boom"), ExitGeneric},
+		{"invalid flags", ErrInvalidFlags(fmt.Errorf("invalid output format 'xml'")), ExitInvalidInput},
+		{"export", ErrExportingDesign(fmt.Errorf("no components")), ExitInvalidInput},
+		{"design not found", ErrDesignNotFound("3f1c9e2a"), ExitInvalidInput},
+		{"auth", ErrInvalidToken(fmt.Errorf("401")), ExitAuthFailure},
+		{"wrapped server error", fmt.Errorf("uploading group web: %w", ErrMesheryUnreachable("http://localhost:9081", fmt.Errorf("refused"))), ExitServerError},
+		{"wrapped twice", fmt.Errorf("a: %w", fmt.Errorf("b: %w", ErrPRComment(fmt.Errorf("403")))), ExitWorkflowFailure},
+		{"unmapped plugin error", ErrHistory(fmt.Errorf("locked")), ExitGeneric},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: ExitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsUnreachableWrapped(t *testing.T) {
+	err := fmt.Errorf("dispatch: %w", ErrGitHubUnreachable(fmt.Errorf("timeout")))
+	if !IsUnreachable(err) {
+		t.Error("IsUnreachable = false for a wrapped unreachable error")
+	}
+	if IsUnreachable(ErrInvalidToken(fmt.Errorf("401"))) {
+		t.Error("IsUnreachable = true for a rejected token")
+	}
+}
@@ -0,0 +1,36 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic replaces the file at path with data through a temporary file in the
+// same directory and a rename, so concurrent readers never see a partly written file.
+// The file is created with mode 0600.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LockFor locks path+".lock", creating the directory of path if needed, so updates
+// to the file at path made through read, change and WriteFileAtomic do not lose
+// changes made by concurrent runs
+func LockFor(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return Lock(path + ".lock")
+}
@@ -0,0 +1,47 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "designs.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFileAtomic: %v", err)
+		}
+		if got, err := os.ReadFile(path); err != nil || string(got) != content {
+			t.Errorf("file = %q, %v, want %q", got, err, content)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode = %o, want 600", mode)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want the temporary files removed", len(entries))
+	}
+}
+
+func TestLockFor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	unlock, err := LockFor(path)
+	if err != nil {
+		t.Fatalf("LockFor: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Errorf("lock file not created: %v", err)
+	}
+}
@@ -0,0 +1,2 @@
+// Package filelock provides advisory locks on files shared by concurrent runs of the plugin.
+package filelock
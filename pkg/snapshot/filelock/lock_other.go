@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package filelock
+
+// Lock is a no-op on platforms without file locking, so concurrent runs are not serialized
+func Lock(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,182 @@
+// Package gitdiff finds the manifests changed in a local git repository relative to a base ref.
+package gitdiff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Repo is the git repository containing a directory
+type Repo struct {
+	// Root is the absolute path of the top-level directory of the working tree
+	Root string
+}
+
+// Open returns the repository containing path, which may be a file or a directory
+func Open(path string) (*Repo, error) {
+	dir := path
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository: %w", path, err)
+	}
+	return &Repo{Root: filepath.Clean(root)}, nil
+}
+
+// MergeBase returns the commit where HEAD branched off base, so changes made on base
+// since then are not reported as changes of the current branch
+func (r *Repo) MergeBase(base string) (string, error) {
+	if _, err := r.Commit(base); err != nil {
+		return "", err
+	}
+	commit, err := git(r.Root, "merge-base", base, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("no common ancestor of '%s' and HEAD: %w", base, err)
+	}
+	return commit, nil
+}
+
+// Commit returns the SHA of the commit the ref points to
+func (r *Repo) Commit(ref string) (string, error) {
+	commit, err := git(r.Root, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown git ref '%s'", ref)
+	}
+	return commit, nil
+}
+
+// Files returns the absolute paths of the files at path as of the commit. path may be
+// a file or a directory; subdirectories are only searched if recursive is set.
+func (r *Repo) Files(commit, path string, recursive bool) ([]string, error) {
+	rel, err := filepath.Rel(r.Root, path)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"ls-tree", "-z"}
+	if recursive {
+		args = append(args, "-r")
+	}
+	spec := filepath.ToSlash(rel)
+	if spec == "." {
+		spec = ""
+	}
+	// A trailing slash lists the contents of a directory instead of the directory itself
+	if kind, _ := git(r.Root, "cat-file", "-t", commit+":"+spec); kind == "tree" && spec != "" {
+		spec += "/"
+	}
+	args = append(args, commit, "--")
+	if spec != "" {
+		args = append(args, spec)
+	}
+	out, err := git(r.Root, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range strings.Split(out, "\x00") {
+		// Entries are "<mode> <type> <object>\t<path>"
+		info, p, ok := strings.Cut(entry, "\t")
+		if !ok || !strings.Contains(info, " blob ") {
+			continue
+		}
+		paths = append(paths, filepath.Join(r.Root, filepath.FromSlash(p)))
+	}
+	return paths, nil
+}
+
+// ChangedFiles returns the absolute paths of files added or modified in the working tree
+// relative to the commit, including untracked files that are not ignored.
+// Renamed files are reported under their new name.
+func (r *Repo) ChangedFiles(commit string) ([]string, error) {
+	changed, err := git(r.Root, "diff", "--name-only", "-z", "--no-renames", "--diff-filter=AM", commit, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(r.Root, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, out := range []string{changed, untracked} {
+		for _, p := range strings.Split(out, "\x00") {
+			if p != "" {
+				paths = append(paths, filepath.Join(r.Root, filepath.FromSlash(p)))
+			}
+		}
+	}
+	return paths, nil
+}
+
+// Show returns the content of the file at the absolute path as of the commit.
+// ok is false if the file did not exist at the commit.
+func (r *Repo) Show(commit, path string) (content []byte, ok bool, err error) {
+	rel, err := filepath.Rel(r.Root, path)
+	if err != nil {
+		return nil, false, err
+	}
+	spec := commit + ":" + filepath.ToSlash(rel)
+	if _, err := git(r.Root, "cat-file", "-e", spec); err != nil {
+		return nil, false, nil
+	}
+	out, err := gitBytes(r.Root, "show", spec)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+// DefaultName returns a design name for the checked out commit: the branch name and the
+// short commit SHA, e.g. feature-x-1a2b3c4. On a detached HEAD, as in most CI checkouts,
+// the branch is taken from GITHUB_HEAD_REF or CI_COMMIT_REF_NAME, or left out.
+func (r *Repo) DefaultName() (string, error) {
+	sha, err := git(r.Root, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	branch, _ := git(r.Root, "rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "" || branch == "HEAD" {
+		branch = firstEnv("GITHUB_HEAD_REF", "CI_COMMIT_REF_NAME")
+	}
+	if branch == "" {
+		return sha, nil
+	}
+	return branch + "-" + sha, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// git runs a git command in dir and returns its trimmed output
+func git(dir string, args ...string) (string, error) {
+	out, err := gitBytes(dir, args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+func gitBytes(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,197 @@
+package gitdiff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testRepo is a repository in a temporary directory with a commit on main
+type testRepo struct {
+	t    *testing.T
+	root string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &testRepo{t: t, root: root}
+	r.git("init", "-q", "-b", "main")
+	r.git("config", "user.name", "Test")
+	r.git("config", "user.email", "test@example.com")
+	r.git("config", "commit.gpgsign", "false")
+	return r
+}
+
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	out, err := git(r.root, args...)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return out
+}
+
+func (r *testRepo) write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// commit commits all changes and returns the commit SHA
+func (r *testRepo) commit(msg string) string {
+	r.t.Helper()
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", msg)
+	return r.git("rev-parse", "HEAD")
+}
+
+func (r *testRepo) path(name string) string {
+	return filepath.Join(r.root, name)
+}
+
+func TestOpen(t *testing.T) {
+	r := newTestRepo(t)
+	r.write("k8s/app.yaml", "kind: ConfigMap\n")
+	r.commit("initial")
+
+	for _, path := range []string{r.root, r.path("k8s"), r.path("k8s/app.yaml")} {
+		repo, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", path, err)
+		}
+		if repo.Root != r.root {
+			t.Errorf("Open(%s).Root = %s, want %s", path, repo.Root, r.root)
+		}
+	}
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Error("Open outside a repository succeeded, want an error")
+	}
+}
+
+func TestMergeBase(t *testing.T) {
+	r := newTestRepo(t)
+	r.write("app.yaml", "v: 1\n")
+	fork := r.commit("initial")
+
+	r.git("checkout", "-q", "-b", "feature")
+	r.write("app.yaml", "v: 2\n")
+	r.commit("feature change")
+	r.git("checkout", "-q", "main")
+	r.write("other.yaml", "v: 1\n")
+	r.commit("main change")
+	r.git("checkout", "-q", "feature")
+
+	repo, err := Open(r.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Changes made on main after the branch was created are not part of the merge base
+	if base, err := repo.MergeBase("main"); err != nil || base != fork {
+		t.Errorf("MergeBase(main) = %s, %v, want %s", base, err, fork)
+	}
+	if _, err := repo.MergeBase("missing"); err == nil {
+		t.Error("MergeBase of an unknown ref succeeded, want an error")
+	}
+
+	r.git("checkout", "-q", "--orphan", "unrelated")
+	r.commit("unrelated history")
+	if _, err := repo.MergeBase("main"); err == nil {
+		t.Error("MergeBase without a common ancestor succeeded, want an error")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	r := newTestRepo(t)
+	r.write(".gitignore", "*.local.yaml\n")
+	r.write("modified.yaml", "v: 1\n")
+	r.write("unchanged.yaml", "v: 1\n")
+	r.write("deleted.yaml", "v: 1\n")
+	r.write("old-name.yaml", "kind: ConfigMap\nmetadata: {name: renamed}\n")
+	base := r.commit("initial")
+
+	r.write("modified.yaml", "v: 2\n")
+	r.write("dir/added.yaml", "v: 1\n")
+	r.git("rm", "-q", "deleted.yaml")
+	r.git("mv", "old-name.yaml", "new-name.yaml")
+	r.commit("second")
+	r.write("untracked.yaml", "v: 1\n")
+	r.write("ignored.local.yaml", "v: 1\n")
+
+	repo, err := Open(r.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.ChangedFiles(base)
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	sort.Strings(got)
+	want := []string{r.path("dir/added.yaml"), r.path("modified.yaml"), r.path("new-name.yaml"), r.path("untracked.yaml")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles = %v, want %v", got, want)
+	}
+}
+
+func TestShow(t *testing.T) {
+	r := newTestRepo(t)
+	r.write("k8s/app.yaml", "v: 1\n")
+	base := r.commit("initial")
+	r.write("k8s/app.yaml", "v: 2\n")
+	r.write("k8s/new.yaml", "v: 1\n")
+	r.commit("second")
+
+	repo, err := Open(r.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, ok, err := repo.Show(base, r.path("k8s/app.yaml"))
+	if err != nil || !ok || string(content) != "v: 1\n" {
+		t.Errorf("Show of app.yaml at the base = %q, %v, %v, want the first version", content, ok, err)
+	}
+	if content, ok, err := repo.Show(base, r.path("k8s/new.yaml")); err != nil || ok || content != nil {
+		t.Errorf("Show of a file added later = %q, %v, %v, want none", content, ok, err)
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	t.Setenv("GITHUB_HEAD_REF", "")
+	t.Setenv("CI_COMMIT_REF_NAME", "")
+	r := newTestRepo(t)
+	r.write("app.yaml", "v: 1\n")
+	r.commit("initial")
+	r.git("checkout", "-q", "-b", "feature-x")
+	sha := r.git("rev-parse", "--short", "HEAD")
+
+	repo, err := Open(r.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := repo.DefaultName(); err != nil || name != "feature-x-"+sha {
+		t.Errorf("DefaultName on a branch = %s, %v, want feature-x-%s", name, err, sha)
+	}
+
+	// Detached HEAD, as in CI checkouts
+	r.git("checkout", "-q", "--detach")
+	if name, err := repo.DefaultName(); err != nil || name != sha {
+		t.Errorf("DefaultName on a detached HEAD = %s, %v, want %s", name, err, sha)
+	}
+	t.Setenv("CI_COMMIT_REF_NAME", "mr-branch")
+	if name, err := repo.DefaultName(); err != nil || name != "mr-branch-"+sha {
+		t.Errorf("DefaultName with CI_COMMIT_REF_NAME = %s, %v, want mr-branch-%s", name, err, sha)
+	}
+	t.Setenv("GITHUB_HEAD_REF", "pr-branch")
+	if name, err := repo.DefaultName(); err != nil || name != "pr-branch-"+sha {
+		t.Errorf("DefaultName with GITHUB_HEAD_REF = %s, %v, want pr-branch-%s", name, err, sha)
+	}
+}
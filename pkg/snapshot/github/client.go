@@ -0,0 +1,191 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// commentsPerPage is the page size used when searching for the plugin's comment
+const commentsPerPage = 100
+
+// ActionsBot is the user comments made with the GITHUB_TOKEN of GitHub Actions belong to
+const ActionsBot = "github-actions[bot]"
+
+// Client calls the GitHub REST API
+type Client struct {
+	// BaseURL is the API root, https://api.github.com or https://<host>/api/v3
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+
+	// login is the user the token belongs to, looked up on first use
+	login string
+}
+
+// Comment is an issue or pull request comment
+type Comment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	User    User   `json:"user"`
+}
+
+// User is the author of a comment, or the user a token belongs to
+type User struct {
+	Login string `json:"login"`
+}
+
+// UpsertComment updates the first comment on the pull request containing marker that was
+// posted with the client's token, or creates one if there is none, so repeated runs keep
+// a single comment up to date. Comments of other users containing the marker are ignored.
+// created reports whether a new comment was posted.
+func (c *Client) UpsertComment(pr PullRequest, marker, body string) (comment *Comment, created bool, err error) {
+	login, err := c.Login()
+	if err != nil {
+		return nil, false, err
+	}
+	existing, err := c.findComment(pr, marker, login)
+	if err != nil {
+		return nil, false, err
+	}
+	payload := map[string]string{"body": body}
+	if existing != nil {
+		comment = &Comment{}
+		path := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", url.PathEscape(pr.Owner), url.PathEscape(pr.Repo), existing.ID)
+		if err := c.do("PATCH", path, payload, comment); err != nil {
+			return nil, false, err
+		}
+		return comment, false, nil
+	}
+	comment = &Comment{}
+	if err := c.do("POST", issuePath(pr)+"/comments", payload, comment); err != nil {
+		return nil, false, err
+	}
+	return comment, true, nil
+}
+
+// Login returns the user the token belongs to. Installation tokens, such as the GITHUB_TOKEN
+// of GitHub Actions, cannot read /user; their comments belong to ActionsBot.
+func (c *Client) Login() (string, error) {
+	if c.login != "" {
+		return c.login, nil
+	}
+	var user User
+	err := c.do("GET", "/user", nil, &user)
+	if apiErr, ok := err.(*APIError); ok && (apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusNotFound) {
+		user.Login, err = ActionsBot, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	c.login = user.Login
+	return c.login, nil
+}
+
+// findComment returns the first comment on the pull request by author containing marker, or nil
+func (c *Client) findComment(pr PullRequest, marker, author string) (*Comment, error) {
+	for page := 1; ; page++ {
+		var comments []Comment
+		path := fmt.Sprintf("%s/comments?per_page=%d&page=%d", issuePath(pr), commentsPerPage, page)
+		if err := c.do("GET", path, nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.EqualFold(comments[i].User.Login, author) && strings.Contains(comments[i].Body, marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < commentsPerPage {
+			return nil, nil
+		}
+	}
+}
+
+// FileExists reports whether the file exists in the repository at the ref
+func (c *Client) FileExists(owner, repo, ref, path string) (bool, error) {
+	var escaped []string
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		escaped = append(escaped, url.PathEscape(part))
+	}
+	apiPath := fmt.Sprintf("/repos/%s/%s/contents/%s?ref=%s", url.PathEscape(owner), url.PathEscape(repo), strings.Join(escaped, "/"), url.QueryEscape(ref))
+	err := c.do("GET", apiPath, nil, nil)
+	if apiErr, ok := err.(*APIError); ok && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// APIError is a response from GitHub with an unexpected status
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: GitHub returned %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+func issuePath(pr PullRequest) string {
+	return fmt.Sprintf("/repos/%s/%s/issues/%d", url.PathEscape(pr.Owner), url.PathEscape(pr.Repo), pr.Number)
+}
+
+// do sends a request with the JSON payload, if any, and decodes the response into out, if set
+func (c *Client) do(method, path string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			message = apiErr.Message
+		}
+		if len(message) > 200 {
+			message = message[:200] + "..."
+		}
+		return &APIError{Method: method, Path: strings.SplitN(path, "?", 2)[0], StatusCode: resp.StatusCode, Message: message}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error decoding GitHub response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testMarker = "<!-- kubectl-kanvas-snapshot -->"
+
+// fakeGitHub serves the issue comment endpoints of a single pull request
+type fakeGitHub struct {
+	t *testing.T
+	// login is the user of the token, empty to answer /user like an installation token
+	login string
+
+	mu       sync.Mutex
+	comments []Comment
+	nextID   int64
+	requests []string
+}
+
+func newFakeGitHub(t *testing.T, login string) (*fakeGitHub, *Client) {
+	f := &fakeGitHub{t: t, login: login, nextID: 1000}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, &Client{BaseURL: server.URL, Token: "test-token", HTTP: server.Client()}
+}
+
+// add stores an existing comment on the pull request
+func (f *fakeGitHub) add(author, body string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	f.comments = append(f.comments, Comment{ID: f.nextID, Body: body, User: User{Login: author}})
+	return f.nextID
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "token test-token" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+
+	const issue = "/repos/octo/app/issues/7/comments"
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/user":
+		if f.login == "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"message": "Resource not accessible by integration"})
+			return
+		}
+		writeJSON(w, http.StatusOK, User{Login: f.login})
+
+	case r.Method == "GET" && r.URL.Path == issue:
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start, end := (page-1)*perPage, page*perPage
+		if start > len(f.comments) {
+			start = len(f.comments)
+		}
+		if end > len(f.comments) {
+			end = len(f.comments)
+		}
+		writeJSON(w, http.StatusOK, f.comments[start:end])
+
+	case r.Method == "POST" && r.URL.Path == issue:
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			f.t.Errorf("decoding comment: %v", err)
+		}
+		f.nextID++
+		comment := Comment{ID: f.nextID, Body: payload["body"], User: User{Login: f.author()}}
+		comment.HTMLURL = fmt.Sprintf("https://github.com/octo/app/pull/7#issuecomment-%d", comment.ID)
+		f.comments = append(f.comments, comment)
+		writeJSON(w, http.StatusCreated, comment)
+
+	case r.Method == "PATCH" && strings.HasPrefix(r.URL.Path, "/repos/octo/app/issues/comments/"):
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/repos/octo/app/issues/comments/"), 10, 64)
+		for i := range f.comments {
+			if f.comments[i].ID != id {
+				continue
+			}
+			if f.comments[i].User.Login != f.author() {
+				writeJSON(w, http.StatusForbidden, map[string]string{"message": "Must have admin rights to Repository."})
+				return
+			}
+			var payload map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				f.t.Errorf("decoding comment: %v", err)
+			}
+			f.comments[i].Body = payload["body"]
+			writeJSON(w, http.StatusOK, f.comments[i])
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+// author returns the user comments posted with the token belong to
+func (f *fakeGitHub) author() string {
+	if f.login == "" {
+		return ActionsBot
+	}
+	return f.login
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+var testPR = PullRequest{Owner: "octo", Repo: "app", Number: 7}
+
+func TestUpsertCommentCreates(t *testing.T) {
+	f, client := newFakeGitHub(t, "snapshot-bot")
+	f.add("alice", "Looks good")
+
+	comment, created, err := client.UpsertComment(testPR, testMarker, testMarker+"\nfirst")
+	if err != nil {
+		t.Fatalf("UpsertComment: %v", err)
+	}
+	if !created {
+		t.Error("created = false, want true")
+	}
+	if len(f.comments) != 2 || f.comments[1].Body != testMarker+"\nfirst" {
+		t.Errorf("comments = %+v, want the new comment appended", f.comments)
+	}
+	if comment.HTMLURL == "" {
+		t.Error("comment has no HTML URL")
+	}
+}
+
+func TestUpsertCommentUpdates(t *testing.T) {
+	f, client := newFakeGitHub(t, "snapshot-bot")
+	id := f.add("snapshot-bot", testMarker+"\nfirst")
+
+	comment, created, err := client.UpsertComment(testPR, testMarker, testMarker+"\nsecond")
+	if err != nil {
+		t.Fatalf("UpsertComment: %v", err)
+	}
+	if created {
+		t.Error("created = true, want false")
+	}
+	if comment.ID != id || len(f.comments) != 1 || f.comments[0].Body != testMarker+"\nsecond" {
+		t.Errorf("comments = %+v, want comment %d updated", f.comments, id)
+	}
+}
+
+func TestUpsertCommentIgnoresOtherAuthors(t *testing.T) {
+	f, client := newFakeGitHub(t, "snapshot-bot")
+	f.add("mallory", testMarker+"\nnot the plugin's comment")
+
+	_, created, err := client.UpsertComment(testPR, testMarker, testMarker+"\nfirst")
+	if err != nil {
+		t.Fatalf("UpsertComment: %v", err)
+	}
+	if !created {
+		t.Error("created = false, want a new comment instead of editing another user's")
+	}
+	if f.comments[0].Body != testMarker+"\nnot the plugin's comment" {
+		t.Errorf("comment of another user was changed: %q", f.comments[0].Body)
+	}
+}
+
+func TestUpsertCommentActionsToken(t *testing.T) {
+	f, client := newFakeGitHub(t, "")
+	f.add("mallory", testMarker)
+	id := f.add(ActionsBot, testMarker+"\nfirst")
+
+	_, created, err := client.UpsertComment(testPR, testMarker, testMarker+"\nsecond")
+	if err != nil {
+		t.Fatalf("UpsertComment: %v", err)
+	}
+	if created {
+		t.Error("created = true, want the comment of github-actions[bot] updated")
+	}
+	for _, c := range f.comments {
+		if c.ID == id && c.Body != testMarker+"\nsecond" {
+			t.Errorf("comment %d = %q, want it updated", id, c.Body)
+		}
+	}
+}
+
+func TestUpsertCommentPagination(t *testing.T) {
+	f, client := newFakeGitHub(t, "snapshot-bot")
+	for i := 0; i < commentsPerPage+5; i++ {
+		f.add("alice", fmt.Sprintf("comment %d", i))
+	}
+	id := f.add("snapshot-bot", testMarker+"\nfirst")
+
+	comment, created, err := client.UpsertComment(testPR, testMarker, testMarker+"\nsecond")
+	if err != nil {
+		t.Fatalf("UpsertComment: %v", err)
+	}
+	if created || comment.ID != id {
+		t.Errorf("got comment %d (created %v), want comment %d on the second page updated", comment.ID, created, id)
+	}
+
+	pages := 0
+	for _, r := range f.requests {
+		if r == "GET /repos/octo/app/issues/7/comments" {
+			pages++
+		}
+	}
+	if pages != 2 {
+		t.Errorf("listed %d pages of comments, want 2", pages)
+	}
+}
+
+func TestUpsertCommentBadCredentials(t *testing.T) {
+	_, client := newFakeGitHub(t, "snapshot-bot")
+	client.Token = "wrong"
+
+	_, _, err := client.UpsertComment(testPR, testMarker, testMarker)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want a 401 APIError", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Package github finds the pull request a CI build runs for and keeps a single comment
+// of the plugin up to date on it through the GitHub REST API.
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PullRequest identifies a pull request
+type PullRequest struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// String returns the pull request as owner/repo#number
+func (p PullRequest) String() string {
+	return fmt.Sprintf("%s/%s#%d", p.Owner, p.Repo, p.Number)
+}
+
+var (
+	// pullURL matches pull request web URLs such as https://github.com/owner/repo/pull/12
+	pullURL = regexp.MustCompile(`^https?://[^/]+/([^/]+)/([^/]+)/pull/(\d+)`)
+	// shortRef matches owner/repo#12
+	shortRef = regexp.MustCompile(`^([^/#\s]+)/([^/#\s]+)#(\d+)$`)
+	// pullRef matches the refs of pull request builds, refs/pull/12/merge or refs/pull/12/head
+	pullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+	// repoURL matches clone URLs, https://github.com/owner/repo.git or git@github.com:owner/repo.git
+	repoURL = regexp.MustCompile(`[:/]([^/:]+)/([^/]+?)(\.git)?/?$`)
+)
+
+// ParsePullRequest parses a pull request given as a web URL, as owner/repo#number, or as
+// a number of the repository named by repo (owner/repo).
+func ParsePullRequest(value, repo string) (*PullRequest, error) {
+	if m := pullURL.FindStringSubmatch(value); m != nil {
+		return newPullRequest(m[1], m[2], m[3])
+	}
+	if m := shortRef.FindStringSubmatch(value); m != nil {
+		return newPullRequest(m[1], m[2], m[3])
+	}
+	if _, err := strconv.Atoi(value); err == nil {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok {
+			return nil, fmt.Errorf("pull request %s has no repository, pass it as owner/repo#%s", value, value)
+		}
+		return newPullRequest(owner, name, value)
+	}
+	return nil, fmt.Errorf("invalid pull request '%s': expected a number, owner/repo#number or a pull request URL", value)
+}
+
+// DetectPullRequest returns the pull request the CI build runs for, and the CI system it was
+// detected from. It reads the environment of GitHub Actions, Jenkins, CircleCI, Buildkite
+// and Travis CI. The pull request is nil if the build is not for a pull request.
+func DetectPullRequest(getenv func(string) string) (*PullRequest, string, error) {
+	if getenv("GITHUB_ACTIONS") == "true" {
+		pr, err := gitHubActionsPullRequest(getenv)
+		return pr, "GitHub Actions", err
+	}
+	if u := getenv("CHANGE_URL"); u != "" && getenv("JENKINS_URL") != "" {
+		pr, err := ParsePullRequest(u, "")
+		return pr, "Jenkins", err
+	}
+	if u := getenv("CIRCLE_PULL_REQUEST"); u != "" {
+		pr, err := ParsePullRequest(u, "")
+		return pr, "CircleCI", err
+	}
+	if n := getenv("BUILDKITE_PULL_REQUEST"); n != "" && n != "false" {
+		m := repoURL.FindStringSubmatch(getenv("BUILDKITE_REPO"))
+		if m == nil {
+			return nil, "Buildkite", fmt.Errorf("cannot parse BUILDKITE_REPO '%s'", getenv("BUILDKITE_REPO"))
+		}
+		pr, err := newPullRequest(m[1], m[2], n)
+		return pr, "Buildkite", err
+	}
+	if n := getenv("TRAVIS_PULL_REQUEST"); n != "" && n != "false" {
+		pr, err := ParsePullRequest(n, getenv("TRAVIS_REPO_SLUG"))
+		return pr, "Travis CI", err
+	}
+	return nil, "", nil
+}
+
+// gitHubActionsPullRequest reads the pull request from the event payload of a pull_request,
+// pull_request_target or issue_comment event, or from a refs/pull/<n>/merge ref
+func gitHubActionsPullRequest(getenv func(string) string) (*PullRequest, error) {
+	repo := getenv("GITHUB_REPOSITORY")
+	if path := getenv("GITHUB_EVENT_PATH"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading GitHub event: %w", err)
+		}
+		var event struct {
+			PullRequest *struct {
+				Number int `json:"number"`
+			} `json:"pull_request"`
+			Issue *struct {
+				Number      int             `json:"number"`
+				PullRequest json.RawMessage `json:"pull_request"`
+			} `json:"issue"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("error parsing GitHub event: %w", err)
+		}
+		switch {
+		case event.PullRequest != nil && event.PullRequest.Number > 0:
+			return ParsePullRequest(strconv.Itoa(event.PullRequest.Number), repo)
+		case event.Issue != nil && len(event.Issue.PullRequest) > 0:
+			return ParsePullRequest(strconv.Itoa(event.Issue.Number), repo)
+		}
+	}
+	if m := pullRef.FindStringSubmatch(getenv("GITHUB_REF")); m != nil {
+		return ParsePullRequest(m[1], repo)
+	}
+	return nil, nil
+}
+
+func newPullRequest(owner, repo, number string) (*PullRequest, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid pull request number '%s'", number)
+	}
+	owner, _ = url.PathUnescape(owner)
+	repo, _ = url.PathUnescape(repo)
+	return &PullRequest{Owner: owner, Repo: repo, Number: n}, nil
+}
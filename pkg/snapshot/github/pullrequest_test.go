@@ -0,0 +1,147 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePullRequest(t *testing.T) {
+	tests := []struct {
+		value, repo string
+		want        PullRequest
+		wantErr     bool
+	}{
+		{value: "https://github.com/octo/app/pull/12", want: PullRequest{"octo", "app", 12}},
+		{value: "https://github.example.com/octo/app/pull/12/files", want: PullRequest{"octo", "app", 12}},
+		{value: "octo/app#12", want: PullRequest{"octo", "app", 12}},
+		{value: "12", repo: "octo/app", want: PullRequest{"octo", "app", 12}},
+		{value: "12", wantErr: true},
+		{value: "0", repo: "octo/app", wantErr: true},
+		{value: "octo/app", wantErr: true},
+	}
+	for _, tt := range tests {
+		pr, err := ParsePullRequest(tt.value, tt.repo)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePullRequest(%q, %q) = %v, want an error", tt.value, tt.repo, pr)
+			}
+			continue
+		}
+		if err != nil || *pr != tt.want {
+			t.Errorf("ParsePullRequest(%q, %q) = %v, %v, want %v", tt.value, tt.repo, pr, err, tt.want)
+		}
+	}
+}
+
+func TestDetectPullRequest(t *testing.T) {
+	dir := t.TempDir()
+	event := func(name, payload string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(payload), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pullRequestEvent := event("pull_request.json", `{"pull_request": {"number": 21}}`)
+	commentEvent := event("issue_comment.json", `{"issue": {"number": 22, "pull_request": {"url": "https://api.github.com/repos/octo/app/pulls/22"}}}`)
+	issueEvent := event("issue.json", `{"issue": {"number": 23}}`)
+	pushEvent := event("push.json", `{"ref": "refs/heads/main"}`)
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		want   *PullRequest
+		wantCI string
+	}{
+		{
+			name:   "GitHub Actions pull_request event",
+			env:    map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_EVENT_PATH": pullRequestEvent},
+			want:   &PullRequest{"octo", "app", 21},
+			wantCI: "GitHub Actions",
+		},
+		{
+			name:   "GitHub Actions issue_comment event",
+			env:    map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_EVENT_PATH": commentEvent},
+			want:   &PullRequest{"octo", "app", 22},
+			wantCI: "GitHub Actions",
+		},
+		{
+			name:   "GitHub Actions comment on an issue",
+			env:    map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_EVENT_PATH": issueEvent},
+			wantCI: "GitHub Actions",
+		},
+		{
+			name:   "GitHub Actions pull request ref",
+			env:    map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_EVENT_PATH": pushEvent, "GITHUB_REF": "refs/pull/24/merge"},
+			want:   &PullRequest{"octo", "app", 24},
+			wantCI: "GitHub Actions",
+		},
+		{
+			name:   "GitHub Actions push",
+			env:    map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REPOSITORY": "octo/app", "GITHUB_EVENT_PATH": pushEvent, "GITHUB_REF": "refs/heads/main"},
+			wantCI: "GitHub Actions",
+		},
+		{
+			name:   "Jenkins",
+			env:    map[string]string{"JENKINS_URL": "https://ci.example.com/", "CHANGE_URL": "https://github.com/octo/app/pull/31"},
+			want:   &PullRequest{"octo", "app", 31},
+			wantCI: "Jenkins",
+		},
+		{
+			name:   "CircleCI",
+			env:    map[string]string{"CIRCLE_PULL_REQUEST": "https://github.com/octo/app/pull/41"},
+			want:   &PullRequest{"octo", "app", 41},
+			wantCI: "CircleCI",
+		},
+		{
+			name:   "Buildkite",
+			env:    map[string]string{"BUILDKITE_PULL_REQUEST": "51", "BUILDKITE_REPO": "git@github.com:octo/app.git"},
+			want:   &PullRequest{"octo", "app", 51},
+			wantCI: "Buildkite",
+		},
+		{
+			name: "Buildkite branch build",
+			env:  map[string]string{"BUILDKITE_PULL_REQUEST": "false", "BUILDKITE_REPO": "git@github.com:octo/app.git"},
+		},
+		{
+			name:   "Travis CI",
+			env:    map[string]string{"TRAVIS_PULL_REQUEST": "61", "TRAVIS_REPO_SLUG": "octo/app"},
+			want:   &PullRequest{"octo", "app", 61},
+			wantCI: "Travis CI",
+		},
+		{
+			name: "Travis CI branch build",
+			env:  map[string]string{"TRAVIS_PULL_REQUEST": "false", "TRAVIS_REPO_SLUG": "octo/app"},
+		},
+		{
+			name: "no CI",
+			env:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, ci, err := DetectPullRequest(func(key string) string { return tt.env[key] })
+			if err != nil {
+				t.Fatalf("DetectPullRequest: %v", err)
+			}
+			if ci != tt.wantCI {
+				t.Errorf("CI = %q, want %q", ci, tt.wantCI)
+			}
+			switch {
+			case tt.want == nil && pr != nil:
+				t.Errorf("pull request = %v, want none", pr)
+			case tt.want != nil && (pr == nil || *pr != *tt.want):
+				t.Errorf("pull request = %v, want %v", pr, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectPullRequestInvalidBuildkiteRepo(t *testing.T) {
+	env := map[string]string{"BUILDKITE_PULL_REQUEST": "51", "BUILDKITE_REPO": "not a url"}
+	if _, _, err := DetectPullRequest(func(key string) string { return env[key] }); err == nil {
+		t.Error("DetectPullRequest succeeded, want an error for an unparsable BUILDKITE_REPO")
+	}
+}
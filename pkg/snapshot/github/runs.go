@@ -0,0 +1,59 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WorkflowRun is a run of a GitHub Actions workflow
+type WorkflowRun struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+	// DisplayTitle is the run-name of the workflow, or the workflow name if it has none
+	DisplayTitle string    `json:"display_title"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FindDispatchedRun returns the newest run of the workflow started by a workflow_dispatch
+// event at or after since whose title contains the design ID as a word, or nil if GitHub
+// lists none. Runs are only told apart by their title, so the workflow must put the design
+// ID in its run-name. workflow is the workflow file name or ID.
+func (c *Client) FindDispatchedRun(owner, repo, workflow, designID string, since time.Time) (*WorkflowRun, error) {
+	query := url.Values{
+		"event":    {"workflow_dispatch"},
+		"created":  {">=" + since.UTC().Format(time.RFC3339)},
+		"per_page": {"100"},
+	}
+	path := fmt.Sprintf("/repos/%s/%s/actions/workflows/%s/runs?%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(workflow), query.Encode())
+
+	var out struct {
+		WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+	}
+	if err := c.do("GET", path, nil, &out); err != nil {
+		return nil, err
+	}
+
+	var newest *WorkflowRun
+	for i, run := range out.WorkflowRuns {
+		if run.CreatedAt.Before(since) || !hasWord(run.DisplayTitle, designID) {
+			continue
+		}
+		if newest == nil || run.CreatedAt.After(newest.CreatedAt) {
+			newest = &out.WorkflowRuns[i]
+		}
+	}
+	return newest, nil
+}
+
+// hasWord reports whether word is one of the space separated words of s
+func hasWord(s, word string) bool {
+	for _, w := range strings.Fields(s) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFindDispatchedRun(t *testing.T) {
+	since := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octo/app/actions/workflows/snapshot.yml/runs" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		query = r.URL.RawQuery
+		writeJSON(w, http.StatusOK, map[string]interface{}{"workflow_runs": []WorkflowRun{
+			// An older run for the same design
+			{ID: 1, DisplayTitle: "Kanvas Snapshot d-42", CreatedAt: since.Add(-time.Minute)},
+			// Runs dispatched by other users or other groups at the same time
+			{ID: 2, DisplayTitle: "Kanvas Snapshot d-7", CreatedAt: since.Add(3 * time.Second)},
+			{ID: 3, DisplayTitle: "Kanvas Snapshot d-420", CreatedAt: since.Add(2 * time.Second)},
+			{ID: 4, DisplayTitle: "Kanvas Snapshot", CreatedAt: since.Add(4 * time.Second)},
+			{ID: 5, HTMLURL: "https://github.com/octo/app/actions/runs/5", DisplayTitle: "Kanvas Snapshot d-42", CreatedAt: since.Add(time.Second)},
+		}})
+	}))
+	defer server.Close()
+	client := &Client{BaseURL: server.URL, HTTP: server.Client()}
+
+	run, err := client.FindDispatchedRun("octo", "app", "snapshot.yml", "d-42", since)
+	if err != nil {
+		t.Fatalf("FindDispatchedRun: %v", err)
+	}
+	if run == nil || run.ID != 5 {
+		t.Fatalf("run = %+v, want the run titled with the design, 5", run)
+	}
+	if want := "created=%3E%3D2026-05-04T10%3A00%3A00Z&event=workflow_dispatch&per_page=100"; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+
+	// Workflows without the design ID in their run-name never match
+	if run, err := client.FindDispatchedRun("octo", "app", "snapshot.yml", "d-99", since); err != nil || run != nil {
+		t.Errorf("FindDispatchedRun of another design = %+v, %v, want none", run, err)
+	}
+	if run, err := client.FindDispatchedRun("octo", "app", "snapshot.yml", "d-42", since.Add(time.Hour)); err != nil || run != nil {
+		t.Errorf("FindDispatchedRun after all runs = %+v, %v, want none", run, err)
+	}
+	if _, err := client.FindDispatchedRun("octo", "app", "missing.yml", "d-42", since); err == nil {
+		t.Error("FindDispatchedRun of a missing workflow succeeded, want an error")
+	}
+}
@@ -0,0 +1,168 @@
+// Package history records the designs and snapshots created by each run of the plugin.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/filelock"
+)
+
+// Record is a design created or reused by a run
+type Record struct {
+	Time time.Time `json:"time" yaml:"time"`
+	// Inputs are the manifest paths the design was created from
+	Inputs     []string `json:"inputs" yaml:"inputs"`
+	Hash       string   `json:"hash" yaml:"hash"`
+	Server     string   `json:"server" yaml:"server"`
+	DesignID   string   `json:"designID" yaml:"designID"`
+	DesignName string   `json:"designName" yaml:"designName"`
+	ViewURL    string   `json:"viewURL" yaml:"viewURL"`
+	// WorkflowRun is the URL of the workflow run rendering the snapshot, or of the
+	// workflow's runs page if the run was not found
+	WorkflowRun   string `json:"workflowRun,omitempty" yaml:"workflowRun,omitempty"`
+	AssetLocation string `json:"assetLocation,omitempty" yaml:"assetLocation,omitempty"`
+	// Cached is set when the design was reused from the design cache
+	Cached bool `json:"cached,omitempty" yaml:"cached,omitempty"`
+}
+
+// Matches reports whether any of the record's text fields contains query, ignoring case
+func (r Record) Matches(query string) bool {
+	query = strings.ToLower(query)
+	fields := append([]string{r.Hash, r.Server, r.DesignID, r.DesignName, r.ViewURL, r.WorkflowRun, r.AssetLocation}, r.Inputs...)
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is a history stored as JSON lines, oldest first. Appends and prunes
+// hold a lock on a separate lock file so concurrent runs do not lose records.
+type Store struct {
+	path string
+}
+
+// New returns a history stored in the file at path
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath returns the history file, ~/.meshery/kubectl-kanvas-snapshot/history.jsonl
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".meshery", "kubectl-kanvas-snapshot", "history.jsonl"), nil
+}
+
+// Path returns the history file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append adds a record to the history
+func (s *Store) Append(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening history file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing history file: %w", err)
+	}
+	return f.Close()
+}
+
+// List returns all records, oldest first. A missing file is an empty history.
+// Lines that cannot be parsed, e.g. from an interrupted write, are skipped.
+func (s *Store) List() ([]Record, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history file: %w", err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Prune removes the records for which remove returns true, given each record and its
+// position counted from the newest record (1 is the newest). It returns the number removed.
+func (s *Store) Prune(remove func(r Record, n int) bool) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	records, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	removed := 0
+	for i, r := range records {
+		if remove(r, len(records)-i) {
+			removed++
+			continue
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.replace(buf.Bytes())
+}
+
+// lock takes the history lock, creating the history directory if needed
+func (s *Store) lock() (func(), error) {
+	unlock, err := filelock.LockFor(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("error locking history file: %w", err)
+	}
+	return unlock, nil
+}
+
+// replace atomically replaces the history file with data
+func (s *Store) replace(data []byte) error {
+	if err := filelock.WriteFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("error writing history file: %w", err)
+	}
+	return nil
+}
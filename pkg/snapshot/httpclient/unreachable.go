@@ -0,0 +1,30 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// IsUnreachable reports whether a request failed because the server could not be reached:
+// the name did not resolve, the connection was refused or reset, or the request timed out.
+// TLS and HTTP protocol errors are not included, since retrying later will not fix them.
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	// TLS alerts are reported as "local error" and "remote error" operations
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op != "local error" && opErr.Op != "remote error"
+}
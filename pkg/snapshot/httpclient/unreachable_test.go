@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsUnreachable(t *testing.T) {
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer tlsServer.Close()
+
+	// Clients connect directly, whatever proxy the environment sets
+	direct := &http.Client{Transport: &http.Transport{}}
+	get := func(client *http.Client, url string) error {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"connection refused", get(direct, closedURL), true},
+		{"timeout", get(&http.Client{Transport: &http.Transport{}, Timeout: 20 * time.Millisecond}, slow.URL), true},
+		{"deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), true},
+		{"name not resolved", &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "meshery.invalid", IsNotFound: true}}, true},
+		{"reset", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, true},
+		{"unknown certificate authority", get(direct, tlsServer.URL), false},
+		{"TLS alert", &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}, false},
+		{"other error", errors.New("unexpected response code 500"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil && tt.want {
+				t.Fatal("request succeeded, want an error")
+			}
+			if got := IsUnreachable(tt.err); got != tt.want {
+				t.Errorf("IsUnreachable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
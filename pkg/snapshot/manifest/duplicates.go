@@ -0,0 +1,149 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Duplicate is a resource defined more than once in the manifests
+type Duplicate struct {
+	// Key identifies the resource by apiVersion, kind, namespace and name,
+	// e.g. apps/v1 Deployment default/web
+	Key string
+	// Definitions are all definitions of the resource, in the order they were read
+	Definitions []Resource
+	// Conflicting is set if the definitions differ
+	Conflicting bool
+}
+
+// Locations returns where the resource is defined, e.g. [web.yaml:1 overrides.yaml:12]
+func (d Duplicate) Locations() []string {
+	locations := make([]string, len(d.Definitions))
+	for i, r := range d.Definitions {
+		locations[i] = fmt.Sprintf("%s:%d", r.Source, r.Line)
+	}
+	return locations
+}
+
+// duplicateKey identifies a resource by group, version, kind, namespace and name.
+// Resources without a kind or name, such as those using generateName, have no key.
+func duplicateKey(r Resource) string {
+	if r.Kind == "" || r.Name == "" {
+		return ""
+	}
+	if ns := r.EffectiveNamespace(); ns != "" {
+		return fmt.Sprintf("%s %s %s/%s", r.APIVersion, r.Kind, ns, r.Name)
+	}
+	return fmt.Sprintf("%s %s %s", r.APIVersion, r.Kind, r.Name)
+}
+
+// FindDuplicates returns the resources of the files that are defined more than once,
+// in the order of their first definition
+func FindDuplicates(files []File) []Duplicate {
+	index := make(map[string]int)
+	var all []Duplicate
+	for _, f := range files {
+		for _, r := range f.Resources {
+			key := duplicateKey(r)
+			if key == "" {
+				continue
+			}
+			i, ok := index[key]
+			if !ok {
+				i = len(all)
+				index[key] = i
+				all = append(all, Duplicate{Key: key})
+			}
+			all[i].Definitions = append(all[i].Definitions, r)
+		}
+	}
+
+	var dups []Duplicate
+	for _, d := range all {
+		if len(d.Definitions) < 2 {
+			continue
+		}
+		for _, r := range d.Definitions[1:] {
+			if !sameDefinition(r.Object, d.Definitions[0].Object) {
+				d.Conflicting = true
+			}
+		}
+		dups = append(dups, d)
+	}
+	return dups
+}
+
+// sameDefinition reports whether two definitions of a resource are equal. The namespace
+// is ignored, since the definitions already share the same effective namespace, and
+// one may leave out the default namespace the other sets.
+func sameDefinition(a, b map[string]interface{}) bool {
+	withoutNamespace := func(obj map[string]interface{}) map[string]interface{} {
+		metadata, ok := obj["metadata"].(map[string]interface{})
+		if !ok {
+			return obj
+		}
+		copied := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			copied[k] = v
+		}
+		meta := make(map[string]interface{}, len(metadata))
+		for k, v := range metadata {
+			if k != "namespace" {
+				meta[k] = v
+			}
+		}
+		copied["metadata"] = meta
+		return copied
+	}
+	return reflect.DeepEqual(withoutNamespace(a), withoutNamespace(b))
+}
+
+// KeepLast removes all but the last definition of each duplicated resource, the way
+// kubectl apply leaves the last one in place. Files that lose resources are serialized
+// again from their remaining resources; the others are returned unchanged. Files that
+// could not be fully parsed are always returned unchanged, since serializing them again
+// would drop the documents that failed to parse.
+func KeepLast(files []File) ([]File, error) {
+	last := make(map[string][2]int)
+	for i, f := range files {
+		for j, r := range f.Resources {
+			if key := duplicateKey(r); key != "" {
+				last[key] = [2]int{i, j}
+			}
+		}
+	}
+
+	out := make([]File, len(files))
+	for i, f := range files {
+		out[i] = f
+		if f.ParseErr != nil {
+			continue
+		}
+		var kept []Resource
+		for j, r := range f.Resources {
+			if key := duplicateKey(r); key == "" || last[key] == [2]int{i, j} {
+				kept = append(kept, r)
+			}
+		}
+		if len(kept) == len(f.Resources) {
+			continue
+		}
+		content, err := MarshalResources(kept)
+		if err != nil {
+			return nil, err
+		}
+		out[i].Resources = kept
+		out[i].Content = []byte(content)
+	}
+	return out, nil
+}
+
+// String describes the duplicate for messages
+func (d Duplicate) String() string {
+	what := "identical definitions"
+	if d.Conflicting {
+		what = "conflicting definitions"
+	}
+	return fmt.Sprintf("%s has %d %s: %s", d.Key, len(d.Definitions), what, strings.Join(d.Locations(), ", "))
+}
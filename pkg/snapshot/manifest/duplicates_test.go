@@ -0,0 +1,156 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseFile parses a manifest as a loaded file
+func parseFile(path, content string) File {
+	resources, err := Parse(path, []byte(content))
+	return File{Path: path, Content: []byte(content), Resources: resources, ParseErr: err}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []File
+		// want lists the keys of the duplicates, with whether they conflict
+		want map[string]bool
+		// locations of the first duplicate
+		locations []string
+	}{
+		{
+			name: "identical definitions",
+			files: []File{
+				parseFile("a.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: web}\ndata: {v: \"1\"}\n"),
+				parseFile("b.yaml", "apiVersion: v1\nkind: Secret\nmetadata: {name: web}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: web}\ndata: {v: \"1\"}\n"),
+			},
+			want:      map[string]bool{"v1 ConfigMap default/web": false},
+			locations: []string{"a.yaml:1", "b.yaml:5"},
+		},
+		{
+			name: "conflicting definitions",
+			files: []File{
+				parseFile("a.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: web}\ndata: {v: \"1\"}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: web}\ndata: {v: \"2\"}\n"),
+			},
+			want:      map[string]bool{"v1 ConfigMap default/web": true},
+			locations: []string{"a.yaml:1", "a.yaml:6"},
+		},
+		{
+			name: "omitted and explicit default namespace",
+			files: []File{
+				parseFile("a.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: web}\n"),
+				parseFile("b.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: web, namespace: default}\n"),
+			},
+			want:      map[string]bool{"v1 ConfigMap default/web": false},
+			locations: []string{"a.yaml:1", "b.yaml:1"},
+		},
+		{
+			name: "cluster-scoped",
+			files: []File{
+				parseFile("a.yaml", "apiVersion: v1\nkind: Namespace\nmetadata: {name: shop}\n---\napiVersion: v1\nkind: Namespace\nmetadata: {name: shop, namespace: ignored}\n"),
+			},
+			want:      map[string]bool{"v1 Namespace shop": false},
+			locations: []string{"a.yaml:1", "a.yaml:5"},
+		},
+		{
+			name: "different namespaces, versions or no name",
+			files: []File{
+				parseFile("a.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: web, namespace: a}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: web, namespace: b}\n"),
+				parseFile("b.yaml", "apiVersion: autoscaling/v1\nkind: HorizontalPodAutoscaler\nmetadata: {name: web}\n---\napiVersion: autoscaling/v2\nkind: HorizontalPodAutoscaler\nmetadata: {name: web}\n"),
+				parseFile("c.yaml", "apiVersion: batch/v1\nkind: Job\nmetadata: {generateName: run-}\n---\napiVersion: batch/v1\nkind: Job\nmetadata: {generateName: run-}\n"),
+			},
+			want: map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dups := FindDuplicates(tt.files)
+			got := make(map[string]bool, len(dups))
+			for _, d := range dups {
+				got[d.Key] = d.Conflicting
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("FindDuplicates = %v, want %v", got, tt.want)
+			}
+			if tt.locations != nil && !reflect.DeepEqual(dups[0].Locations(), tt.locations) {
+				t.Errorf("Locations = %v, want %v", dups[0].Locations(), tt.locations)
+			}
+		})
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	base := parseFile("base.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+data: {mode: slow}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+`)
+	overrides := parseFile("overrides.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: default}
+data: {mode: fast}
+`)
+	other := parseFile("other.yaml", "apiVersion: v1\nkind: Secret\nmetadata: {name: token}\n")
+
+	kept, err := KeepLast([]File{base, overrides, other})
+	if err != nil {
+		t.Fatalf("KeepLast: %v", err)
+	}
+	if len(kept) != 3 {
+		t.Fatalf("KeepLast returned %d files, want 3", len(kept))
+	}
+
+	// The first definition is dropped and base.yaml serialized again without it
+	if len(kept[0].Resources) != 1 || kept[0].Resources[0].Kind != "Service" {
+		t.Errorf("base.yaml keeps %v, want the Service only", kept[0].Resources)
+	}
+	reparsed, err := Parse("base.yaml", kept[0].Content)
+	if err != nil || len(reparsed) != 1 || reparsed[0].Ref() != "Service/default/web" {
+		t.Errorf("base.yaml content = %q, want the Service only", kept[0].Content)
+	}
+	// Files that keep all their resources are unchanged
+	for i, f := range []File{overrides, other} {
+		if string(kept[i+1].Content) != string(f.Content) || len(kept[i+1].Resources) != 1 {
+			t.Errorf("%s changed to %q", f.Path, kept[i+1].Content)
+		}
+	}
+	if FindDuplicates(kept) != nil {
+		t.Errorf("duplicates left after KeepLast: %v", FindDuplicates(kept))
+	}
+	// The input is not modified
+	if len(base.Resources) != 2 {
+		t.Errorf("KeepLast modified its input")
+	}
+}
+
+func TestKeepLastLeavesUnparsedFiles(t *testing.T) {
+	broken := parseFile("broken.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+data: {mode: slow}
+---
+kind: [unclosed
+`)
+	if broken.ParseErr == nil || len(broken.Resources) != 1 {
+		t.Fatalf("broken.yaml parsed to %v, %v, want one resource and an error", broken.Resources, broken.ParseErr)
+	}
+	overrides := parseFile("overrides.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: settings}\ndata: {mode: fast}\n")
+
+	kept, err := KeepLast([]File{broken, overrides})
+	if err != nil {
+		t.Fatalf("KeepLast: %v", err)
+	}
+	if string(kept[0].Content) != string(broken.Content) || len(kept[0].Resources) != 1 || kept[0].ParseErr == nil {
+		t.Errorf("broken.yaml changed to %q with %d resources", kept[0].Content, len(kept[0].Resources))
+	}
+	if string(kept[1].Content) != string(overrides.Content) {
+		t.Errorf("overrides.yaml changed to %q", kept[1].Content)
+	}
+}
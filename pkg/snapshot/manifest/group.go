@@ -0,0 +1,143 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Ways manifests can be split into groups
+const (
+	SplitByDir       = "dir"
+	SplitByFile      = "file"
+	SplitByNamespace = "namespace"
+	SplitByLabel     = "label"
+)
+
+// Group is a set of resources uploaded as one design
+type Group struct {
+	Name string
+	// Sources are the files the resources were read from
+	Sources   []string
+	Resources []Resource
+	// Content is the combined manifest of the group
+	Content string
+
+	// documents collects the manifests joined into Content
+	documents []string
+}
+
+// ParseSplitBy parses a --split-by value: dir, file, namespace or label=<key>.
+// It returns the kind of split and the label key.
+func ParseSplitBy(value string) (string, string, error) {
+	kind, key, hasKey := strings.Cut(value, "=")
+	switch kind {
+	case SplitByDir, SplitByFile, SplitByNamespace:
+		if !hasKey {
+			return kind, "", nil
+		}
+	case SplitByLabel:
+		if key != "" {
+			return kind, key, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid split '%s': must be one of dir, file, namespace, label=<key>", value)
+}
+
+// GroupFiles splits the files loaded from root into groups, in the order the groups first appear.
+//   - dir groups files by the top-level directory below root; files directly in root form the group "."
+//   - file makes a group of each file
+//   - namespace groups resources by metadata.namespace, with "default" for resources without one
+//   - label groups resources by the value of the label key, with "unlabeled" for resources without it
+func GroupFiles(files []File, root, kind, labelKey string) ([]Group, error) {
+	switch kind {
+	case SplitByDir, SplitByFile:
+		return groupFilesBy(files, func(f File) string {
+			rel, err := filepath.Rel(root, f.Path)
+			if err != nil || rel == "." {
+				rel = filepath.Base(f.Path)
+			}
+			if kind == SplitByFile {
+				return filepath.ToSlash(rel)
+			}
+			dir, _, found := strings.Cut(filepath.ToSlash(rel), "/")
+			if !found {
+				return "."
+			}
+			return dir
+		}), nil
+	case SplitByNamespace:
+		return groupResourcesBy(files, func(r Resource) string {
+			if r.Namespace == "" {
+				return "default"
+			}
+			return r.Namespace
+		})
+	case SplitByLabel:
+		return groupResourcesBy(files, func(r Resource) string {
+			if value, ok := r.Labels[labelKey]; ok && value != "" {
+				return value
+			}
+			return "unlabeled"
+		})
+	}
+	return nil, fmt.Errorf("unknown split '%s'", kind)
+}
+
+// groupFilesBy groups whole files, keeping their original content
+func groupFilesBy(files []File, key func(File) string) []Group {
+	var groups []*Group
+	index := make(map[string]*Group)
+	for _, f := range files {
+		name := key(f)
+		g, ok := index[name]
+		if !ok {
+			g = &Group{Name: name}
+			index[name] = g
+			groups = append(groups, g)
+		}
+		g.documents = append(g.documents, string(f.Content))
+		g.Sources = append(g.Sources, f.Path)
+		g.Resources = append(g.Resources, f.Resources...)
+	}
+	return finishGroups(groups)
+}
+
+// groupResourcesBy groups individual resources, serializing each group's resources again
+func groupResourcesBy(files []File, key func(Resource) string) ([]Group, error) {
+	var groups []*Group
+	index := make(map[string]*Group)
+	for _, f := range files {
+		for _, r := range f.Resources {
+			name := key(r)
+			g, ok := index[name]
+			if !ok {
+				g = &Group{Name: name}
+				index[name] = g
+				groups = append(groups, g)
+			}
+
+			data, err := MarshalObject(r.Object)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", r.Source, r.Line, err)
+			}
+			g.documents = append(g.documents, string(data))
+			if len(g.Sources) == 0 || g.Sources[len(g.Sources)-1] != r.Source {
+				g.Sources = append(g.Sources, r.Source)
+			}
+			g.Resources = append(g.Resources, r)
+		}
+	}
+	return finishGroups(groups), nil
+}
+
+// finishGroups joins the documents of each group into its content
+func finishGroups(groups []*Group) []Group {
+	result := make([]Group, len(groups))
+	for i, g := range groups {
+		g.Content = strings.Join(g.documents, "\n---\n")
+		g.documents = nil
+		result[i] = *g
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package manifest
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSplitBy(t *testing.T) {
+	tests := []struct {
+		value, kind, key string
+		ok               bool
+	}{
+		{"dir", SplitByDir, "", true},
+		{"file", SplitByFile, "", true},
+		{"namespace", SplitByNamespace, "", true},
+		{"label=app.kubernetes.io/part-of", SplitByLabel, "app.kubernetes.io/part-of", true},
+		{"label", "", "", false},
+		{"label=", "", "", false},
+		{"dir=x", "", "", false},
+		{"kind", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		kind, key, err := ParseSplitBy(tt.value)
+		if kind != tt.kind || key != tt.key || (err == nil) != tt.ok {
+			t.Errorf("ParseSplitBy(%q) = %q, %q, %v", tt.value, kind, key, err)
+		}
+	}
+}
+
+// groupSummary describes each group as name: sources: resource names
+func groupSummary(root string, groups []Group) []string {
+	var out []string
+	for _, g := range groups {
+		var sources, names []string
+		for _, s := range g.Sources {
+			rel, _ := filepath.Rel(root, s)
+			sources = append(sources, filepath.ToSlash(rel))
+		}
+		for _, r := range g.Resources {
+			names = append(names, r.Name)
+		}
+		out = append(out, g.Name+": "+strings.Join(sources, ",")+": "+strings.Join(names, ","))
+	}
+	return out
+}
+
+func TestGroupFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"base.yaml": `apiVersion: v1
+kind: Namespace
+metadata: {name: shop}
+`,
+		"api/deploy.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata: {name: api, namespace: shop, labels: {tier: backend}}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: api-config, labels: {tier: ""}}
+`,
+		"api/v2/deploy.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata: {name: api-v2, namespace: shop, labels: {tier: backend}}
+`,
+		"web/deploy.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: shop, labels: {tier: frontend}}
+`,
+	})
+	files, err := Load(root, LoadOptions{Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		split string
+		want  []string
+	}{
+		{
+			// Groups appear in the order of their first file
+			split: "dir",
+			want: []string{
+				"api: api/deploy.yaml,api/v2/deploy.yaml: api,api-config,api-v2",
+				".: base.yaml: shop",
+				"web: web/deploy.yaml: web",
+			},
+		},
+		{
+			split: "file",
+			want: []string{
+				"api/deploy.yaml: api/deploy.yaml: api,api-config",
+				"api/v2/deploy.yaml: api/v2/deploy.yaml: api-v2",
+				"base.yaml: base.yaml: shop",
+				"web/deploy.yaml: web/deploy.yaml: web",
+			},
+		},
+		{
+			// Cluster-scoped resources and resources without a namespace are in default
+			split: "namespace",
+			want: []string{
+				"shop: api/deploy.yaml,api/v2/deploy.yaml,web/deploy.yaml: api,api-v2,web",
+				"default: api/deploy.yaml,base.yaml: api-config,shop",
+			},
+		},
+		{
+			// An empty label value counts as unlabeled
+			split: "label=tier",
+			want: []string{
+				"backend: api/deploy.yaml,api/v2/deploy.yaml: api,api-v2",
+				"unlabeled: api/deploy.yaml,base.yaml: api-config,shop",
+				"frontend: web/deploy.yaml: web",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.split, func(t *testing.T) {
+			kind, key, err := ParseSplitBy(tt.split)
+			if err != nil {
+				t.Fatal(err)
+			}
+			groups, err := GroupFiles(files, root, kind, key)
+			if err != nil {
+				t.Fatalf("GroupFiles: %v", err)
+			}
+			if got := groupSummary(root, groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groups:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			// The content of each group parses back to its resources
+			for _, g := range groups {
+				resources, err := Parse(g.Name, []byte(g.Content))
+				if err != nil {
+					t.Fatalf("group %s: %v", g.Name, err)
+				}
+				if len(resources) != len(g.Resources) {
+					t.Errorf("group %s content has %d resources, want %d", g.Name, len(resources), len(g.Resources))
+				}
+				for i := range resources {
+					if resources[i].Ref() != g.Resources[i].Ref() {
+						t.Errorf("group %s content resource %d is %s, want %s", g.Name, i, resources[i].Ref(), g.Resources[i].Ref())
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := GroupFiles(files, root, "kind", ""); err == nil {
+		t.Error("GroupFiles with an unknown split succeeded, want an error")
+	}
+}
+
+func TestGroupFilesSingleFile(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"app.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: a}\n"})
+	path := filepath.Join(root, "app.yaml")
+	files, err := Load(path, LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A file given as the root is grouped by its own name
+	for kind, want := range map[string]string{SplitByFile: "app.yaml", SplitByDir: "."} {
+		groups, err := GroupFiles(files, path, kind, "")
+		if err != nil || len(groups) != 1 || groups[0].Name != want {
+			t.Errorf("GroupFiles by %s = %v, %v, want one group %s", kind, groups, err, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hash returns a hash of a multi-document manifest that does not change with formatting,
+// comments, key order or document order. Manifests that are not valid YAML are hashed as is.
+func Hash(content []byte) string {
+	docs, err := canonicalDocuments(content)
+	if err != nil {
+		sum := sha256.Sum256(content)
+		return fmt.Sprintf("%x", sum)
+	}
+	sort.Strings(docs)
+
+	h := sha256.New()
+	for _, doc := range docs {
+		// Length prefixes keep document boundaries unambiguous
+		fmt.Fprintf(h, "%d:%s", len(doc), doc)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// canonicalDocuments returns each non-empty document of the manifest encoded as JSON,
+// which sorts object keys
+func canonicalDocuments(content []byte) ([]string, error) {
+	var docs []string
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, string(data))
+	}
+}
@@ -0,0 +1,154 @@
+package manifest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// File is a manifest file read from disk
+type File struct {
+	Path    string
+	Content []byte
+	// Resources are the objects parsed from Content
+	Resources []Resource
+	// ParseErr is set if Content could not be fully parsed
+	ParseErr error
+}
+
+// LoadOptions controls how manifest files are found and read
+type LoadOptions struct {
+	// Recursive descends into subdirectories
+	Recursive bool
+	// Workers is the number of files read and parsed concurrently, defaulting to the number of CPUs
+	Workers int
+	// MaxFiles stops loading if more files are found, if greater than zero
+	MaxFiles int
+	// MaxBytes stops loading if the files are larger in total, if greater than zero
+	MaxBytes int64
+}
+
+// LimitError reports that the manifests exceed LoadOptions.MaxFiles or MaxBytes
+type LimitError struct {
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("manifests exceed the limit of %d %s", e.Max, e.Limit)
+}
+
+// IsManifestFile reports whether path has a YAML extension
+func IsManifestFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Find returns the manifest files at path in lexical order. A file path is returned as is;
+// a directory is searched for YAML files. The limits are checked while searching so
+// that a huge directory tree stops the run early.
+func Find(path string, opts LoadOptions) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if opts.MaxBytes > 0 && info.Size() > opts.MaxBytes {
+			return nil, &LimitError{Limit: "bytes", Max: opts.MaxBytes}
+		}
+		return []string{path}, nil
+	}
+
+	var paths []string
+	var total int64
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && !opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !IsManifestFile(p) {
+			return nil
+		}
+
+		if opts.MaxFiles > 0 && len(paths) >= opts.MaxFiles {
+			return &LimitError{Limit: "files", Max: int64(opts.MaxFiles)}
+		}
+		if opts.MaxBytes > 0 {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if total += info.Size(); total > opts.MaxBytes {
+				return &LimitError{Limit: "bytes", Max: opts.MaxBytes}
+			}
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	return paths, err
+}
+
+// Load finds the manifest files at path, then reads and parses them with a bounded
+// pool of workers. Files are returned in the order Find returns them, regardless of
+// the order in which workers finish. Read errors abort loading; parse errors are
+// recorded on the file.
+func Load(path string, opts LoadOptions) ([]File, error) {
+	paths, err := Find(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	files := make([]File, len(paths))
+	errs := make([]error, len(paths))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				files[i], errs[i] = loadFile(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// loadFile reads and parses a single manifest file
+func loadFile(path string) (File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	resources, parseErr := Parse(path, content)
+	return File{Path: path, Content: content, Resources: resources, ParseErr: parseErr}, nil
+}
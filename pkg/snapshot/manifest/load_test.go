@@ -0,0 +1,164 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the files below dir, creating directories as needed
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func configMap(name string, padding int) string {
+	return fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata: {name: %s}\ndata: {v: %q}\n", name, strings.Repeat("x", padding))
+}
+
+func TestLoadKeepsInputOrder(t *testing.T) {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	var want []string
+	for i := 0; i < 64; i++ {
+		name := fmt.Sprintf("cm-%02d", i)
+		// Earlier files are larger, so workers tend to finish them last
+		files[name+".yaml"] = configMap(name, (64-i)*256)
+		want = append(want, name)
+	}
+	writeFiles(t, dir, files)
+
+	for _, workers := range []int{0, 1, 8, 100} {
+		loaded, err := Load(dir, LoadOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("Load with %d workers: %v", workers, err)
+		}
+		var got []string
+		for i, f := range loaded {
+			if f.Path != filepath.Join(dir, want[i]+".yaml") || len(f.Resources) != 1 {
+				t.Fatalf("Load with %d workers: file %d is %s with %d resources", workers, i, f.Path, len(f.Resources))
+			}
+			got = append(got, f.Resources[0].Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Load with %d workers = %v, want %v", workers, got, want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"b.yaml":          configMap("b", 0),
+		"a.YML":           configMap("a", 0),
+		"notes.txt":       "not a manifest",
+		"sub/c.yaml":      configMap("c", 0),
+		"sub/deep/d.yaml": configMap("d", 0),
+	})
+
+	tests := []struct {
+		name string
+		path string
+		opts LoadOptions
+		want []string
+	}{
+		{"directory", dir, LoadOptions{}, []string{"a.YML", "b.yaml"}},
+		{"recursive", dir, LoadOptions{Recursive: true}, []string{"a.YML", "b.yaml", "sub/c.yaml", "sub/deep/d.yaml"}},
+		// A file is used as is, whatever its extension
+		{"file", filepath.Join(dir, "notes.txt"), LoadOptions{}, []string{"notes.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := Find(tt.path, tt.opts)
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			var got []string
+			for _, p := range paths {
+				rel, _ := filepath.Rel(dir, p)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Find(filepath.Join(dir, "missing"), LoadOptions{}); err == nil {
+		t.Error("Find of a missing path succeeded, want an error")
+	}
+}
+
+func TestLoadLimits(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml":     configMap("a", 100),
+		"b.yaml":     configMap("b", 100),
+		"sub/c.yaml": configMap("c", 100),
+	})
+	size := int64(len(configMap("a", 100)))
+
+	tests := []struct {
+		name  string
+		path  string
+		opts  LoadOptions
+		limit string
+	}{
+		{"files within the limit", dir, LoadOptions{Recursive: true, MaxFiles: 3}, ""},
+		{"too many files", dir, LoadOptions{Recursive: true, MaxFiles: 2}, "files"},
+		// Files in skipped subdirectories do not count
+		{"files not searched", dir, LoadOptions{MaxFiles: 2}, ""},
+		{"bytes within the limit", dir, LoadOptions{Recursive: true, MaxBytes: 3 * size}, ""},
+		{"too many bytes", dir, LoadOptions{Recursive: true, MaxBytes: 3*size - 1}, "bytes"},
+		{"file too large", filepath.Join(dir, "a.yaml"), LoadOptions{MaxBytes: size - 1}, "bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Load(tt.path, tt.opts)
+			if tt.limit == "" {
+				if err != nil {
+					t.Errorf("Load: %v", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != tt.limit || files != nil {
+				t.Fatalf("Load = %d files, %v, want the %s limit exceeded", len(files), err, tt.limit)
+			}
+			max := int64(tt.opts.MaxFiles)
+			if tt.limit == "bytes" {
+				max = tt.opts.MaxBytes
+			}
+			if limitErr.Max != max {
+				t.Errorf("limit = %d, want %d", limitErr.Max, max)
+			}
+		})
+	}
+}
+
+func TestLoadRecordsParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": configMap("a", 0),
+		"b.yaml": "kind: [unclosed\n",
+	})
+	files, err := Load(dir, LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(files) != 2 || files[0].ParseErr != nil || len(files[0].Resources) != 1 || files[1].ParseErr == nil {
+		t.Errorf("Load = %+v, want the parse error on b.yaml only", files)
+	}
+}
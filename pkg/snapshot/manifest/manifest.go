@@ -0,0 +1,160 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Resource represents a single Kubernetes object parsed from a manifest
+type Resource struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+	Labels     map[string]string
+	Object     map[string]interface{}
+	// Source is the file the resource was read from
+	Source string
+	// Line is the line within Source where the resource document starts
+	Line int
+	// Node is the YAML node of the object, used to find the lines of its fields.
+	// It is nil for resources that were not read from a manifest file.
+	Node *yaml.Node
+}
+
+// Parse parses a multi-document YAML manifest into resources.
+// Empty documents are skipped and items of a v1 List are expanded.
+func Parse(source string, data []byte) ([]Resource, error) {
+	var resources []Resource
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return resources, fmt.Errorf("%s: %w", source, err)
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err := node.Decode(&obj); err != nil {
+			return resources, fmt.Errorf("%s:%d: %w", source, node.Content[0].Line, err)
+		}
+		if obj == nil {
+			continue
+		}
+
+		resources = append(resources, newResources(source, node.Content[0], obj)...)
+	}
+
+	return resources, nil
+}
+
+// newResources builds resources from a decoded document, expanding List kinds
+func newResources(source string, node *yaml.Node, obj map[string]interface{}) []Resource {
+	kind, _ := obj["kind"].(string)
+	if items, ok := obj["items"].([]interface{}); ok && kind == "List" {
+		itemNodes := childNode(node, "items")
+		var resources []Resource
+		for i, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			r := newResource(source, node.Line, m)
+			if itemNodes != nil && i < len(itemNodes.Content) {
+				r.Node = itemNodes.Content[i]
+				r.Line = r.Node.Line
+			}
+			resources = append(resources, r)
+		}
+		return resources
+	}
+	r := newResource(source, node.Line, obj)
+	r.Node = node
+	return []Resource{r}
+}
+
+// FromObject builds a resource from a Kubernetes object that was not read from a manifest
+// file, such as a design component. source describes where the object came from.
+func FromObject(source string, obj map[string]interface{}) Resource {
+	return newResource(source, 0, obj)
+}
+
+// newResource extracts identifying fields from a decoded object
+func newResource(source string, line int, obj map[string]interface{}) Resource {
+	r := Resource{Object: obj, Source: source, Line: line}
+	r.APIVersion, _ = obj["apiVersion"].(string)
+	r.Kind, _ = obj["kind"].(string)
+
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		r.Name, _ = metadata["name"].(string)
+		r.Namespace, _ = metadata["namespace"].(string)
+		if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+			r.Labels = make(map[string]string, len(labels))
+			for k, v := range labels {
+				r.Labels[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return r
+}
+
+// CountByKind returns the number of resources of each kind
+func CountByKind(resources []Resource) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range resources {
+		kind := r.Kind
+		if kind == "" {
+			kind = "Unknown"
+		}
+		counts[kind]++
+	}
+	return counts
+}
+
+// LineOf returns the line of the field at path, e.g. spec, template, spec, containers, 0,
+// where numbers index sequences. If the field is not in the manifest, the line of its
+// closest parent is returned.
+func (r Resource) LineOf(path []string) int {
+	line := r.Line
+	node := r.Node
+	for _, key := range path {
+		if node == nil {
+			break
+		}
+		node = childNode(node, key)
+		if node != nil {
+			line = node.Line
+		}
+	}
+	return line
+}
+
+// childNode returns the value of key in a mapping node, or the item at index key in a
+// sequence node, or nil
+func childNode(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
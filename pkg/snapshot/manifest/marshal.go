@@ -0,0 +1,56 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalObject serializes a Kubernetes object as YAML with apiVersion, kind and metadata first
+func MarshalObject(obj map[string]interface{}) ([]byte, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		if k != "apiVersion" && k != "kind" && k != "metadata" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	keys = append([]string{"apiVersion", "kind", "metadata"}, keys...)
+
+	for _, k := range keys {
+		value := &yaml.Node{}
+		if err := value.Encode(obj[k]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, value)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalResources serializes the resources as a multi-document manifest
+func MarshalResources(resources []Resource) (string, error) {
+	docs := make([]string, 0, len(resources))
+	for _, r := range resources {
+		data, err := MarshalObject(r.Object)
+		if err != nil {
+			return "", fmt.Errorf("%s:%d: %w", r.Source, r.Line, err)
+		}
+		docs = append(docs, string(data))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
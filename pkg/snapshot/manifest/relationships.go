@@ -0,0 +1,328 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Types of relationships inferred between resources
+const (
+	// RelNetwork is traffic routing: a Service selecting pods, an Ingress routing to a Service
+	RelNetwork = "network"
+	// RelMount is a workload using a ConfigMap, Secret or PersistentVolumeClaim
+	RelMount = "mount"
+	// RelStorage is a PersistentVolumeClaim bound to a PersistentVolume or StorageClass
+	RelStorage = "storage"
+	// RelPermission is a workload running as a ServiceAccount, or a binding granting a role
+	RelPermission = "permission"
+	// RelScale is an autoscaler or disruption budget targeting a workload
+	RelScale = "scale"
+	// RelParent is a namespaced resource belonging to a Namespace defined in the manifests
+	RelParent = "parent"
+)
+
+// Relationship is a reference from one resource to another, inferred from their specs
+// the way Kanvas draws edges between components. From and To index the resources
+// the relationships were inferred from.
+type Relationship struct {
+	From int
+	To   int
+	Type string
+}
+
+// clusterScopedKinds are the built-in kinds without a namespace
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"StorageClass":                   true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CustomResourceDefinition":       true,
+	"PriorityClass":                  true,
+	"IngressClass":                   true,
+	"RuntimeClass":                   true,
+	"CSIDriver":                      true,
+	"VolumeAttachment":               true,
+	"APIService":                     true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+}
+
+// IsClusterScoped reports whether resources of the kind have no namespace
+func IsClusterScoped(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// EffectiveNamespace returns the namespace the resource is created in:
+// empty for cluster-scoped kinds, default if the manifest does not set one
+func (r Resource) EffectiveNamespace() string {
+	if IsClusterScoped(r.Kind) {
+		return ""
+	}
+	if r.Namespace == "" {
+		return "default"
+	}
+	return r.Namespace
+}
+
+// Ref identifies the resource by kind, namespace and name, e.g. Deployment/default/web,
+// or by kind and name for cluster-scoped resources, e.g. Namespace/shop
+func (r Resource) Ref() string {
+	return refKey(r.Kind, r.EffectiveNamespace(), r.Name)
+}
+
+func refKey(kind, namespace, name string) string {
+	if namespace == "" {
+		return fmt.Sprintf("%s/%s", kind, name)
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// InferRelationships returns the relationships between the resources, sorted by From, To and Type
+func InferRelationships(resources []Resource) []Relationship {
+	index := make(map[string]int, len(resources))
+	for i, r := range resources {
+		index[r.Ref()] = i
+	}
+
+	seen := make(map[Relationship]bool)
+	var rels []Relationship
+	add := func(from int, kind, namespace, name, relType string) {
+		to, ok := index[refKey(kind, namespace, name)]
+		if !ok || name == "" || to == from {
+			return
+		}
+		rel := Relationship{From: from, To: to, Type: relType}
+		if !seen[rel] {
+			seen[rel] = true
+			rels = append(rels, rel)
+		}
+	}
+
+	for i, r := range resources {
+		ns := r.EffectiveNamespace()
+
+		if ns != "" {
+			add(i, "Namespace", "", ns, RelParent)
+		}
+
+		if spec, _, ok := podTemplate(r); ok {
+			podSpecReferences(spec, func(kind, name, relType string) { add(i, kind, ns, name, relType) })
+		}
+
+		switch r.Kind {
+		case "Service":
+			selector := stringMap(nested(r.Object, "spec", "selector"))
+			selectPods(resources, i, ns, selector, func(to int) { add(i, resources[to].Kind, ns, resources[to].Name, RelNetwork) })
+
+		case "Ingress":
+			ingressReferences(r.Object, func(kind, name, relType string) { add(i, kind, ns, name, relType) })
+
+		case "RoleBinding", "ClusterRoleBinding":
+			roleKind := nestedString(r.Object, "roleRef", "kind")
+			roleNS := ns
+			if roleKind == "ClusterRole" {
+				roleNS = ""
+			}
+			add(i, roleKind, roleNS, nestedString(r.Object, "roleRef", "name"), RelPermission)
+			for _, s := range slice(nested(r.Object, "subjects")) {
+				if nestedString(s, "kind") != "ServiceAccount" {
+					continue
+				}
+				subjectNS := nestedString(s, "namespace")
+				if subjectNS == "" {
+					subjectNS = ns
+				}
+				add(i, "ServiceAccount", subjectNS, nestedString(s, "name"), RelPermission)
+			}
+
+		case "HorizontalPodAutoscaler":
+			add(i, nestedString(r.Object, "spec", "scaleTargetRef", "kind"), ns, nestedString(r.Object, "spec", "scaleTargetRef", "name"), RelScale)
+
+		case "PodDisruptionBudget":
+			selector := stringMap(nested(r.Object, "spec", "selector", "matchLabels"))
+			selectPods(resources, i, ns, selector, func(to int) { add(i, resources[to].Kind, ns, resources[to].Name, RelScale) })
+
+		case "PersistentVolumeClaim":
+			add(i, "PersistentVolume", "", nestedString(r.Object, "spec", "volumeName"), RelStorage)
+			add(i, "StorageClass", "", nestedString(r.Object, "spec", "storageClassName"), RelStorage)
+		}
+	}
+
+	sort.Slice(rels, func(a, b int) bool {
+		if rels[a].From != rels[b].From {
+			return rels[a].From < rels[b].From
+		}
+		if rels[a].To != rels[b].To {
+			return rels[a].To < rels[b].To
+		}
+		return rels[a].Type < rels[b].Type
+	})
+	return rels
+}
+
+// podTemplate returns the pod spec and pod labels of a workload
+func podTemplate(r Resource) (map[string]interface{}, map[string]string, bool) {
+	var template map[string]interface{}
+	switch r.Kind {
+	case "Pod":
+		template = r.Object
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		template = mapValue(nested(r.Object, "spec", "template"))
+	case "CronJob":
+		template = mapValue(nested(r.Object, "spec", "jobTemplate", "spec", "template"))
+	}
+	spec := mapValue(nested(template, "spec"))
+	if spec == nil {
+		return nil, nil, false
+	}
+	return spec, stringMap(nested(template, "metadata", "labels")), true
+}
+
+// selectPods calls match for each workload in the namespace whose pods carry all selector labels
+func selectPods(resources []Resource, from int, namespace string, selector map[string]string, match func(to int)) {
+	if len(selector) == 0 {
+		return
+	}
+	for i, r := range resources {
+		if i == from || r.EffectiveNamespace() != namespace {
+			continue
+		}
+		_, labels, ok := podTemplate(r)
+		if !ok {
+			continue
+		}
+		matches := true
+		for k, v := range selector {
+			if labels[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			match(i)
+		}
+	}
+}
+
+// podSpecReferences reports the ConfigMaps, Secrets, PersistentVolumeClaims and
+// ServiceAccount a pod spec refers to
+func podSpecReferences(spec map[string]interface{}, ref func(kind, name, relType string)) {
+	ref("ServiceAccount", nestedString(spec, "serviceAccountName"), RelPermission)
+	for _, s := range slice(nested(spec, "imagePullSecrets")) {
+		ref("Secret", nestedString(s, "name"), RelMount)
+	}
+
+	for _, v := range slice(nested(spec, "volumes")) {
+		ref("ConfigMap", nestedString(v, "configMap", "name"), RelMount)
+		ref("Secret", nestedString(v, "secret", "secretName"), RelMount)
+		ref("PersistentVolumeClaim", nestedString(v, "persistentVolumeClaim", "claimName"), RelMount)
+		for _, p := range slice(nested(v, "projected", "sources")) {
+			ref("ConfigMap", nestedString(p, "configMap", "name"), RelMount)
+			ref("Secret", nestedString(p, "secret", "name"), RelMount)
+		}
+	}
+
+	var containers []interface{}
+	containers = append(containers, slice(nested(spec, "containers"))...)
+	containers = append(containers, slice(nested(spec, "initContainers"))...)
+	for _, c := range containers {
+		for _, e := range slice(nested(c, "env")) {
+			ref("ConfigMap", nestedString(e, "valueFrom", "configMapKeyRef", "name"), RelMount)
+			ref("Secret", nestedString(e, "valueFrom", "secretKeyRef", "name"), RelMount)
+		}
+		for _, e := range slice(nested(c, "envFrom")) {
+			ref("ConfigMap", nestedString(e, "configMapRef", "name"), RelMount)
+			ref("Secret", nestedString(e, "secretRef", "name"), RelMount)
+		}
+	}
+}
+
+// ingressReferences reports the Services an Ingress routes to and its TLS Secrets,
+// for both networking.k8s.io/v1 and the older extensions/v1beta1 backends
+func ingressReferences(obj map[string]interface{}, ref func(kind, name, relType string)) {
+	backend := func(b interface{}) {
+		ref("Service", nestedString(b, "service", "name"), RelNetwork)
+		ref("Service", nestedString(b, "serviceName"), RelNetwork)
+	}
+	backend(nested(obj, "spec", "defaultBackend"))
+	backend(nested(obj, "spec", "backend"))
+	for _, rule := range slice(nested(obj, "spec", "rules")) {
+		for _, p := range slice(nested(rule, "http", "paths")) {
+			backend(nested(p, "backend"))
+		}
+	}
+	for _, t := range slice(nested(obj, "spec", "tls")) {
+		ref("Secret", nestedString(t, "secretName"), RelMount)
+	}
+}
+
+// nested returns the value at the path of map keys, or nil
+func nested(v interface{}, keys ...string) interface{} {
+	for _, k := range keys {
+		m := mapValue(v)
+		if m == nil {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// nestedString returns the string at the path of map keys, or an empty string
+func nestedString(v interface{}, keys ...string) string {
+	s, _ := nested(v, keys...).(string)
+	return s
+}
+
+func mapValue(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func slice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+// stringMap converts a map of scalars, such as labels, to strings
+func stringMap(v interface{}) map[string]string {
+	m := mapValue(v)
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		out[k] = fmt.Sprint(val)
+	}
+	return out
+}
+
+// WithReferences returns the given resource indexes and those of all resources they
+// reference, directly or through other resources, in ascending order
+func WithReferences(rels []Relationship, start []int) []int {
+	out := make(map[int][]int)
+	for _, rel := range rels {
+		out[rel.From] = append(out[rel.From], rel.To)
+	}
+
+	selected := make(map[int]bool)
+	queue := append([]int(nil), start...)
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if selected[i] {
+			continue
+		}
+		selected[i] = true
+		queue = append(queue, out[i]...)
+	}
+
+	indexes := make([]int, 0, len(selected))
+	for i := range selected {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
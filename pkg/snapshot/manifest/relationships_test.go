@@ -0,0 +1,263 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+// parseTest parses a multi-document manifest
+func parseTest(t *testing.T, data string) []Resource {
+	t.Helper()
+	resources, err := Parse("test.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resources
+}
+
+// relationshipRefs describes the relationships by resource reference
+func relationshipRefs(resources []Resource, rels []Relationship) []string {
+	out := make([]string, 0, len(rels))
+	for _, rel := range rels {
+		out = append(out, resources[rel.From].Ref()+" -"+rel.Type+"-> "+resources[rel.To].Ref())
+	}
+	return out
+}
+
+const appManifests = `
+apiVersion: v1
+kind: Namespace
+metadata: {name: shop}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {matchLabels: {app: web}}
+  template:
+    metadata: {labels: {app: web, tier: frontend}}
+    spec:
+      serviceAccountName: web
+      imagePullSecrets: [{name: registry}]
+      containers:
+        - name: web
+          image: web:1
+          envFrom: [{configMapRef: {name: settings}}]
+          env:
+            - name: TOKEN
+              valueFrom: {secretKeyRef: {name: token, key: value}}
+      volumes:
+        - name: data
+          persistentVolumeClaim: {claimName: data}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {app: web}
+  ports: [{port: 80}]
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata: {name: web, namespace: shop}
+spec:
+  tls: [{secretName: tls}]
+  rules:
+    - http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend: {service: {name: web, port: {number: 80}}}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: shop}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: token, namespace: shop}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: registry, namespace: shop}
+---
+apiVersion: v1
+kind: Secret
+metadata: {name: tls, namespace: shop}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata: {name: web, namespace: shop}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata: {name: web-reader, namespace: shop}
+roleRef: {kind: ClusterRole, name: reader}
+subjects: [{kind: ServiceAccount, name: web}]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata: {name: reader}
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata: {name: data, namespace: shop}
+spec: {storageClassName: fast}
+---
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata: {name: fast}
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata: {name: web, namespace: shop}
+spec:
+  scaleTargetRef: {apiVersion: apps/v1, kind: Deployment, name: web}
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {matchLabels: {tier: frontend}}
+`
+
+func TestInferRelationships(t *testing.T) {
+	resources := parseTest(t, appManifests)
+	got := relationshipRefs(resources, InferRelationships(resources))
+	want := []string{
+		"Deployment/shop/web -parent-> Namespace/shop",
+		"Deployment/shop/web -mount-> ConfigMap/shop/settings",
+		"Deployment/shop/web -mount-> Secret/shop/token",
+		"Deployment/shop/web -mount-> Secret/shop/registry",
+		"Deployment/shop/web -permission-> ServiceAccount/shop/web",
+		"Deployment/shop/web -mount-> PersistentVolumeClaim/shop/data",
+		"Service/shop/web -parent-> Namespace/shop",
+		"Service/shop/web -network-> Deployment/shop/web",
+		"Ingress/shop/web -parent-> Namespace/shop",
+		"Ingress/shop/web -network-> Service/shop/web",
+		"Ingress/shop/web -mount-> Secret/shop/tls",
+		"ConfigMap/shop/settings -parent-> Namespace/shop",
+		"Secret/shop/token -parent-> Namespace/shop",
+		"Secret/shop/registry -parent-> Namespace/shop",
+		"Secret/shop/tls -parent-> Namespace/shop",
+		"ServiceAccount/shop/web -parent-> Namespace/shop",
+		"RoleBinding/shop/web-reader -parent-> Namespace/shop",
+		"RoleBinding/shop/web-reader -permission-> ServiceAccount/shop/web",
+		"RoleBinding/shop/web-reader -permission-> ClusterRole/reader",
+		"PersistentVolumeClaim/shop/data -parent-> Namespace/shop",
+		"PersistentVolumeClaim/shop/data -storage-> StorageClass/fast",
+		"HorizontalPodAutoscaler/shop/web -parent-> Namespace/shop",
+		"HorizontalPodAutoscaler/shop/web -scale-> Deployment/shop/web",
+		"PodDisruptionBudget/shop/web -parent-> Namespace/shop",
+		"PodDisruptionBudget/shop/web -scale-> Deployment/shop/web",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relationships:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestInferRelationshipsNamespaces(t *testing.T) {
+	// The omitted namespace is default, and references never cross namespaces
+	resources := parseTest(t, `
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {selector: {app: web}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: default}
+spec:
+  template:
+    metadata: {labels: {app: web}}
+    spec:
+      containers: [{name: web, envFrom: [{configMapRef: {name: settings}}]}]
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: other}
+spec:
+  template:
+    metadata: {labels: {app: web}}
+    spec: {containers: [{name: web}]}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings, namespace: other}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: all}
+spec: {selector: {}}
+`)
+	got := relationshipRefs(resources, InferRelationships(resources))
+	want := []string{"Service/default/web -network-> Deployment/default/web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relationships = %v, want %v", got, want)
+	}
+}
+
+func TestWithReferences(t *testing.T) {
+	resources := parseTest(t, appManifests)
+	rels := InferRelationships(resources)
+	index := make(map[string]int, len(resources))
+	for i, r := range resources {
+		index[r.Ref()] = i
+	}
+	refs := func(indexes []int) []string {
+		out := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			out = append(out, resources[i].Ref())
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		start []string
+		want  []string
+	}{
+		{
+			name:  "nothing",
+			start: nil,
+			want:  []string{},
+		},
+		{
+			name:  "resource without references",
+			start: []string{"ClusterRole/reader"},
+			want:  []string{"ClusterRole/reader"},
+		},
+		{
+			name:  "direct references",
+			start: []string{"PersistentVolumeClaim/shop/data"},
+			want:  []string{"Namespace/shop", "PersistentVolumeClaim/shop/data", "StorageClass/fast"},
+		},
+		{
+			// The Ingress reaches the Deployment through the Service, and its references in turn
+			name:  "transitive references",
+			start: []string{"Ingress/shop/web"},
+			want: []string{
+				"Namespace/shop", "Deployment/shop/web", "Service/shop/web", "Ingress/shop/web",
+				"ConfigMap/shop/settings", "Secret/shop/token", "Secret/shop/registry", "Secret/shop/tls",
+				"ServiceAccount/shop/web", "PersistentVolumeClaim/shop/data", "StorageClass/fast",
+			},
+		},
+		{
+			// Referencing resources are not added, only referenced ones
+			name:  "referenced resource",
+			start: []string{"Secret/shop/tls", "Namespace/shop"},
+			want:  []string{"Namespace/shop", "Secret/shop/tls"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var start []int
+			for _, ref := range tt.start {
+				start = append(start, index[ref])
+			}
+			if got := refs(WithReferences(rels, start)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithReferences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
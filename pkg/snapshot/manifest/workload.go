@@ -0,0 +1,163 @@
+package manifest
+
+import "fmt"
+
+// Container is a container of a workload's pod template
+type Container struct {
+	Name  string
+	Image string
+	// Init is set for init containers
+	Init  bool
+	Ports []Port
+}
+
+// Port is a container port or a Service port
+type Port struct {
+	Name     string
+	Port     int
+	Protocol string
+	// TargetPort is the number or name of the container port a Service port forwards to
+	TargetPort string
+	// NodePort is the port opened on every node by NodePort and LoadBalancer Services
+	NodePort int
+}
+
+// String returns the port as 80/TCP, with the target port and node port of a Service port,
+// e.g. 80:8080/TCP (node port 30080)
+func (p Port) String() string {
+	s := fmt.Sprint(p.Port)
+	if p.TargetPort != "" && p.TargetPort != s {
+		s += ":" + p.TargetPort
+	}
+	protocol := p.Protocol
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	s += "/" + protocol
+	if p.NodePort != 0 {
+		s += fmt.Sprintf(" (node port %d)", p.NodePort)
+	}
+	return s
+}
+
+// Containers returns the init containers and containers of a workload's pod template,
+// or nil if the resource has none
+func (r Resource) Containers() []Container {
+	spec, _, ok := podTemplate(r)
+	if !ok {
+		return nil
+	}
+	var containers []Container
+	for _, field := range []string{"initContainers", "containers"} {
+		for _, c := range slice(nested(spec, field)) {
+			container := Container{
+				Name:  nestedString(c, "name"),
+				Image: nestedString(c, "image"),
+				Init:  field == "initContainers",
+			}
+			for _, p := range slice(nested(c, "ports")) {
+				container.Ports = append(container.Ports, Port{
+					Name:     nestedString(p, "name"),
+					Port:     intValue(nested(p, "containerPort")),
+					Protocol: nestedString(p, "protocol"),
+				})
+			}
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+// ServicePorts returns the ports of a Service
+func (r Resource) ServicePorts() []Port {
+	if r.Kind != "Service" {
+		return nil
+	}
+	var ports []Port
+	for _, p := range slice(nested(r.Object, "spec", "ports")) {
+		port := Port{
+			Name:     nestedString(p, "name"),
+			Port:     intValue(nested(p, "port")),
+			Protocol: nestedString(p, "protocol"),
+			NodePort: intValue(nested(p, "nodePort")),
+		}
+		if target := nested(p, "targetPort"); target != nil {
+			port.TargetPort = fmt.Sprint(target)
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// ServiceType returns the type of a Service, ClusterIP if not set
+func (r Resource) ServiceType() string {
+	if t := nestedString(r.Object, "spec", "type"); t != "" {
+		return t
+	}
+	return "ClusterIP"
+}
+
+// IngressRoute is a host and path an Ingress routes to a Service port
+type IngressRoute struct {
+	Host    string
+	Path    string
+	Service string
+	Port    string
+}
+
+// IngressRoutes returns the routes of an Ingress, including its default backend
+func (r Resource) IngressRoutes() []IngressRoute {
+	if r.Kind != "Ingress" {
+		return nil
+	}
+	backend := func(host, path string, b interface{}) (IngressRoute, bool) {
+		route := IngressRoute{Host: host, Path: path}
+		// networking.k8s.io/v1 and extensions/v1beta1 backends
+		if name := nestedString(b, "service", "name"); name != "" {
+			route.Service = name
+			if n := nested(b, "service", "port", "number"); n != nil {
+				route.Port = fmt.Sprint(n)
+			} else {
+				route.Port = nestedString(b, "service", "port", "name")
+			}
+		} else if name := nestedString(b, "serviceName"); name != "" {
+			route.Service = name
+			if p := nested(b, "servicePort"); p != nil {
+				route.Port = fmt.Sprint(p)
+			}
+		}
+		return route, route.Service != ""
+	}
+
+	var routes []IngressRoute
+	for _, field := range []string{"defaultBackend", "backend"} {
+		if route, ok := backend("*", "", nested(r.Object, "spec", field)); ok {
+			routes = append(routes, route)
+		}
+	}
+	for _, rule := range slice(nested(r.Object, "spec", "rules")) {
+		host := nestedString(rule, "host")
+		if host == "" {
+			host = "*"
+		}
+		for _, p := range slice(nested(rule, "http", "paths")) {
+			if route, ok := backend(host, nestedString(p, "path"), nested(p, "backend")); ok {
+				routes = append(routes, route)
+			}
+		}
+	}
+	return routes
+}
+
+// intValue converts a decoded YAML or JSON number to an int, 0 if v is not a number
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
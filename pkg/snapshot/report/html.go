@@ -0,0 +1,99 @@
+package report
+
+import (
+	"html/template"
+	"strings"
+)
+
+// mermaidScript loads Mermaid to draw the relationship graph when the report is opened.
+// Without network access the graph is shown as Mermaid source.
+const mermaidScript = "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs"
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join":   strings.Join,
+	"orDash": orDash,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Kanvas report: {{.Title}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 72em; padding: 0 1em; color: #1f2328; }
+  table { border-collapse: collapse; margin: 0.5em 0 1.5em; }
+  th, td { border: 1px solid #d0d7de; padding: 0.3em 0.8em; text-align: left; vertical-align: top; }
+  th { background: #f6f8fa; }
+  code { font-size: 0.9em; }
+  .mermaid { background: #f6f8fa; padding: 1em; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>Kanvas report: {{.Title}}</h1>
+<p>Generated by kubectl kanvas-snapshot at {{.Generated.UTC.Format "2006-01-02 15:04 UTC"}}.</p>
+<p>{{len .Resources}} resource(s) of {{.Kinds}} kind(s) in {{len .Namespaces}} namespace(s), read from {{len .Sources}} file(s).</p>
+
+<h2>Designs</h2>
+{{if .Designs}}<table>
+<tr><th>Design</th><th>Status</th><th>Snapshot</th></tr>
+{{range .Designs}}<tr><td>{{if .ViewURL}}<a href="{{.ViewURL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td>{{orDash .Status}}</td><td>{{if .SnapshotURL}}<a href="{{.SnapshotURL}}">image</a>{{else}}-{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No design was created.</p>{{end}}
+
+<h2>Inventory</h2>
+{{if .Inventory}}<table>
+<tr><th>Kind</th><th>Namespace</th><th>Count</th></tr>
+{{range .Inventory}}<tr><td>{{.Kind}}</td><td>{{orDash .Namespace}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+
+<h2>Container images</h2>
+{{if .Images}}<table>
+<tr><th>Image</th><th>Used by</th></tr>
+{{range .Images}}<tr><td><code>{{.Image}}</code></td><td>{{range $i, $u := .UsedBy}}{{if $i}}<br>{{end}}{{$u}}{{end}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+
+<h2>Services</h2>
+{{if .Services}}<table>
+<tr><th>Service</th><th>Type</th><th>Ports</th><th>Backends</th></tr>
+{{range .Services}}<tr><td>{{.Service}}</td><td>{{.Type}}</td><td>{{orDash (join .Ports ", ")}}</td><td>{{if .Backends}}{{range $i, $b := .Backends}}{{if $i}}<br>{{end}}{{$b}}{{end}}{{else}}-{{end}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+
+<h2>Ingress routes</h2>
+{{if .Routes}}<table>
+<tr><th>Ingress</th><th>Host</th><th>Path</th><th>Backend</th></tr>
+{{range .Routes}}<tr><td>{{.Ingress}}</td><td>{{.Host}}</td><td>{{orDash .Path}}</td><td>{{.Backend}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+
+<h2>Container ports</h2>
+{{if .Ports}}<table>
+<tr><th>Workload</th><th>Container</th><th>Port</th><th>Name</th></tr>
+{{range .Ports}}<tr><td>{{.Workload}}</td><td>{{.Container}}</td><td>{{.Port}}</td><td>{{orDash .Name}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+
+<h2>Relationships</h2>
+{{if not .Graph.Edges}}<p>No relationships were found between the resources.</p>
+{{end}}<pre class="mermaid">
+{{.Graph.Mermaid}}</pre>
+
+<h2>Warnings</h2>
+{{if .Warnings}}<ul>
+{{range .Warnings}}<li>{{.}}</li>
+{{end}}</ul>{{else}}<p>None.</p>{{end}}
+
+<script type="module">
+  import mermaid from "{{.Script}}";
+  mermaid.initialize({ startOnLoad: true });
+</script>
+</body>
+</html>
+`))
+
+// HTML renders the report as a standalone HTML page
+func HTML(r *Report) (string, error) {
+	var b strings.Builder
+	data := struct {
+		*content
+		Script string
+	}{build(r), mermaidScript}
+	if err := htmlTemplate.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the report as GitHub-flavored Markdown, with the relationship
+// graph as a Mermaid block
+func Markdown(r *Report) string {
+	c := build(r)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Kanvas report: %s\n\n", c.Title)
+	fmt.Fprintf(&b, "Generated by kubectl kanvas-snapshot at %s.\n\n", c.Generated.UTC().Format("2006-01-02 15:04 UTC"))
+	fmt.Fprintf(&b, "%d resource(s) of %d kind(s) in %d namespace(s), read from %d file(s).\n", len(c.Resources), c.Kinds, len(c.Namespaces), len(c.Sources))
+
+	b.WriteString("\n## Designs\n\n")
+	if len(c.Designs) == 0 {
+		b.WriteString("No design was created.\n")
+	} else {
+		table(&b, []string{"Design", "Status", "Snapshot"}, len(c.Designs), func(i int) []string {
+			d := c.Designs[i]
+			snapshot := "-"
+			if d.SnapshotURL != "" {
+				snapshot = link("image", d.SnapshotURL)
+			}
+			return []string{link(d.Name, d.ViewURL), orDash(d.Status), snapshot}
+		})
+	}
+
+	b.WriteString("\n## Inventory\n\n")
+	table(&b, []string{"Kind", "Namespace", "Count"}, len(c.Inventory), func(i int) []string {
+		row := c.Inventory[i]
+		return []string{row.Kind, orDash(row.Namespace), fmt.Sprint(row.Count)}
+	})
+
+	b.WriteString("\n## Container images\n\n")
+	table(&b, []string{"Image", "Used by"}, len(c.Images), func(i int) []string {
+		return []string{code(c.Images[i].Image), strings.Join(c.Images[i].UsedBy, "<br>")}
+	})
+
+	b.WriteString("\n## Services\n\n")
+	table(&b, []string{"Service", "Type", "Ports", "Backends"}, len(c.Services), func(i int) []string {
+		s := c.Services[i]
+		return []string{s.Service, s.Type, orDash(strings.Join(s.Ports, ", ")), orDash(strings.Join(s.Backends, "<br>"))}
+	})
+
+	b.WriteString("\n## Ingress routes\n\n")
+	table(&b, []string{"Ingress", "Host", "Path", "Backend"}, len(c.Routes), func(i int) []string {
+		rt := c.Routes[i]
+		return []string{rt.Ingress, rt.Host, orDash(rt.Path), rt.Backend}
+	})
+
+	b.WriteString("\n## Container ports\n\n")
+	table(&b, []string{"Workload", "Container", "Port", "Name"}, len(c.Ports), func(i int) []string {
+		p := c.Ports[i]
+		return []string{p.Workload, p.Container, p.Port, orDash(p.Name)}
+	})
+
+	b.WriteString("\n## Relationships\n\n")
+	if len(c.Graph.Edges) == 0 {
+		b.WriteString("No relationships were found between the resources.\n\n")
+	}
+	b.WriteString(c.Graph.Markdown())
+
+	b.WriteString("\n## Warnings\n\n")
+	if len(c.Warnings) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, w := range c.Warnings {
+		fmt.Fprintf(&b, "- %s\n", cell(w))
+	}
+	return b.String()
+}
+
+// table writes a Markdown table with a row for each of n items, or None. if n is 0
+func table(b *strings.Builder, header []string, n int, row func(i int) []string) {
+	if n == 0 {
+		b.WriteString("None.\n")
+		return
+	}
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+	for i := 0; i < n; i++ {
+		cells := row(i)
+		for j := range cells {
+			cells[j] = cell(cells[j])
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+}
+
+// cell escapes pipes and line breaks, which end a table cell
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// link returns a Markdown link, or just the text if url is empty
+func link(text, url string) string {
+	if url == "" {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+func code(s string) string {
+	return "`" + s + "`"
+}
@@ -0,0 +1,228 @@
+// Package report renders an inventory of a manifest set, with its images, exposed ports,
+// relationship graph and the designs created from it, as a Markdown or HTML document
+// that can be attached to change tickets.
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/diagram"
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// Formats of a report, chosen by the file extension
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Design is a Meshery design created from the manifests
+type Design struct {
+	Name        string
+	ViewURL     string
+	SnapshotURL string
+	// Status is the outcome of the upload, e.g. snapshot-triggered or failed
+	Status string
+}
+
+// Report is the input of a report
+type Report struct {
+	Title     string
+	Generated time.Time
+	// Sources are the manifest files the resources were read from
+	Sources   []string
+	Resources []manifest.Resource
+	Designs   []Design
+	Warnings  []string
+}
+
+// FormatOf returns the format of a report written to path
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("unsupported report file '%s': use a .md or .html file", path)
+}
+
+// Write renders the report in the format of path and writes it there
+func Write(path string, r *Report) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	content := Markdown(r)
+	if format == FormatHTML {
+		if content, err = HTML(r); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// inventoryRow counts the resources of a kind in a namespace
+type inventoryRow struct {
+	Kind      string
+	Namespace string
+	Count     int
+}
+
+// imageRow lists the containers running an image
+type imageRow struct {
+	Image string
+	// UsedBy are the workloads and containers, e.g. Deployment/shop/web (app)
+	UsedBy []string
+}
+
+// portRow is a port a container listens on
+type portRow struct {
+	Workload  string
+	Container string
+	Port      string
+	Name      string
+}
+
+// serviceRow is a Service with its ports and the workloads it selects
+type serviceRow struct {
+	Service  string
+	Type     string
+	Ports    []string
+	Backends []string
+}
+
+// routeRow is a host and path routed to a Service by an Ingress
+type routeRow struct {
+	Ingress string
+	Host    string
+	Path    string
+	Backend string
+}
+
+// content holds the sections of a report, shared by the Markdown and HTML renderers
+type content struct {
+	*Report
+	Kinds      int
+	Namespaces []string
+	Inventory  []inventoryRow
+	Images     []imageRow
+	Ports      []portRow
+	Services   []serviceRow
+	Routes     []routeRow
+	Graph      *diagram.Graph
+}
+
+// build computes the report sections from the resources
+func build(r *Report) *content {
+	c := &content{Report: r}
+	rels := manifest.InferRelationships(r.Resources)
+
+	counts := make(map[inventoryRow]int)
+	namespaces := make(map[string]bool)
+	images := make(map[string][]string)
+	for _, res := range r.Resources {
+		ns := res.EffectiveNamespace()
+		counts[inventoryRow{Kind: kindOf(res), Namespace: ns}]++
+		if ns != "" {
+			namespaces[ns] = true
+		}
+
+		for _, ct := range res.Containers() {
+			if ct.Image != "" {
+				container := ct.Name
+				if ct.Init {
+					container += ", init"
+				}
+				images[ct.Image] = append(images[ct.Image], fmt.Sprintf("%s (%s)", res.Ref(), container))
+			}
+			for _, p := range ct.Ports {
+				c.Ports = append(c.Ports, portRow{Workload: res.Ref(), Container: ct.Name, Port: p.String(), Name: p.Name})
+			}
+		}
+
+		for _, route := range res.IngressRoutes() {
+			backend := route.Service
+			if route.Port != "" {
+				backend += ":" + route.Port
+			}
+			c.Routes = append(c.Routes, routeRow{Ingress: res.Ref(), Host: route.Host, Path: route.Path, Backend: backend})
+		}
+	}
+
+	for row, n := range counts {
+		row.Count = n
+		c.Inventory = append(c.Inventory, row)
+	}
+	sort.Slice(c.Inventory, func(a, b int) bool {
+		if c.Inventory[a].Kind != c.Inventory[b].Kind {
+			return c.Inventory[a].Kind < c.Inventory[b].Kind
+		}
+		return c.Inventory[a].Namespace < c.Inventory[b].Namespace
+	})
+	c.Kinds = len(manifest.CountByKind(r.Resources))
+	for ns := range namespaces {
+		c.Namespaces = append(c.Namespaces, ns)
+	}
+	sort.Strings(c.Namespaces)
+
+	for image, usedBy := range images {
+		c.Images = append(c.Images, imageRow{Image: image, UsedBy: usedBy})
+	}
+	sort.Slice(c.Images, func(a, b int) bool { return c.Images[a].Image < c.Images[b].Image })
+
+	for i, res := range r.Resources {
+		if res.Kind != "Service" {
+			continue
+		}
+		row := serviceRow{Service: res.Ref(), Type: res.ServiceType()}
+		for _, p := range res.ServicePorts() {
+			row.Ports = append(row.Ports, p.String())
+		}
+		for _, rel := range rels {
+			if rel.From == i && rel.Type == manifest.RelNetwork {
+				row.Backends = append(row.Backends, r.Resources[rel.To].Ref())
+			}
+		}
+		c.Services = append(c.Services, row)
+	}
+
+	c.Graph = graph(r.Resources, rels)
+	return c
+}
+
+// graph draws the resources grouped by namespace, with arrows labeled by relationship type
+func graph(resources []manifest.Resource, rels []manifest.Relationship) *diagram.Graph {
+	g := &diagram.Graph{}
+	for i, res := range resources {
+		group := ""
+		if ns := res.EffectiveNamespace(); ns != "" {
+			group = "namespace " + ns
+		}
+		g.Nodes = append(g.Nodes, diagram.Node{ID: fmt.Sprintf("r%d", i), Label: kindOf(res) + "\n" + res.Name, Group: group})
+	}
+	for _, rel := range rels {
+		g.Edges = append(g.Edges, diagram.Edge{From: fmt.Sprintf("r%d", rel.From), To: fmt.Sprintf("r%d", rel.To), Label: rel.Type})
+	}
+	return g
+}
+
+func kindOf(r manifest.Resource) string {
+	if r.Kind == "" {
+		return "Unknown"
+	}
+	return r.Kind
+}
+
+// orDash returns s, or - if it is empty, for table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
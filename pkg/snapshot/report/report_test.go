@@ -0,0 +1,271 @@
+package report
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const shopManifests = `apiVersion: v1
+kind: Namespace
+metadata: {name: shop}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: shop}
+spec:
+  selector: {matchLabels: {app: web}}
+  template:
+    metadata: {labels: {app: web}}
+    spec:
+      initContainers:
+        - {name: migrate, image: "shop/web:1.2"}
+      containers:
+        - name: app
+          image: "shop/web:1.2"
+          ports:
+            - {name: http, containerPort: 8080}
+            - {containerPort: 9090, protocol: UDP}
+        - {name: proxy, image: "envoyproxy/envoy:v1.29"}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: worker, namespace: shop}
+spec:
+  selector: {matchLabels: {app: worker}}
+  template:
+    metadata: {labels: {app: worker}}
+    spec:
+      containers:
+        - {name: worker, image: "shop/worker:1.2"}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: web, namespace: shop}
+spec:
+  type: NodePort
+  selector: {app: web}
+  ports:
+    - {port: 80, targetPort: 8080, nodePort: 30080}
+---
+apiVersion: v1
+kind: Service
+metadata: {name: orphan, namespace: shop}
+spec:
+  selector: {app: none}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata: {name: web, namespace: shop}
+spec:
+  rules:
+    - host: shop.example.com
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend: {service: {name: web, port: {number: 80}}}
+`
+
+// testReport returns a report of the shop manifests
+func testReport(t *testing.T) *Report {
+	t.Helper()
+	resources, err := manifest.Parse("shop.yaml", []byte(shopManifests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Report{
+		Title:     "shop",
+		Generated: time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		Sources:   []string{"shop.yaml"},
+		Resources: resources,
+		Designs: []Design{
+			{Name: "shop", ViewURL: "https://cloud.layer5.io/catalog/content/my-designs/1", SnapshotURL: "https://example.com/shop.png", Status: "snapshot-triggered"},
+			{Name: "shop-failed", Status: "failed"},
+		},
+		Warnings: []string{"shop.yaml:3: unknown field"},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	c := build(testReport(t))
+
+	wantInventory := []inventoryRow{
+		{Kind: "Deployment", Namespace: "shop", Count: 2},
+		{Kind: "Ingress", Namespace: "shop", Count: 1},
+		{Kind: "Namespace", Namespace: "", Count: 1},
+		{Kind: "Service", Namespace: "shop", Count: 2},
+	}
+	if !reflect.DeepEqual(c.Inventory, wantInventory) {
+		t.Errorf("Inventory = %+v, want %+v", c.Inventory, wantInventory)
+	}
+	if c.Kinds != 4 || !reflect.DeepEqual(c.Namespaces, []string{"shop"}) {
+		t.Errorf("Kinds, Namespaces = %d, %v, want 4, [shop]", c.Kinds, c.Namespaces)
+	}
+
+	// Images are sorted and list every container running them, init containers marked
+	wantImages := []imageRow{
+		{Image: "envoyproxy/envoy:v1.29", UsedBy: []string{"Deployment/shop/web (proxy)"}},
+		{Image: "shop/web:1.2", UsedBy: []string{"Deployment/shop/web (migrate, init)", "Deployment/shop/web (app)"}},
+		{Image: "shop/worker:1.2", UsedBy: []string{"Deployment/shop/worker (worker)"}},
+	}
+	if !reflect.DeepEqual(c.Images, wantImages) {
+		t.Errorf("Images = %+v, want %+v", c.Images, wantImages)
+	}
+
+	wantPorts := []portRow{
+		{Workload: "Deployment/shop/web", Container: "app", Port: "8080/TCP", Name: "http"},
+		{Workload: "Deployment/shop/web", Container: "app", Port: "9090/UDP"},
+	}
+	if !reflect.DeepEqual(c.Ports, wantPorts) {
+		t.Errorf("Ports = %+v, want %+v", c.Ports, wantPorts)
+	}
+
+	// Backends are the workloads a Service selects
+	wantServices := []serviceRow{
+		{Service: "Service/shop/web", Type: "NodePort", Ports: []string{"80:8080/TCP (node port 30080)"}, Backends: []string{"Deployment/shop/web"}},
+		{Service: "Service/shop/orphan", Type: "ClusterIP"},
+	}
+	if !reflect.DeepEqual(c.Services, wantServices) {
+		t.Errorf("Services = %+v, want %+v", c.Services, wantServices)
+	}
+
+	wantRoutes := []routeRow{{Ingress: "Ingress/shop/web", Host: "shop.example.com", Path: "/", Backend: "web:80"}}
+	if !reflect.DeepEqual(c.Routes, wantRoutes) {
+		t.Errorf("Routes = %+v, want %+v", c.Routes, wantRoutes)
+	}
+
+	if len(c.Graph.Nodes) != 6 || c.Graph.Nodes[0].Group != "" || c.Graph.Nodes[1].Group != "namespace shop" || c.Graph.Nodes[1].Label != "Deployment\nweb" {
+		t.Errorf("Graph nodes = %+v", c.Graph.Nodes)
+	}
+}
+
+func TestBuildEmpty(t *testing.T) {
+	c := build(&Report{Title: "empty"})
+	if c.Inventory != nil || c.Images != nil || c.Ports != nil || c.Services != nil || c.Routes != nil || c.Kinds != 0 {
+		t.Errorf("build of no resources = %+v", c)
+	}
+	md := Markdown(&Report{Title: "empty"})
+	for _, want := range []string{"No design was created.", "## Inventory\n\nNone.", "No relationships were found between the resources.", "## Warnings\n\nNone."} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown of an empty report misses %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestMarkdownEscaping(t *testing.T) {
+	resources, err := manifest.Parse("odd.yaml", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata: {name: "a|b"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := Markdown(&Report{
+		Title:     "odd",
+		Resources: resources,
+		Designs:   []Design{{Name: "x|y", Status: "failed: line one\nline two"}},
+		Warnings:  []string{"pipe | and\nline break"},
+	})
+
+	for _, want := range []string{
+		// Pipes and line breaks would end the table cell or list item
+		"| x\\|y | failed: line one line two | - |",
+		"- pipe \\| and line break\n",
+		// Mermaid labels escape pipes and quotes and break lines with <br/>
+		`r0["ConfigMap<br/>a#124;b"]`,
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown misses %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestHTMLEscaping(t *testing.T) {
+	page, err := HTML(&Report{
+		Title:    "<shop>",
+		Designs:  []Design{{Name: "<b>shop</b>", ViewURL: "javascript:alert(1)"}},
+		Warnings: []string{"<script>alert(1)</script>"},
+	})
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	for _, bad := range []string{"<shop>", "<b>shop</b>", "<script>alert(1)</script>", `href="javascript:`} {
+		if strings.Contains(page, bad) {
+			t.Errorf("HTML holds unescaped %q", bad)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	r := testReport(t)
+	page, err := HTML(r)
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	for file, got := range map[string]string{"report.md": Markdown(r), "report.html": page} {
+		t.Run(file, func(t *testing.T) {
+			path := filepath.Join("testdata", file)
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("%s differs from %s, run go test -update to rewrite it:\n%s", file, path, got)
+			}
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"report.md", FormatMarkdown},
+		{"report.MARKDOWN", FormatMarkdown},
+		{"out/report.html", FormatHTML},
+		{"report.htm", FormatHTML},
+		{"report.txt", ""},
+		{"report", ""},
+	}
+	for _, tt := range tests {
+		got, err := FormatOf(tt.path)
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("FormatOf(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	r := testReport(t)
+	for _, name := range []string{"report.md", "report.html"} {
+		path := filepath.Join(dir, name)
+		if err := Write(path, r); err != nil {
+			t.Fatalf("Write(%s): %v", name, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, ".html") != strings.HasPrefix(string(data), "<!DOCTYPE html>") {
+			t.Errorf("%s was written in the wrong format: %.40q", name, data)
+		}
+	}
+	if err := Write(filepath.Join(dir, "report.txt"), r); err == nil {
+		t.Error("Write of a .txt report succeeded, want an error")
+	}
+}
@@ -0,0 +1,302 @@
+package schema
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed builtin.yaml
+var builtinYAML []byte
+
+// Range of Kubernetes versions the bundled schemas describe
+var (
+	MinVersion = Version{Major: 1, Minor: 16}
+	MaxVersion = Version{Major: 1, Minor: 33}
+)
+
+// Version is a Kubernetes minor version
+type Version struct {
+	Major int
+	Minor int
+}
+
+// ParseVersion parses a version such as 1.29, v1.29 or 1.29.3; the patch is ignored
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid Kubernetes version '%s': expected <major>.<minor>, e.g. 1.29", s)
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || major < 0 || minor < 0 {
+		return Version{}, fmt.Errorf("invalid Kubernetes version '%s': expected <major>.<minor>, e.g. 1.29", s)
+	}
+	return Version{Major: major, Minor: minor}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Less reports whether v is older than o
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	return v.Minor < o.Minor
+}
+
+// builtin is the layout of builtin.yaml
+type builtin struct {
+	Definitions map[string]interface{} `yaml:"definitions"`
+	Resources   []struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Schema     string `yaml:"schema"`
+		Added      string `yaml:"added"`
+	} `yaml:"resources"`
+	Removed []struct {
+		APIVersion  string   `yaml:"apiVersion"`
+		Kinds       []string `yaml:"kinds"`
+		Removed     string   `yaml:"removed"`
+		Replacement string   `yaml:"replacement"`
+	} `yaml:"removed"`
+}
+
+// gvk identifies a kind in a group version, e.g. apps/v1 Deployment
+type gvk struct {
+	APIVersion string
+	Kind       string
+}
+
+func (k gvk) String() string {
+	return k.APIVersion + " " + k.Kind
+}
+
+// removal describes a group version of a kind that is no longer served
+type removal struct {
+	Removed     Version
+	Replacement string
+}
+
+// Registry holds the schemas of the kinds served by a Kubernetes version
+type Registry struct {
+	version     Version
+	definitions map[string]*Schema
+	kinds       map[gvk]*Schema
+	// added is the version that added a kind, if later than MinVersion
+	added   map[gvk]Version
+	removed map[gvk]removal
+	// crds are the kinds with schemas from CustomResourceDefinitions
+	crds map[gvk]bool
+}
+
+// Builtin returns a registry of the built-in kinds of the Kubernetes version
+func Builtin(kubeVersion string) (*Registry, error) {
+	version, err := ParseVersion(kubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	if version.Less(MinVersion) || MaxVersion.Less(version) {
+		return nil, fmt.Errorf("unsupported Kubernetes version %s: schemas are bundled for %s to %s", version, MinVersion, MaxVersion)
+	}
+
+	var b builtin
+	if err := yaml.Unmarshal(builtinYAML, &b); err != nil {
+		return nil, fmt.Errorf("error parsing bundled schemas: %w", err)
+	}
+	r := &Registry{
+		version:     version,
+		definitions: make(map[string]*Schema, len(b.Definitions)),
+		kinds:       make(map[gvk]*Schema, len(b.Resources)),
+		added:       make(map[gvk]Version),
+		removed:     make(map[gvk]removal),
+		crds:        make(map[gvk]bool),
+	}
+	for name, def := range b.Definitions {
+		s, err := fromObject(def)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing bundled schema %s: %w", name, err)
+		}
+		r.definitions[name] = s
+	}
+	for _, res := range b.Resources {
+		fields, ok := r.definitions[res.Schema]
+		if !ok {
+			return nil, fmt.Errorf("bundled schema %s of %s %s is not defined", res.Schema, res.APIVersion, res.Kind)
+		}
+		k := gvk{APIVersion: res.APIVersion, Kind: res.Kind}
+		r.kinds[k] = topLevel(fields)
+		if res.Added != "" {
+			if r.added[k], err = ParseVersion(res.Added); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, rm := range b.Removed {
+		removed, err := ParseVersion(rm.Removed)
+		if err != nil {
+			return nil, err
+		}
+		for _, kind := range rm.Kinds {
+			r.removed[gvk{APIVersion: rm.APIVersion, Kind: kind}] = removal{Removed: removed, Replacement: rm.Replacement}
+		}
+	}
+	return r, nil
+}
+
+// Version returns the Kubernetes version of the registry
+func (r *Registry) Version() Version {
+	return r.version
+}
+
+// topLevel returns the schema of a resource with the fields of the kind, and the
+// apiVersion, kind, metadata and status fields common to all resources
+func topLevel(fields *Schema) *Schema {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"apiVersion": {Type: "string"},
+			"kind":       {Type: "string"},
+			"metadata":   {Ref: "ObjectMeta"},
+			"status":     {PreserveUnknownFields: true},
+		},
+		Required:              fields.Required,
+		PreserveUnknownFields: fields.PreserveUnknownFields,
+	}
+	for name, prop := range fields.Properties {
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// AddCRD adds the schemas of the versions of a CustomResourceDefinition, in
+// apiextensions.k8s.io/v1 or v1beta1. Versions without a schema accept any fields.
+func (r *Registry) AddCRD(obj map[string]interface{}) error {
+	spec, _ := obj["spec"].(map[string]interface{})
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]interface{})
+	kind, _ := names["kind"].(string)
+	if group == "" || kind == "" {
+		return fmt.Errorf("CustomResourceDefinition has no spec.group or spec.names.kind")
+	}
+
+	// v1beta1 has a schema for all versions in spec.validation, and may name a single version
+	shared := nestedMap(spec, "validation", "openAPIV3Schema")
+	versions, _ := spec["versions"].([]interface{})
+	if v, ok := spec["version"].(string); ok && len(versions) == 0 {
+		versions = []interface{}{map[string]interface{}{"name": v}}
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("CustomResourceDefinition %s has no versions", kind)
+	}
+
+	for _, item := range versions {
+		version, _ := item.(map[string]interface{})
+		name, _ := version["name"].(string)
+		if name == "" {
+			continue
+		}
+		raw := nestedMap(version, "schema", "openAPIV3Schema")
+		if raw == nil {
+			raw = shared
+		}
+		s := &Schema{Type: "object", PreserveUnknownFields: true}
+		if raw != nil {
+			var err error
+			if s, err = fromObject(raw); err != nil {
+				return fmt.Errorf("error parsing schema of %s/%s %s: %w", group, name, kind, err)
+			}
+		}
+		k := gvk{APIVersion: group + "/" + name, Kind: kind}
+		r.kinds[k] = crdTopLevel(s)
+		r.crds[k] = true
+	}
+	return nil
+}
+
+// crdTopLevel adds the fields common to all resources to a custom resource schema
+func crdTopLevel(s *Schema) *Schema {
+	top := *s
+	top.Type = "object"
+	top.Properties = map[string]*Schema{
+		"apiVersion": {Type: "string"},
+		"kind":       {Type: "string"},
+		"metadata":   {Ref: "ObjectMeta"},
+	}
+	for name, prop := range s.Properties {
+		// The API server only checks the name and namespace of custom resource metadata
+		if name != "metadata" {
+			top.Properties[name] = prop
+		}
+	}
+	return &top
+}
+
+// HasSchema reports whether the registry knows the kind of the resource
+func (r *Registry) HasSchema(res manifest.Resource) bool {
+	k := gvk{APIVersion: res.APIVersion, Kind: res.Kind}
+	_, ok := r.kinds[k]
+	_, removed := r.removed[k]
+	return ok || removed
+}
+
+// Validate checks a resource against the schema of its kind. Resources of kinds the
+// registry does not know are only checked for apiVersion, kind and name; HasSchema
+// tells them apart.
+func (r *Registry) Validate(res manifest.Resource) []Violation {
+	v := &validator{definitions: r.definitions, version: r.version}
+	if res.APIVersion == "" {
+		v.report(nil, "missing required field %q", "apiVersion")
+	}
+	if res.Kind == "" {
+		v.report(nil, "missing required field %q", "kind")
+	}
+	if res.Name == "" && nestedString(res.Object, "metadata", "generateName") == "" {
+		v.report([]string{"metadata"}, "missing required field %q", "name")
+	}
+	if len(v.violations) > 0 {
+		return v.violations
+	}
+
+	k := gvk{APIVersion: res.APIVersion, Kind: res.Kind}
+	if rm, ok := r.removed[k]; ok && !r.version.Less(rm.Removed) && !r.crds[k] {
+		msg := fmt.Sprintf("%s is not served by Kubernetes %s, it was removed in %s", k, r.version, rm.Removed)
+		if rm.Replacement != "" {
+			msg += fmt.Sprintf("; use %s", rm.Replacement)
+		}
+		v.report([]string{"apiVersion"}, "%s", msg)
+		return v.violations
+	}
+	if added, ok := r.added[k]; ok && r.version.Less(added) {
+		v.report([]string{"apiVersion"}, "%s is not served by Kubernetes %s, it was added in %s", k, r.version, added)
+		return v.violations
+	}
+
+	if s, ok := r.kinds[k]; ok {
+		v.validate(nil, res.Object, s)
+	}
+	return v.violations
+}
+
+func nestedMap(obj map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, k := range keys {
+		next, ok := obj[k].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = next
+	}
+	return obj
+}
+
+func nestedString(obj map[string]interface{}, keys ...string) string {
+	m := nestedMap(obj, keys[:len(keys)-1]...)
+	s, _ := m[keys[len(keys)-1]].(string)
+	return s
+}
@@ -0,0 +1,282 @@
+// Package schema validates Kubernetes objects against OpenAPI v3 schemas: a bundled subset
+// of the built-in kinds for a selected Kubernetes version, and the schemas of custom
+// resources read from CustomResourceDefinitions.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of an OpenAPI v3 schema used by Kubernetes structural schemas
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *BoolOrSchema      `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	// Ref names a definition of the bundled schemas
+	Ref string `json:"$ref,omitempty"`
+	// IntOrString accepts integers and strings, such as ports and quantities
+	IntOrString bool `json:"x-kubernetes-int-or-string,omitempty"`
+	// PreserveUnknownFields accepts fields that are not listed in Properties
+	PreserveUnknownFields bool `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	// EmbeddedResource is an object with its own apiVersion, kind and metadata
+	EmbeddedResource bool `json:"x-kubernetes-embedded-resource,omitempty"`
+	// Added is the Kubernetes version that added the field
+	Added string `json:"x-kanvas-added,omitempty"`
+}
+
+// BoolOrSchema is an additionalProperties value: false, true or the schema of the values
+type BoolOrSchema struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON decodes a boolean or a schema
+func (b *BoolOrSchema) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Allowed); err == nil {
+		return nil
+	}
+	b.Allowed = true
+	return json.Unmarshal(data, &b.Schema)
+}
+
+// fromObject converts a schema decoded from YAML or JSON
+func fromObject(obj interface{}) (*Schema, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Violation is a field of an object that does not match its schema
+type Violation struct {
+	// Path is the path of the field, with numbers for the items of lists
+	Path    []string
+	Message string
+	// Warning marks fields the schemas do not list. The bundled schemas are trimmed, so
+	// such a field may still be valid and does not fail validation.
+	Warning bool
+}
+
+// Field returns the path in the notation of kubectl explain, e.g. spec.containers[0].image
+func (v Violation) Field() string {
+	return FieldPath(v.Path)
+}
+
+func (v Violation) String() string {
+	if len(v.Path) == 0 {
+		return v.Message
+	}
+	return v.Field() + ": " + v.Message
+}
+
+// FieldPath formats a field path, e.g. spec.containers[0].image
+func FieldPath(path []string) string {
+	var b strings.Builder
+	for _, p := range path {
+		if _, err := strconv.Atoi(p); err == nil {
+			fmt.Fprintf(&b, "[%s]", p)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// validator checks a value against a schema, resolving references to definitions
+type validator struct {
+	definitions map[string]*Schema
+	version     Version
+	violations  []Violation
+}
+
+func (v *validator) report(path []string, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Path: append([]string(nil), path...), Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warn(path []string, format string, args ...interface{}) {
+	v.report(path, format, args...)
+	v.violations[len(v.violations)-1].Warning = true
+}
+
+// validate checks value at path against s. Null values are accepted everywhere,
+// since the API server treats them as unset.
+func (v *validator) validate(path []string, value interface{}, s *Schema) {
+	if s == nil || value == nil {
+		return
+	}
+	if s.Added != "" {
+		if added, err := ParseVersion(s.Added); err == nil && v.version.Less(added) {
+			v.report(path, "field is not available before Kubernetes %s", added)
+			return
+		}
+	}
+	if s.Ref != "" {
+		def, ok := v.definitions[s.Ref]
+		if !ok {
+			v.report(path, "unknown schema definition %s", s.Ref)
+			return
+		}
+		v.validate(path, value, def)
+		return
+	}
+
+	if s.IntOrString {
+		if !isInteger(value) && !isString(value) {
+			v.report(path, "expected integer or string, got %s", describe(value))
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.report(path, "expected object, got %s", describe(value))
+			return
+		}
+		v.validateObject(path, obj, s)
+		return
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.report(path, "expected array, got %s", describe(value))
+			return
+		}
+		for i, item := range items {
+			v.validate(append(path, strconv.Itoa(i)), item, s.Items)
+		}
+		return
+	case "string":
+		if !isString(value) {
+			v.report(path, "expected string, got %s", describe(value))
+			return
+		}
+	case "integer":
+		if !isInteger(value) {
+			v.report(path, "expected integer, got %s", describe(value))
+			return
+		}
+	case "number":
+		if !isInteger(value) && !isFloat(value) {
+			v.report(path, "expected number, got %s", describe(value))
+			return
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.report(path, "expected boolean, got %s", describe(value))
+			return
+		}
+	case "":
+		// Untyped schemas, such as x-kubernetes-preserve-unknown-fields, accept any value
+		if obj, ok := value.(map[string]interface{}); ok && (len(s.Properties) > 0 || len(s.Required) > 0) {
+			v.validateObject(path, obj, s)
+		}
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				return
+			}
+		}
+		allowed := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			allowed[i] = strconv.Quote(fmt.Sprint(e))
+		}
+		v.report(path, "unsupported value %q, expected one of %s", fmt.Sprint(value), strings.Join(allowed, ", "))
+	}
+}
+
+// validateObject checks the required and known fields of an object. Objects without
+// properties accept any fields, as do those preserving unknown fields.
+func (v *validator) validateObject(path []string, obj map[string]interface{}, s *Schema) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			v.report(path, "missing required field %q", name)
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fieldPath := append(path, k)
+		if prop, ok := s.Properties[k]; ok {
+			v.validate(fieldPath, obj[k], prop)
+			continue
+		}
+		if s.EmbeddedResource && (k == "apiVersion" || k == "kind" || k == "metadata") {
+			continue
+		}
+		switch {
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			v.validate(fieldPath, obj[k], s.AdditionalProperties.Schema)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Allowed,
+			s.PreserveUnknownFields,
+			len(s.Properties) == 0 && s.AdditionalProperties == nil:
+		default:
+			v.warn(fieldPath, "unknown field")
+		}
+	}
+}
+
+func isString(value interface{}) bool {
+	switch value.(type) {
+	case string, time.Time:
+		return true
+	}
+	return false
+}
+
+func isInteger(value interface{}) bool {
+	switch n := value.(type) {
+	case int, int64, uint64:
+		return true
+	case float64:
+		return n == math.Trunc(n)
+	}
+	return false
+}
+
+func isFloat(value interface{}) bool {
+	_, ok := value.(float64)
+	return ok
+}
+
+// describe returns the type and value of a decoded YAML value for messages
+func describe(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case bool:
+		return fmt.Sprintf("boolean %v", value)
+	case int, int64, uint64, float64:
+		return fmt.Sprintf("number %v", value)
+	}
+	return fmt.Sprintf("%T", value)
+}
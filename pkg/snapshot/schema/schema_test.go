@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/manifest"
+)
+
+// loadResources parses a manifest file in testdata
+func loadResources(t *testing.T, name string) []manifest.Resource {
+	t.Helper()
+	files, err := manifest.Load(filepath.Join("testdata", name), manifest.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resources []manifest.Resource
+	for _, f := range files {
+		if f.ParseErr != nil {
+			t.Fatal(f.ParseErr)
+		}
+		resources = append(resources, f.Resources...)
+	}
+	return resources
+}
+
+func builtinRegistry(t *testing.T, version string) *Registry {
+	t.Helper()
+	r, err := Builtin(version)
+	if err != nil {
+		t.Fatalf("Builtin(%s): %v", version, err)
+	}
+	return r
+}
+
+func TestValidateRealisticManifests(t *testing.T) {
+	registry := builtinRegistry(t, "1.33")
+	resources := loadResources(t, "app.yaml")
+	if len(resources) != 4 {
+		t.Fatalf("loaded %d resources, want 4", len(resources))
+	}
+	for _, r := range resources {
+		if !registry.HasSchema(r) {
+			t.Errorf("no schema for %s %s", r.APIVersion, r.Kind)
+		}
+		for _, v := range registry.Validate(r) {
+			t.Errorf("%s %s: %s (warning: %v)", r.Kind, r.Name, v, v.Warning)
+		}
+	}
+}
+
+func TestValidateReportsLines(t *testing.T) {
+	registry := builtinRegistry(t, "1.33")
+	r := loadResources(t, "invalid.yaml")[0]
+
+	type found struct {
+		line    int
+		warning bool
+	}
+	got := map[string]found{}
+	for _, v := range registry.Validate(r) {
+		got[v.String()] = found{r.LineOf(v.Path), v.Warning}
+	}
+	want := map[string]found{
+		`spec.replicas: expected integer, got string "three"`:         {6, false},
+		`spec.template.spec.containers[0].volumeMount: unknown field`: {18, true},
+	}
+	for msg, w := range want {
+		if g, ok := got[msg]; !ok {
+			t.Errorf("missing violation %q, got %v", msg, got)
+		} else if g != w {
+			t.Errorf("%s: line %d, warning %v, want line %d, warning %v", msg, g.line, g.warning, w.line, w.warning)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got violations %v, want %v", got, want)
+	}
+}
+
+func TestValidateRemovedAPIVersion(t *testing.T) {
+	r := loadResources(t, "ingress-v1beta1.yaml")[0]
+
+	violations := builtinRegistry(t, "1.22").Validate(r)
+	if len(violations) != 1 || violations[0].Warning {
+		t.Fatalf("violations = %v, want one error", violations)
+	}
+	msg := violations[0].String()
+	if !strings.Contains(msg, "removed in 1.22") || !strings.Contains(msg, "networking.k8s.io/v1") {
+		t.Errorf("violation = %q, want the removal and the replacement", msg)
+	}
+	if line := r.LineOf(violations[0].Path); line != 1 {
+		t.Errorf("line = %d, want 1", line)
+	}
+
+	// Still served before its removal
+	for _, v := range builtinRegistry(t, "1.21").Validate(r) {
+		if !v.Warning {
+			t.Errorf("Kubernetes 1.21: %s", v)
+		}
+	}
+}
+
+func TestParseVersionRange(t *testing.T) {
+	for _, v := range []string{"1.15", "1.34", "one.two", "1"} {
+		if _, err := Builtin(v); err == nil {
+			t.Errorf("Builtin(%s) succeeded, want an error", v)
+		}
+	}
+	for _, v := range []string{"1.16", "v1.29.3", "1.33"} {
+		if _, err := Builtin(v); err != nil {
+			t.Errorf("Builtin(%s): %v", v, err)
+		}
+	}
+}
+
+func TestAddCRD(t *testing.T) {
+	registry := builtinRegistry(t, "1.33")
+	cr := loadResources(t, "crontab.yaml")[0]
+	if registry.HasSchema(cr) {
+		t.Fatal("CronTab has a schema before its CRD is added")
+	}
+
+	if err := registry.AddCRD(loadResources(t, "crontab-crd.yaml")[0].Object); err != nil {
+		t.Fatalf("AddCRD: %v", err)
+	}
+	if !registry.HasSchema(cr) {
+		t.Fatal("CronTab has no schema after its CRD is added")
+	}
+	violations := registry.Validate(cr)
+	if len(violations) != 1 || violations[0].String() != `spec.replicas: expected integer, got string "2"` {
+		t.Fatalf("violations = %v, want spec.replicas", violations)
+	}
+	if line := cr.LineOf(violations[0].Path); line != 7 {
+		t.Errorf("line = %d, want 7", line)
+	}
+
+	if err := registry.AddCRD(map[string]interface{}{"spec": map[string]interface{}{}}); err == nil {
+		t.Error("AddCRD of a CRD without group and kind succeeded, want an error")
+	}
+}
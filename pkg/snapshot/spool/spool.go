@@ -0,0 +1,193 @@
+// Package spool queues design uploads and workflow dispatches that could not reach
+// Meshery or GitHub, so they can be retried later.
+package spool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meshery/kubectl-kanvas-snapshot/pkg/snapshot/filelock"
+)
+
+// Item is a queued upload, a queued workflow dispatch, or an upload followed by a dispatch
+type Item struct {
+	// ID is the name of the item's file without extension, assigned by Add
+	ID        string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Server is the Meshery server the design belongs to
+	Server string `json:"server"`
+	// Profile and Context are the configuration profile and mesheryctl context the item was
+	// queued with. They select the tokens it is sent with.
+	Profile   string `json:"profile,omitempty"`
+	Context   string `json:"context,omitempty"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError,omitempty"`
+	// Upload is the design import request, set until the design is created
+	Upload *Upload `json:"upload,omitempty"`
+	// Dispatch is the snapshot workflow to trigger once the design exists
+	Dispatch *Dispatch `json:"dispatch,omitempty"`
+}
+
+// Upload is a prepared design import request
+type Upload struct {
+	Endpoint   string `json:"endpoint"`
+	DesignName string `json:"designName"`
+	// Payload is the request body, sent as is
+	Payload json.RawMessage `json:"payload"`
+	// Hash and Inputs are the manifest hash and paths, for the design cache and history
+	Hash   string   `json:"hash"`
+	Inputs []string `json:"inputs"`
+}
+
+// Dispatch is a snapshot workflow dispatch. DesignID and AssetLocation are empty
+// while the item still has an upload.
+type Dispatch struct {
+	DesignID      string `json:"designID,omitempty"`
+	AssetLocation string `json:"assetLocation,omitempty"`
+	Owner         string `json:"owner"`
+	Repo          string `json:"repo"`
+	Workflow      string `json:"workflow"`
+	Ref           string `json:"ref"`
+}
+
+// Name describes the item for log messages
+func (i *Item) Name() string {
+	if i.Upload != nil {
+		return i.Upload.DesignName
+	}
+	if i.Dispatch != nil {
+		return i.Dispatch.DesignID
+	}
+	return i.ID
+}
+
+// Kind describes what is queued: upload, dispatch or upload+dispatch
+func (i *Item) Kind() string {
+	var kinds []string
+	if i.Upload != nil {
+		kinds = append(kinds, "upload")
+	}
+	if i.Dispatch != nil {
+		kinds = append(kinds, "dispatch")
+	}
+	return strings.Join(kinds, "+")
+}
+
+// Spool is a directory holding one JSON file per queued item
+type Spool struct {
+	dir string
+}
+
+// New returns a spool stored in dir
+func New(dir string) *Spool {
+	return &Spool{dir: dir}
+}
+
+// DefaultDir returns the spool directory, ~/.meshery/kubectl-kanvas-snapshot/spool
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".meshery", "kubectl-kanvas-snapshot", "spool"), nil
+}
+
+// Dir returns the spool directory
+func (s *Spool) Dir() string {
+	return s.dir
+}
+
+// Add queues the item, assigning its ID and creation time
+func (s *Spool) Add(item *Item) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	item.CreatedAt = time.Now().UTC()
+	// IDs sort in creation order
+	item.ID = item.CreatedAt.Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix)
+	return s.Update(item)
+}
+
+// Update replaces the stored item with the same ID
+func (s *Spool) Update(item *Item) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("error creating spool directory: %w", err)
+	}
+
+	// Write through a temporary file so a concurrent flush never reads a partial item
+	if err := filelock.WriteFileAtomic(s.path(item.ID), data); err != nil {
+		return fmt.Errorf("error writing spool item: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the item. Removing an absent item is not an error.
+func (s *Spool) Remove(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid spool item ID '%s'", id)
+	}
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing spool item: %w", err)
+	}
+	return nil
+}
+
+// List returns the queued items, oldest first. A missing directory is an empty spool.
+func (s *Spool) List() ([]*Item, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading spool directory: %w", err)
+	}
+
+	var items []*Item
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading spool item: %w", err)
+		}
+		item := &Item{}
+		if err := json.Unmarshal(data, item); err != nil {
+			return nil, fmt.Errorf("error parsing spool item %s: %w", name, err)
+		}
+		item.ID = strings.TrimSuffix(name, ".json")
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+// Lock takes the spool lock, so only one flush processes the queue at a time
+func (s *Spool) Lock() (func(), error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating spool directory: %w", err)
+	}
+	unlock, err := filelock.Lock(filepath.Join(s.dir, ".lock"))
+	if err != nil {
+		return nil, fmt.Errorf("error locking spool: %w", err)
+	}
+	return unlock, nil
+}
+
+// path returns the file of the item with the given ID
+func (s *Spool) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
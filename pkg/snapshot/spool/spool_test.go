@@ -0,0 +1,110 @@
+package spool
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "spool"))
+	if items, err := s.List(); err != nil || len(items) != 0 {
+		t.Fatalf("List of a missing directory = %v, %v, want none", items, err)
+	}
+
+	upload := &Item{
+		Server: "http://localhost:9081",
+		Upload: &Upload{Endpoint: "/api/pattern/import", DesignName: "web", Payload: json.RawMessage(`{"name":"web"}`)},
+		// Filled in once the upload created the design
+		Dispatch: &Dispatch{Owner: "octo", Repo: "app", Workflow: "kanvas.yaml", Ref: "master"},
+	}
+	dispatch := &Item{Server: "http://localhost:9081", Dispatch: &Dispatch{DesignID: "d-1", Owner: "octo", Repo: "app", Workflow: "kanvas.yaml", Ref: "master"}}
+	for _, item := range []*Item{upload, dispatch} {
+		if err := s.Add(item); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if upload.ID == "" || upload.ID == dispatch.ID || upload.CreatedAt.IsZero() {
+		t.Fatalf("Add assigned IDs %q and %q at %v", upload.ID, dispatch.ID, upload.CreatedAt)
+	}
+	if upload.Kind() != "upload+dispatch" || upload.Name() != "web" || dispatch.Kind() != "dispatch" || dispatch.Name() != "d-1" {
+		t.Errorf("Kind and Name = %s %s, %s %s", upload.Kind(), upload.Name(), dispatch.Kind(), dispatch.Name())
+	}
+
+	// Items are listed oldest first
+	items, err := s.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != upload.ID || items[1].ID != dispatch.ID {
+		t.Fatalf("List = %v, want the upload then the dispatch", items)
+	}
+	// The payload is stored indented
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, items[0].Upload.Payload); err != nil || payload.String() != `{"name":"web"}` {
+		t.Errorf("listed payload = %s, %v", items[0].Upload.Payload, err)
+	}
+	items[0].Upload.Payload = upload.Upload.Payload
+	if !reflect.DeepEqual(items[0].Upload, upload.Upload) || !reflect.DeepEqual(items[0].Dispatch, upload.Dispatch) {
+		t.Errorf("listed item = %+v %+v, want %+v %+v", items[0].Upload, items[0].Dispatch, upload.Upload, upload.Dispatch)
+	}
+
+	// The upload is done: the item is rewritten as a dispatch only
+	upload.Upload = nil
+	upload.Dispatch.DesignID = "d-2"
+	upload.Attempts = 1
+	if err := s.Update(upload); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	items, _ = s.List()
+	if len(items) != 2 || items[0].Upload != nil || items[0].Dispatch.DesignID != "d-2" || items[0].Attempts != 1 || items[0].Kind() != "dispatch" {
+		t.Errorf("updated item = %+v", items[0])
+	}
+
+	if err := s.Remove(dispatch.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := s.Remove(dispatch.ID); err != nil {
+		t.Errorf("Remove of a removed item: %v", err)
+	}
+	for _, id := range []string{"", "../config", `..\config`} {
+		if err := s.Remove(id); err == nil {
+			t.Errorf("Remove(%q) succeeded, want an error", id)
+		}
+	}
+	if items, _ := s.List(); len(items) != 1 || items[0].ID != upload.ID {
+		t.Errorf("List after Remove = %v", items)
+	}
+}
+
+func TestListSkipsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	if err := s.Add(&Item{Server: "http://localhost:9081", Dispatch: &Dispatch{DesignID: "d-1"}}); err != nil {
+		t.Fatal(err)
+	}
+	// The lock, temporary files of an interrupted write and unrelated files
+	unlock, err := s.Lock()
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	defer unlock()
+	for _, name := range []string{".item.json.123.tmp", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if items, err := s.List(); err != nil || len(items) != 1 {
+		t.Errorf("List = %v, %v, want the queued item", items, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List(); err == nil {
+		t.Error("List with a corrupt item succeeded, want an error")
+	}
+}